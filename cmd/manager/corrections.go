@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/corrections"
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/store"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+)
+
+// importCorrections reads a transactions report edited by the user, records every
+// category that was changed as a correction and applies it to the ledger.
+// Corrections are learned from on the next run (see corrections.Learn).
+// Categories typed outside the taxonomy are kept as typed, with a warning.
+func importCorrections(ledger *store.Store, categories *taxonomy.Taxonomy, reportPath string, repeats int) error {
+	fmt.Printf("📝 Reading corrections from %s...\n", reportPath)
+	rows, err := corrections.ReadReport(reportPath)
+	if err != nil {
+		return err
+	}
+
+	// Rows are matched to the stored transactions of their statement file
+	var sources []string
+	bySource := make(map[string][]corrections.Row)
+	for _, row := range rows {
+		if _, ok := bySource[row.Source]; !ok {
+			sources = append(sources, row.Source)
+		}
+		bySource[row.Source] = append(bySource[row.Source], row)
+	}
+
+	now := time.Now().UTC()
+	var recorded []*models.Correction
+	unmatched := 0
+	for _, source := range sources {
+		stored, err := ledger.TransactionsBySource(source)
+		if err != nil {
+			return err
+		}
+		changes, missing := corrections.Diff(bySource[source], stored)
+		unmatched += missing
+		if len(changes) == 0 {
+			continue
+		}
+
+		for _, change := range changes {
+			t := change.Transaction
+			// Categories may be typed as IDs or names; they are recorded as the taxonomy names them
+			category, ok := categories.Find(change.Row.Category, change.Row.Subcategory)
+			if ok {
+				change.Row.Category, change.Row.Subcategory = category.Root().Name, category.Path()
+				if change.Row.Category == t.Category && change.Row.Subcategory == t.Subcategory {
+					continue
+				}
+			} else {
+				fmt.Printf("⚠️  Warning: line %d: category %q is not in the category taxonomy\n", change.Row.Line, change.Row.Category)
+			}
+			fmt.Printf("   %s %s: %s / %s -> %s / %s\n", t.Date.Format("2006-01-02"), t.Description,
+				t.Category, t.Subcategory, change.Row.Category, change.Row.Subcategory)
+			recorded = append(recorded, change.Correction(now))
+
+			t.Category = change.Row.Category
+			t.Subcategory = change.Row.Subcategory
+			t.CategoryID = ""
+			if ok {
+				t.CategoryID = category.ID
+			}
+			t.Confidence = 1.0
+			t.CategorizedBy = models.CategorizedByUser
+		}
+		// All rows of the source are written so identical rows keep their ledger identity
+		if err := ledger.UpsertTransactions(stored); err != nil {
+			return err
+		}
+	}
+
+	if err := ledger.SaveCorrections(recorded); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Recorded %d corrections from %d rows\n", len(recorded), len(rows))
+	if unmatched > 0 {
+		fmt.Printf("⚠️  Warning: %d rows did not match a transaction in the ledger and were ignored\n", unmatched)
+	}
+
+	all, err := ledger.Corrections()
+	if err != nil {
+		return err
+	}
+	learned := corrections.Learn(all, repeats)
+	fmt.Printf("🧠 From %d corrections: %d merchant rules, %d examples for the model\n",
+		len(all), learned.Rules(), len(learned.Examples()))
+	return nil
+}
+
+// runCorrections runs the corrections command: manager [flags] corrections <report.csv>
+func runCorrections(ledgerPath, taxonomyPath string, args []string, repeats int) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: manager [flags] corrections <edited transactions CSV>")
+	}
+	categories, err := taxonomy.Load(taxonomyPath)
+	if err != nil {
+		log.Fatalf("Failed to load category taxonomy: %v", err)
+	}
+	ledger, err := store.Open(ledgerPath)
+	if err != nil {
+		log.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+	if err := importCorrections(ledger, categories, args[0], repeats); err != nil {
+		log.Fatalf("Failed to import corrections: %v", err)
+	}
+}
@@ -1,18 +1,42 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/KerynSuoress/finance-manager/internal/analyzer"
+	"github.com/KerynSuoress/finance-manager/internal/corrections"
+	"github.com/KerynSuoress/finance-manager/internal/dedupe"
 	"github.com/KerynSuoress/finance-manager/internal/extractor"
+	"github.com/KerynSuoress/finance-manager/internal/fx"
+	"github.com/KerynSuoress/finance-manager/internal/importer"
+	"github.com/KerynSuoress/finance-manager/internal/journal"
+	"github.com/KerynSuoress/finance-manager/internal/llm"
 	"github.com/KerynSuoress/finance-manager/internal/loader"
 	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/parser"
+	"github.com/KerynSuoress/finance-manager/internal/payee"
+	"github.com/KerynSuoress/finance-manager/internal/reconcile"
+	"github.com/KerynSuoress/finance-manager/internal/rules"
+	"github.com/KerynSuoress/finance-manager/internal/store"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+	"github.com/KerynSuoress/finance-manager/internal/transfers"
+	"github.com/KerynSuoress/finance-manager/internal/usage"
+	"github.com/KerynSuoress/finance-manager/internal/workpool"
 )
 
+// errNoTransactions is journaled for statements from which no transactions were extracted
+var errNoTransactions = errors.New("no transactions found")
+
 // readTextFile reads the content of a text file
 func readTextFile(filePath string) (string, error) {
 	content, err := os.ReadFile(filePath)
@@ -22,109 +46,544 @@ func readTextFile(filePath string) (string, error) {
 	return string(content), nil
 }
 
+// printCacheStats prints what the response cache saved during the run
+func printCacheStats(c *llm.Cache) {
+	stats := c.Stats()
+	fmt.Printf("\n💾 Response cache: %d hits, %d misses, %d stored, %d expired, %d evicted\n",
+		stats.Hits, stats.Misses, stats.Writes, stats.Expired, stats.Evictions)
+	fmt.Printf("   Saved %d input and %d output tokens; cache holds %d responses (%.1f MB)\n",
+		stats.SavedTokens.InputTokens, stats.SavedTokens.OutputTokens, stats.Entries, float64(stats.Bytes)/(1<<20))
+}
+
+// findDuplicates marks the exact duplicates among transactions and writes the
+// duplicates report when any were found
+func findDuplicates(transactions []*models.Transaction, opts dedupe.Options, reportFolder string) {
+	result := dedupe.Find(transactions, opts)
+	if len(result.Exact) == 0 && len(result.Likely) == 0 {
+		return
+	}
+	if len(result.Exact) > 0 {
+		fmt.Printf("🔁 Dropped %d transactions listed in more than one statement file\n", len(result.Exact))
+	}
+	if err := os.MkdirAll(reportFolder, 0755); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to create output folder: %v\n", err)
+		return
+	}
+	path := filepath.Join(reportFolder, "duplicates_"+time.Now().Format("20060102")+".csv")
+	if err := dedupe.WriteReport(path, result); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+		return
+	}
+	if len(result.Likely) > 0 {
+		fmt.Printf("🔁 %d likely duplicates were kept; check them in %s\n", len(result.Likely), path)
+	}
+}
+
+// findTransfers pairs the transfers between the user's own accounts and writes the
+// transfers report when any were found
+func findTransfers(transactions []*models.Transaction, opts transfers.Options, reportFolder string) {
+	pairs := transfers.Find(transactions, opts)
+	if len(pairs) == 0 {
+		return
+	}
+	cardPayments := 0
+	for _, p := range pairs {
+		if p.Kind == models.TransferCardPayment {
+			cardPayments++
+		}
+	}
+	fmt.Printf("🔀 Paired %d transfers between your accounts (%d credit card payments); they are not counted as income or expenses\n",
+		len(pairs), cardPayments)
+	if err := os.MkdirAll(reportFolder, 0755); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to create output folder: %v\n", err)
+		return
+	}
+	path := filepath.Join(reportFolder, "transfers_"+time.Now().Format("20060102")+".csv")
+	if err := transfers.WriteReport(path, pairs); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
+}
+
+// checkReconciliation compares the transactions of every statement with the balances
+// and totals printed on it, warns about the statements that do not reconcile and writes
+// the reconciliation report when any statement could be checked
+func checkReconciliation(transactions []*models.Transaction, tolerance float64, reportFolder string) {
+	var checked []reconcile.Result
+	gaps := 0
+	for _, r := range reconcile.Statements(transactions, tolerance) {
+		if !r.Checked() {
+			continue
+		}
+		checked = append(checked, r)
+		if !r.Reconciled() {
+			gaps++
+			fmt.Printf("⚠️  Warning: %s does not reconcile: %s\n", r.Statement.Source, r.Problem())
+		}
+	}
+	if len(checked) == 0 {
+		return
+	}
+	fmt.Printf("🧮 %d of %d statements reconcile with their printed balances and totals\n", len(checked)-gaps, len(checked))
+	if err := os.MkdirAll(reportFolder, 0755); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to create output folder: %v\n", err)
+		return
+	}
+	path := filepath.Join(reportFolder, "reconciliation_"+time.Now().Format("20060102")+".csv")
+	if err := reconcile.WriteReport(path, checked); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+		return
+	}
+	if gaps > 0 {
+		fmt.Printf("🧮 Check the gaps in %s; run with -reextract to extract those statements again\n", path)
+	}
+}
+
+// writeUsageReport prints the token usage summary and writes usage.json to the output folder
+func writeUsageReport(tracker *usage.Tracker, outputFolder string) {
+	report := tracker.Report()
+	fmt.Println("\n💰 Model usage:")
+	for _, line := range report.Summary() {
+		fmt.Println("   " + line)
+	}
+	if outputFolder == "" {
+		outputFolder = "reports" // Same default as the other reports
+	}
+	if err := tracker.WriteJSON(filepath.Join(outputFolder, "usage.json")); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
+}
+
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run is the manager command. It returns instead of exiting so the deferred usage
+// report, cache statistics and ledger and journal closes run on every failure.
+func run() error {
 	// Command line flags
 	var (
 		outputFolder = flag.String("o", "output", "Path to output folder for reports (default: output)")
+		ledgerPath   = flag.String("db", "data/ledger.db", "Path to the SQLite transaction ledger")
+		fullHistory  = flag.Bool("all", false, "Generate reports from the full ledger history instead of only this run")
+		force        = flag.Bool("force", false, "Re-process statements even if they were already ingested unchanged")
+		llmOnly      = flag.Bool("llm-only", false, "Always extract transactions with Claude, even for statement layouts with a built-in parser")
+		profilesPath = flag.String("import-profiles", "config/import_profiles.yaml", "Path to the CSV/XLSX column mapping profiles")
+		extractWith  = flag.String("extractor", extractor.BackendNative, "PDF text extractor: native, python or auto (native with Python fallback)")
+		baseCurrency = flag.String("base-currency", "", "Currency report totals are converted to (default: DEFAULT_CURRENCY or COP)")
+		fxRatesPath  = flag.String("fx-rates", "config/fx_rates.csv", "Path to the CSV of exchange rates (date,currency,rate) into the base currency")
+		noCache      = flag.Bool("no-cache", false, "Always call the model instead of reusing cached responses")
+		cacheDir     = flag.String("cache-dir", "data/cache", "Directory of the on-disk model response cache")
+		cacheTTL     = flag.Duration("cache-ttl", 30*24*time.Hour, "How long cached model responses stay valid (0 = forever)")
+		cacheMaxMB   = flag.Int("cache-max-mb", 200, "Size limit of the response cache in MB; least recently used entries are evicted (0 = unlimited)")
+		pricesPath   = flag.String("prices", "config/prices.yaml", "Path to the per-model price table used for cost accounting")
+		workers      = flag.Int("workers", 4, "Number of statements (and model calls) processed concurrently")
+		budget       = flag.Float64("budget", 0, "Stop calling the model before the run's cost could exceed this amount (price table currency, 0 = unlimited)")
+		taxonomyPath = flag.String("categories", "config/categories.yaml", "Path to the category taxonomy offered to the model (built-in English categories if missing)")
+		payeesPath   = flag.String("payees", "config/payees.yaml", "Path to the payee alias table mapping cleaned descriptions to canonical merchants")
+		rulesPath    = flag.String("rules", "config/rules.yaml", "Path to the categorization rules applied before transactions are sent to the model")
+		reviewBelow  = flag.Float64("review-below", 0.7, "The review command lists model categorizations with a confidence below this threshold")
+		learnAfter   = flag.Int("learn-after", 2, "Corrections of a merchant to the same category needed before they become a rule")
+		noDedupe     = flag.Bool("no-dedupe", false, "Keep transactions listed in more than one statement file instead of dropping the exact duplicates")
+		dedupeDays   = flag.Int("dedupe-days", dedupe.DefaultOptions.WindowDays, "How many days apart the dates of likely duplicate transactions may be")
+		noTransfers  = flag.Bool("no-transfers", false, "Count transfers between your own accounts as income and expenses instead of pairing them")
+		transferDays = flag.Int("transfer-days", transfers.DefaultOptions.WindowDays, "How many days apart the two legs of a transfer between your accounts may be booked")
+		reextract    = flag.Bool("reextract", false, "Extract the pages of statements whose transactions do not reconcile with their printed balances and totals again with Claude")
+		tolerance    = flag.Float64("reconcile-tolerance", reconcile.DefaultTolerance, "Difference, in the statement currency, up to which extracted transactions reconcile with the printed figures")
+		resume       = flag.Bool("resume", false, "Resume the last unfinished run from its journal in the output folder instead of starting over")
 	)
 	flag.Parse()
 
-	// Step 1: Load all PDFs from toProcess folder
+	// Commands other than the default analysis run
+	switch flag.Arg(0) {
+	case "":
+	case "corrections":
+		runCorrections(*ledgerPath, *taxonomyPath, flag.Args()[1:], *learnAfter)
+		return nil
+	case "review":
+		runReview(*ledgerPath, *taxonomyPath, flag.Args()[1:], *reviewBelow, *learnAfter)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (available: corrections, review)", flag.Arg(0))
+	}
+
+	reportFolder := *outputFolder
+	if reportFolder == "" {
+		reportFolder = "reports" // Default for reports if no output specified
+	}
+
+	// Ctrl-C finishes the statements in progress, saves the results and exits cleanly
+	ctx, cancel := interruptible(context.Background())
+	defer cancel()
+
+	// Step 1: Load all PDFs and bank exports from toProcess folder
 	fmt.Println("📁 Loading PDFs from toProcess folder...")
 	pdfLoader := loader.New("toProcess")
-	if err := pdfLoader.Load(); err != nil {
-		log.Fatalf("Failed to load PDFs: %v", err)
+	if err := pdfLoader.Load(ctx); err != nil {
+		return fmt.Errorf("failed to load PDFs: %v", err)
 	}
-	fmt.Printf("✓ Found %d PDF files to process\n", len(pdfLoader.PDFs))
+	fmt.Printf("✓ Found %d PDF files and %d bank exports to process\n", len(pdfLoader.PDFs), len(pdfLoader.Imports))
 
 	// Step 2: Initialize text extractor
 	fmt.Println("🔧 Initializing PDF text extractor...")
-	textExtractor := extractor.New()
+	textExtractor, err := extractor.New(*extractWith)
+	if err != nil {
+		return fmt.Errorf("failed to create PDF text extractor: %v", err)
+	}
 
 	// Step 3: Initialize AI analyzer
-	fmt.Println("🤖 Initializing Claude AI analyzer...")
+	fmt.Println("🤖 Initializing AI analyzer...")
 	aiAnalyzer, err := analyzer.NewAnalyzer()
 	if err != nil {
-		log.Fatalf("Failed to create AI analyzer: %v\nPlease check your CLAUDE_API_KEY environment variable", err)
+		return fmt.Errorf("failed to create AI analyzer: %v\nPlease check your LLM_PROVIDER and CLAUDE_API_KEY environment variables", err)
 	}
+	fmt.Printf("✓ Using the %s LLM provider\n", aiAnalyzer.ProviderName())
 
-	// Step 4: Process each PDF and collect all transactions
-	fmt.Println("📊 Processing PDFs and extracting transactions...")
-	var allTransactions []*models.Transaction
+	// Currency assumed for statements that do not state one (.env is loaded by the analyzer)
+	if currency := os.Getenv("DEFAULT_CURRENCY"); currency != "" {
+		models.DefaultCurrency = strings.ToUpper(currency)
+	}
 
-	for i, pdf := range pdfLoader.PDFs {
-		fmt.Printf("Processing file %d/%d: %s\n", i+1, len(pdfLoader.PDFs), pdf)
+	// Exchange rates for converting report totals to the base currency
+	if *baseCurrency == "" {
+		*baseCurrency = models.DefaultCurrency
+	}
+	rates, err := fx.Load(*fxRatesPath, *baseCurrency)
+	if err != nil {
+		return fmt.Errorf("failed to load exchange rates: %v", err)
+	}
+	aiAnalyzer.SetExchangeRates(rates)
+
+	// Account for the tokens and cost of every model call
+	prices, err := usage.LoadPrices(*pricesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load price table: %v", err)
+	}
+	tracker := usage.NewTracker(prices, *budget)
+	aiAnalyzer.SetUsageTracker(tracker)
+	aiAnalyzer.SetConcurrency(*workers)
+	defer writeUsageReport(tracker, reportFolder)
 
-		// Generate output filename using the same logic as before
-		var textOutputPath string
-		fullPath := filepath.Join("toProcess", pdf)
-		if *outputFolder == "" {
-			base := filepath.Base(fullPath)
-			name := base[:len(base)-len(filepath.Ext(base))]
-			textOutputPath = filepath.Join("output", name+"_extracted.txt")
+	// Cache model responses so re-runs do not pay for identical prompts
+	if !*noCache {
+		responseCache, err := llm.OpenCache(*cacheDir, *cacheTTL, int64(*cacheMaxMB)<<20)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Response cache disabled: %v\n", err)
 		} else {
-			base := filepath.Base(fullPath)
-			name := base[:len(base)-len(filepath.Ext(base))]
-			textOutputPath = filepath.Join(*outputFolder, name+"_extracted.txt")
+			aiAnalyzer.SetCache(responseCache)
+			defer printCacheStats(responseCache)
 		}
+	}
 
-		// Extract text from PDF
-		if err := textExtractor.ExtractToFile(fullPath, textOutputPath); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to extract text from %s: %v\n", pdf, err)
-			continue
+	// The categories the model chooses from and its answers are validated against
+	categories, err := taxonomy.Load(*taxonomyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load category taxonomy: %v", err)
+	}
+	aiAnalyzer.SetTaxonomy(categories)
+
+	// User-editable rules categorize known merchants without a model call
+	categorizationRules, err := rules.Load(*rulesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load categorization rules: %v", err)
+	}
+	if len(categorizationRules.Rules) > 0 {
+		fmt.Printf("✓ Loaded %d categorization rules from %s\n", len(categorizationRules.Rules), *rulesPath)
+	}
+	for _, r := range categorizationRules.Rules {
+		if _, ok := categories.Resolve(r.Category, r.Subcategory); !ok {
+			fmt.Printf("⚠️  Warning: category %q of rule %s is not in the category taxonomy; its transactions will get %s\n",
+				r.Category, r.Name, categories.FallbackCategory().Name)
 		}
+	}
+	aiAnalyzer.SetRules(categorizationRules)
+
+	// Canonical payees for per-merchant reporting
+	payees, err := payee.Load(*payeesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load payee registry: %v", err)
+	}
+	if n := payees.Aliases(); n > 0 {
+		fmt.Printf("✓ Loaded %d payee aliases from %s\n", n, *payeesPath)
+	}
+
+	// Column mapping profiles for spreadsheet exports
+	profiles, err := importer.LoadProfiles(*profilesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load import profiles: %v", err)
+	}
+
+	// Deterministic parsers for known statement layouts; Claude is the fallback
+	pipe := &pipeline{
+		loader:       pdfLoader,
+		extractor:    textExtractor,
+		importers:    importer.Default(profiles),
+		parsers:      parser.Default(),
+		analyzer:     aiAnalyzer,
+		outputFolder: *outputFolder,
+		llmOnly:      *llmOnly,
+		reextract:    *reextract,
+		tolerance:    *tolerance,
+	}
+
+	// Open the persistent ledger so results accumulate across runs
+	fmt.Printf("🗄️  Opening transaction ledger %s...\n", *ledgerPath)
+	ledger, err := store.Open(*ledgerPath)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+
+	// Learn from the categories the user corrected in earlier reports
+	pastCorrections, err := ledger.Corrections()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to load corrections: %v\n", err)
+	} else if len(pastCorrections) > 0 {
+		learned := corrections.Learn(pastCorrections, *learnAfter)
+		aiAnalyzer.SetCorrections(learned)
+		fmt.Printf("✓ Learned %d merchant rules and %d examples from %d corrections\n",
+			learned.Rules(), len(learned.Examples()), len(pastCorrections))
+	}
 
-		// Read the extracted text
-		text, err := readTextFile(textOutputPath)
+	// The run journal records every completed step so a failed run can be resumed
+	runJournal, resumed, err := journal.Open(reportFolder, *resume)
+	if err != nil {
+		return fmt.Errorf("failed to open run journal: %v", err)
+	}
+	defer runJournal.Close()
+	if resumed {
+		fmt.Printf("↩️  Resuming the unfinished run recorded in %s\n", runJournal.Path())
+	} else if *resume {
+		fmt.Println("↩️  No unfinished run to resume; starting a new run")
+	}
+
+	// Step 4: Process each statement file and collect all transactions
+	fmt.Println("📊 Processing statements and extracting transactions...")
+	skipped, resumedFiles := 0, 0
+	statementFiles := pdfLoader.Files()
+
+	// Transactions are collected per file so the merged result keeps the file order
+	// even though statements are extracted concurrently
+	fileTransactions := make([][]*models.Transaction, len(statementFiles))
+	type extractJob struct {
+		index       int
+		name        string
+		contentHash string
+	}
+	var jobs []extractJob
+
+	for i, pdf := range statementFiles {
+		// Skip statements whose content is unchanged since they were last ingested
+		contentHash, err := pdfLoader.Fingerprint(ctx, pdf)
 		if err != nil {
-			fmt.Printf("⚠️  Warning: Failed to read extracted text from %s: %v\n", pdf, err)
+			fmt.Printf("⚠️  Warning: Failed to fingerprint %s: %v\n", pdf, err)
+			continue
+		}
+		// Statements the resumed run already extracted are taken from the journal
+		if transactions, ok := runJournal.Extracted(pdf, contentHash); ok {
+			fmt.Printf("↩️  Resuming %s from the run journal (%d transactions)\n", pdf, len(transactions))
+			fileTransactions[i] = transactions
+			resumedFiles++
 			continue
 		}
+		if !*force {
+			processedHash, err := ledger.ProcessedHash(pdf)
+			if err != nil {
+				fmt.Printf("⚠️  Warning: Failed to check manifest for %s: %v\n", pdf, err)
+			} else if processedHash == contentHash {
+				transactions, err := ledger.TransactionsBySource(pdf)
+				if err != nil {
+					fmt.Printf("⚠️  Warning: Failed to load stored transactions for %s: %v\n", pdf, err)
+					continue
+				}
+				fmt.Printf("⏭️  Skipping unchanged %s (%d stored transactions, use -force to re-process)\n", pdf, len(transactions))
+				fileTransactions[i] = transactions
+				skipped++
+				continue
+			}
+		}
+		jobs = append(jobs, extractJob{index: i, name: pdf, contentHash: contentHash})
+	}
+
+	// Extract with a bounded pool of workers; ledger writes are serialized
+	var ledgerMu sync.Mutex
+	var budgetSkipped, failed, empty atomic.Int32
+	workpool.Run(ctx, *workers, len(jobs), func(j int) {
+		job := jobs[j]
+		if tracker.Exhausted() {
+			budgetSkipped.Add(1)
+			return
+		}
+		if workpool.Stopped(ctx) {
+			return
+		}
+		fmt.Printf("Processing file %d/%d: %s\n", job.index+1, len(statementFiles), job.name)
 
-		// Use AI to extract transactions from the text
-		transactions, err := aiAnalyzer.ExtractTransactionsFromText(text, pdf)
+		transactions, err := pipe.extract(ctx, job.name)
 		if err != nil {
-			fmt.Printf("⚠️  Warning: Failed to extract transactions from %s: %v\n", pdf, err)
-			continue
+			fmt.Printf("⚠️  Warning: %s: %v\n", job.name, err)
+			failed.Add(1)
+			if err := runJournal.RecordExtraction(job.name, job.contentHash, nil, err); err != nil {
+				fmt.Printf("⚠️  Warning: %v\n", err)
+			}
+			return
+		}
+
+		fmt.Printf("✓ Extracted %d transactions from %s\n", len(transactions), job.name)
+		if len(transactions) == 0 {
+			// Not recorded as done anywhere, so the next run and -resume try the statement again
+			fmt.Printf("⚠️  Warning: %s will be processed again on the next run, since no transactions were found\n", job.name)
+			empty.Add(1)
+			if err := runJournal.RecordExtraction(job.name, job.contentHash, nil, errNoTransactions); err != nil {
+				fmt.Printf("⚠️  Warning: %v\n", err)
+			}
+			return
 		}
+		ledgerMu.Lock()
+		if err := ledger.SaveStatement(job.name, job.contentHash, transactions); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to save transactions from %s to ledger: %v\n", job.name, err)
+		}
+		ledgerMu.Unlock()
+		if err := runJournal.RecordExtraction(job.name, job.contentHash, transactions, nil); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+		}
+		fileTransactions[job.index] = transactions
+	})
+	if n := budgetSkipped.Load(); n > 0 {
+		fmt.Printf("💸 Budget reached; skipped %d statements\n", n)
+	}
+	interrupted := workpool.Stopped(ctx)
+	if interrupted {
+		fmt.Println("🛑 Run interrupted; run again with -resume to continue where it stopped")
+	}
+	// The run is only complete when every step succeeded; otherwise -resume retries the rest
+	incomplete := interrupted || budgetSkipped.Load() > 0 || failed.Load() > 0 || empty.Load() > 0
 
-		fmt.Printf("✓ Extracted %d transactions from %s\n", len(transactions), pdf)
+	var allTransactions []*models.Transaction
+	for _, transactions := range fileTransactions {
 		allTransactions = append(allTransactions, transactions...)
 	}
 
 	// Step 5: Report total transactions found
 	fmt.Printf("\n🎯 Total transactions extracted: %d\n", len(allTransactions))
 
+	// Statement figures catch rows the extraction dropped or invented
+	checkReconciliation(allTransactions, *tolerance, reportFolder)
+
+	// Payees are assigned on every run, so edits to the alias table reach stored transactions too
+	if n := payees.Assign(allTransactions); n > 0 {
+		fmt.Printf("🏪 Matched %d transactions to a known payee\n", n)
+	}
+
+	// Transactions listed in more than one statement file are counted once
+	dedupeOptions := dedupe.DefaultOptions
+	dedupeOptions.WindowDays = *dedupeDays
+	if !*noDedupe {
+		findDuplicates(allTransactions, dedupeOptions, reportFolder)
+	}
+	uniqueTransactions := dedupe.Unique(allTransactions)
+
 	if len(allTransactions) == 0 {
 		fmt.Println("❌ No transactions found. Check your PDF files and try again.")
-		return
+		return nil
 	}
 
-	// Step 6: Categorize all transactions using AI
-	fmt.Println("🏷️  Categorizing transactions using Claude AI...")
-	if err := aiAnalyzer.CategorizeTransactions(allTransactions); err != nil {
+	// Step 6: Categorize transactions using AI (stored ones keep their earlier categorization,
+	// and batches the resumed run finished are restored from the journal)
+	if n := runJournal.RestoreCategories(allTransactions); n > 0 {
+		fmt.Printf("↩️  Restored %d categorizations from the run journal\n", n)
+	}
+	aiAnalyzer.SetBatchObserver(func(batch int, transactions []*models.Transaction, err error) {
+		if err := runJournal.RecordBatch(batch, transactions, err); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+		}
+	})
+	var uncategorized []*models.Transaction
+	for _, tx := range uniqueTransactions {
+		if tx.Category == "" {
+			uncategorized = append(uncategorized, tx)
+		}
+	}
+	fmt.Printf("🏷️  Categorizing %d transactions with the %s provider...\n", len(uncategorized), aiAnalyzer.ProviderName())
+	if tracker.Exhausted() {
+		fmt.Println("💸 Budget reached; skipping categorization")
+		incomplete = true
+	} else if interrupted {
+		fmt.Println("🛑 Run interrupted; skipping categorization")
+	} else if err := aiAnalyzer.CategorizeTransactions(ctx, uncategorized); err != nil {
 		fmt.Printf("⚠️  Warning: Categorization failed: %v\n", err)
 		fmt.Println("Continuing with uncategorized transactions...")
+		incomplete = true
 	} else {
 		fmt.Println("✓ Transaction categorization complete")
 	}
 
-	// Step 7: Generate consolidated reports (always in the specified output folder)
-	reportFolder := *outputFolder
-	if reportFolder == "" {
-		reportFolder = "reports" // Default for reports if no output specified
+	// Transfers between the user's own accounts are paired once categories can tell
+	// which transactions are transfers
+	transferOptions := transfers.DefaultOptions
+	transferOptions.WindowDays = *transferDays
+	transferOptions.IsTransfer = func(tx *models.Transaction) bool {
+		return categories.KindOf(tx) == taxonomy.KindTransfer
+	}
+	if *noTransfers {
+		transfers.Clear(allTransactions)
+	} else {
+		findTransfers(allTransactions, transferOptions, reportFolder)
 	}
 
+	if err := ledger.UpsertTransactions(allTransactions); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to save categorizations to ledger: %v\n", err)
+	}
+
+	reportTransactions := uniqueTransactions
+	if *fullHistory {
+		reportTransactions, err = ledger.Transactions(time.Time{}, time.Time{})
+		if err != nil {
+			return fmt.Errorf("failed to read ledger history: %v", err)
+		}
+		payees.Assign(reportTransactions)
+		if !*noDedupe {
+			findDuplicates(reportTransactions, dedupeOptions, reportFolder)
+		}
+		if *noTransfers {
+			transfers.Clear(reportTransactions)
+		} else {
+			findTransfers(reportTransactions, transferOptions, reportFolder)
+		}
+		if err := ledger.UpsertTransactions(reportTransactions); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to save payees to ledger: %v\n", err)
+		}
+		reportTransactions = dedupe.Unique(reportTransactions)
+		fmt.Printf("📚 Reporting on %d transactions from the full ledger history\n", len(reportTransactions))
+	}
+
+	// Step 7: Generate consolidated reports (always in the specified output folder)
 	fmt.Printf("📈 Generating consolidated analysis reports in %s...\n", reportFolder)
-	if err := aiAnalyzer.GenerateReports(allTransactions, reportFolder); err != nil {
-		log.Fatalf("Failed to generate reports: %v", err)
+	if err := aiAnalyzer.GenerateReports(reportTransactions, reportFolder); err != nil {
+		return fmt.Errorf("failed to generate reports: %v", err)
+	}
+	if incomplete {
+		fmt.Printf("↩️  Some steps did not finish; run again with -resume to retry them (see %s)\n", runJournal.Path())
+	} else if err := runJournal.Complete(); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
 	}
 
 	// Step 8: Success summary
 	fmt.Println("\n🎉 Finance analysis complete!")
-	fmt.Printf("📊 Processed %d PDF files\n", len(pdfLoader.PDFs))
-	fmt.Printf("💰 Analyzed %d transactions\n", len(allTransactions))
+	fmt.Printf("📊 Processed %d statement files (%d unchanged and skipped)\n", len(statementFiles), skipped)
+	if resumedFiles > 0 {
+		fmt.Printf("↩️  Resumed %d statement files from the run journal\n", resumedFiles)
+	}
+	fmt.Printf("💰 Analyzed %d transactions\n", len(uniqueTransactions))
+	fmt.Printf("🗄️  Ledger updated: %s\n", ledger.Path())
 	fmt.Printf("📁 Check the %s folder for your analysis results\n", reportFolder)
 	fmt.Println("   - transactions_YYYYMMDD.csv (detailed transaction data)")
 	fmt.Println("   - summary_YYYYMMDD.txt (spending analysis summary)")
+	fmt.Println("   - duplicates_YYYYMMDD.csv (transactions listed in more than one statement, if any)")
+	fmt.Println("   - transfers_YYYYMMDD.csv (transfers paired between your own accounts, if any)")
+	fmt.Println("   - reconciliation_YYYYMMDD.csv (extracted transactions checked against statement totals, if printed)")
+	fmt.Println("   - usage.json (model token usage and cost)")
+	fmt.Printf("   - %s (run journal, used by -resume)\n", journal.FileName)
+	return nil
 }
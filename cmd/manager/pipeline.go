@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/analyzer"
+	"github.com/KerynSuoress/finance-manager/internal/extractor"
+	"github.com/KerynSuoress/finance-manager/internal/importer"
+	"github.com/KerynSuoress/finance-manager/internal/loader"
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/parser"
+	"github.com/KerynSuoress/finance-manager/internal/reconcile"
+)
+
+// pipeline bundles the components that turn one statement file into transactions
+type pipeline struct {
+	loader       *loader.PDFLoader
+	extractor    extractor.Extractor
+	importers    *importer.Registry
+	parsers      *parser.Registry
+	analyzer     *analyzer.Analyzer
+	outputFolder string
+	llmOnly      bool
+
+	// reextract extracts the pages of statements that do not reconcile again;
+	// tolerance is the difference up to which figures reconcile
+	reextract bool
+	tolerance float64
+}
+
+// extract returns the transactions of a statement file.
+// Structured exports are imported directly; PDFs go through text extraction.
+func (p *pipeline) extract(ctx context.Context, name string) ([]*models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if imp := p.importers.ForFile(name); imp != nil {
+		transactions, err := imp.Import(p.loader.Path(name), name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %v", name, err)
+		}
+		fmt.Printf("📥 Imported %s with the %s importer (no Claude call)\n", name, imp.Name())
+		return transactions, nil
+	}
+	return p.extractPDF(ctx, name)
+}
+
+// extractPDF extracts the text of a PDF statement and parses its transactions,
+// using a built-in parser when the layout is known and Claude otherwise
+func (p *pipeline) extractPDF(ctx context.Context, pdf string) ([]*models.Transaction, error) {
+	// Generate output filename using the same logic as before
+	fullPath := p.loader.Path(pdf)
+	folder := p.outputFolder
+	if folder == "" {
+		folder = "output"
+	}
+	base := filepath.Base(fullPath)
+	name := base[:len(base)-len(filepath.Ext(base))]
+	textOutputPath := filepath.Join(folder, name+"_extracted.txt")
+
+	// Extract text from PDF
+	if err := p.extractor.ExtractToFile(ctx, fullPath, textOutputPath); err != nil {
+		return nil, fmt.Errorf("failed to extract text: %v", err)
+	}
+
+	// Read the extracted text
+	text, err := readTextFile(textOutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extracted text: %v", err)
+	}
+
+	// Parse known layouts deterministically, falling back to Claude for everything else
+	if sp := p.parsers.Detect(text, pdf); sp != nil && !p.llmOnly {
+		parsed, err := sp.Parse(text, pdf)
+		switch {
+		case err != nil:
+			fmt.Printf("⚠️  Warning: %s parser failed on %s: %v. Falling back to Claude...\n", sp.Name(), pdf, err)
+		case len(parsed) == 0:
+			fmt.Printf("⚠️  Warning: %s parser found no transactions in %s. Falling back to Claude...\n", sp.Name(), pdf)
+		default:
+			fmt.Printf("🧩 Parsed %s with the %s parser (no Claude call)\n", pdf, sp.Name())
+			return parsed, nil
+		}
+	}
+
+	// Use AI to extract transactions from the text
+	chunks, err := p.analyzer.ExtractChunks(ctx, text, pdf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract transactions: %w", err)
+	}
+	transactions := analyzer.Transactions(chunks)
+
+	// Read the account and statement figures from the header
+	if len(transactions) > 0 {
+		header, err := p.analyzer.ExtractStatementHeader(ctx, text, pdf)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Failed to read the statement header of %s: %v\n", pdf, err)
+		} else {
+			header.Link(transactions)
+			if p.reextract {
+				transactions = p.reextractUnreconciled(ctx, pdf, header, chunks)
+			}
+		}
+	}
+	return transactions, nil
+}
+
+// reextractUnreconciled checks the extracted transactions against the statement
+// header and, when they do not reconcile, extracts the affected chunks again.
+// The new transactions are kept only when they reconcile better.
+func (p *pipeline) reextractUnreconciled(ctx context.Context, pdf string, header *models.Statement, chunks []analyzer.Chunk) []*models.Transaction {
+	transactions := analyzer.Transactions(chunks)
+	first := reconcile.Check(header, transactions, p.tolerance)
+	if first.Reconciled() {
+		return transactions
+	}
+
+	affected := affectedChunks(chunks, first)
+	fmt.Printf("🧮 %s does not reconcile (%s); extracting %s again...\n", pdf, first.Problem(), describeChunks(chunks, affected))
+	retried := make([]analyzer.Chunk, len(chunks))
+	copy(retried, chunks)
+	for _, i := range affected {
+		found, err := p.analyzer.ReextractChunk(ctx, chunks[i], pdf, first.Problem())
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Failed to extract %s of %s again: %v\n", describeChunks(chunks, []int{i}), pdf, err)
+			return transactions
+		}
+		retried[i].Transactions = found
+	}
+
+	candidate := analyzer.Transactions(retried)
+	header.Link(candidate)
+	second := reconcile.Check(header, candidate, p.tolerance)
+	switch {
+	case second.Reconciled():
+		fmt.Printf("✓ %s reconciles after extracting it again (%d transactions, were %d)\n", pdf, len(candidate), len(transactions))
+		return candidate
+	case second.Size() < first.Size() || (second.Size() == first.Size() && len(second.Breaks) < len(first.Breaks)):
+		fmt.Printf("🧮 %s is closer to reconciling after extracting it again: %s\n", pdf, second.Problem())
+		return candidate
+	}
+	fmt.Printf("🧮 Extracting %s again did not reconcile it better; keeping the first extraction\n", pdf)
+	header.Link(transactions)
+	return transactions
+}
+
+// affectedChunks returns the indexes of the chunks to extract again: those where
+// the running balance breaks, including the chunk before a break on a chunk's first
+// row, or every chunk when the balances do not point at any
+func affectedChunks(chunks []analyzer.Chunk, r reconcile.Result) []int {
+	if len(r.Breaks) == 0 {
+		all := make([]int, len(chunks))
+		for i := range chunks {
+			all[i] = i
+		}
+		return all
+	}
+
+	// chunkOf maps the index of a transaction to the chunk it was found in
+	var chunkOf, firstRow []int
+	for i, c := range chunks {
+		firstRow = append(firstRow, len(chunkOf))
+		for range c.Transactions {
+			chunkOf = append(chunkOf, i)
+		}
+	}
+	marked := make([]bool, len(chunks))
+	for _, row := range r.Breaks {
+		c := chunkOf[row]
+		marked[c] = true
+		if row == firstRow[c] && c > 0 {
+			marked[c-1] = true
+		}
+	}
+	var affected []int
+	for i, m := range marked {
+		if m {
+			affected = append(affected, i)
+		}
+	}
+	return affected
+}
+
+// describeChunks names the chunks for messages by their pages, e.g. "pages 3, 4",
+// or by their numbers when the text has no page markers
+func describeChunks(chunks []analyzer.Chunk, indexes []int) string {
+	var pages, numbers []string
+	for _, i := range indexes {
+		numbers = append(numbers, strconv.Itoa(chunks[i].Index))
+		for _, n := range chunks[i].Pages() {
+			pages = append(pages, strconv.Itoa(n))
+		}
+	}
+	switch {
+	case len(pages) == 1:
+		return "page " + pages[0]
+	case len(pages) > 1:
+		return "pages " + strings.Join(pages, ", ")
+	case len(chunks) == 1:
+		return "the statement"
+	}
+	return fmt.Sprintf("chunks %s of %d", strings.Join(numbers, ", "), len(chunks))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/corrections"
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/review"
+	"github.com/KerynSuoress/finance-manager/internal/store"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+)
+
+// reviewLedger lets the user review the ledger transactions categorized with a confidence
+// below threshold, uncategorized, or in the fallback category. Every decision updates the
+// ledger and is recorded as a correction, so later runs learn from it (see corrections.Learn).
+func reviewLedger(ledger *store.Store, categories *taxonomy.Taxonomy, threshold float64, repeats int) error {
+	all, err := ledger.Transactions(time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	queue := review.Queue(all, threshold, categories.FallbackCategory())
+	if len(queue) == 0 {
+		fmt.Printf("✓ Nothing to review: every transaction is categorized with a confidence of at least %.2f\n", threshold)
+		return nil
+	}
+
+	// All rows of a source are written together so identical rows keep their ledger identity
+	bySource := make(map[string][]*models.Transaction)
+	for _, t := range all {
+		bySource[t.Source] = append(bySource[t.Source], t)
+	}
+
+	decided, err := review.Run(queue, categories, func(d review.Decision) error {
+		t := d.Transaction
+		correction := corrections.NewCorrection(t, d.Category, d.Subcategory, time.Now().UTC())
+		t.Category, t.Subcategory, t.CategoryID = d.Category, d.Subcategory, d.CategoryID
+		t.Confidence = 1.0
+		t.CategorizedBy = models.CategorizedByUser
+		if err := ledger.UpsertTransactions(bySource[t.Source]); err != nil {
+			return err
+		}
+		return ledger.SaveCorrections([]*models.Correction{correction})
+	})
+	fmt.Printf("✓ Reviewed %d of %d transactions\n", decided, len(queue))
+	if err != nil {
+		return err
+	}
+
+	past, err := ledger.Corrections()
+	if err != nil {
+		return err
+	}
+	learned := corrections.Learn(past, repeats)
+	fmt.Printf("🧠 From %d corrections: %d merchant rules, %d examples for the model\n",
+		len(past), learned.Rules(), len(learned.Examples()))
+	return nil
+}
+
+// runReview runs the review command: manager [flags] review
+func runReview(ledgerPath, taxonomyPath string, args []string, threshold float64, repeats int) {
+	if len(args) != 0 {
+		log.Fatalf("Usage: manager [flags] review")
+	}
+	categories, err := taxonomy.Load(taxonomyPath)
+	if err != nil {
+		log.Fatalf("Failed to load category taxonomy: %v", err)
+	}
+	ledger, err := store.Open(ledgerPath)
+	if err != nil {
+		log.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+	if err := reviewLedger(ledger, categories, threshold, repeats); err != nil {
+		log.Fatalf("Review failed: %v", err)
+	}
+}
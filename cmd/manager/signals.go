@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/KerynSuoress/finance-manager/internal/workpool"
+)
+
+// interruptible returns a context for the run that handles Ctrl-C in two steps:
+// the first interrupt stops new statements and batches from starting while the ones
+// in progress finish and are saved; the second cancels the context, aborting
+// in-flight requests and extraction processes.
+func interruptible(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := make(chan struct{})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		fmt.Println("\n🛑 Interrupted: finishing the statements in progress and saving results (press Ctrl-C again to abort them)...")
+		close(stop)
+
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		fmt.Println("\n🛑 Aborting in-flight work...")
+		cancel()
+	}()
+
+	return workpool.WithStop(ctx, stop), cancel
+}
@@ -1,57 +1,35 @@
 package analyzer
 
 import (
-	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net"
-	"net/http"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/KerynSuoress/finance-manager/internal/corrections"
+	"github.com/KerynSuoress/finance-manager/internal/fx"
+	"github.com/KerynSuoress/finance-manager/internal/llm"
 	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/rules"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+	"github.com/KerynSuoress/finance-manager/internal/usage"
+	"github.com/KerynSuoress/finance-manager/internal/workpool"
 
 	"github.com/joho/godotenv"
 )
 
-// ClaudeAPIResponse represents the response from Claude API
-type ClaudeAPIResponse struct {
-	Type    string `json:"type"`
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	Usage struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-	} `json:"usage"`
-}
-
-// ClaudeAPIRequest represents the request to Claude API
-type ClaudeAPIRequest struct {
-	Model       string    `json:"model"`
-	MaxTokens   int       `json:"max_tokens"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature"`
-}
-
-// Message represents a message in the Claude API conversation
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
 // Analyzer handles transaction categorization and analysis
 type Analyzer struct {
-	apiKey     string
-	model      string
-	maxTokens  int
-	httpClient *http.Client
+	provider  llm.Provider
+	model     string
+	maxTokens int
 
 	// Cost-saver / debug controls
 	dryRun         bool
@@ -59,22 +37,55 @@ type Analyzer struct {
 	onlyFirstChunk bool
 	maxRequests    int
 	requestsMade   int
+	requestsMu     sync.Mutex
+
+	// concurrency bounds the model calls in flight; slots holds one token per running call
+	concurrency int
+	slots       chan struct{}
+
+	// Exchange rates used to convert report totals to a base currency
+	rates *fx.Table
+
+	// On-disk cache of model responses; nil disables caching
+	cache *llm.Cache
+
+	// Token usage and cost of every call; nil disables accounting
+	usage *usage.Tracker
+
+	// Categories offered to the model; answers and rule categories are resolved against it
+	categories *taxonomy.Taxonomy
+
+	// Categorization rules applied before the model; nil sends every transaction to the model
+	rules *rules.Set
+
+	// What was learned from the user's corrections: merchant rules and prompt examples
+	learned *corrections.Knowledge
+
+	// Called after every categorization batch, e.g. to journal its results
+	batchDone func(batch int, transactions []*models.Transaction, err error)
 }
 
-// NewAnalyzer creates a new analyzer instance
+// NewAnalyzer creates a new analyzer instance configured from the environment
 func NewAnalyzer() (*Analyzer, error) {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
+	// Load environment variables; a missing .env is fine when they are set otherwise (e.g. in CI)
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("failed to load .env file: %v", err)
 	}
 
-	apiKey := os.Getenv("CLAUDE_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("CLAUDE_API_KEY environment variable is required")
+	providerName := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER")))
+	apiKey := os.Getenv("LLM_API_KEY")
+	if providerName == "" || providerName == llm.ProviderAnthropic {
+		apiKey = os.Getenv("CLAUDE_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("CLAUDE_API_KEY environment variable is required")
+		}
 	}
 
 	// Use Claude Sonnet 4 as default model; allow override via env
-	model := os.Getenv("CLAUDE_MODEL")
+	model := os.Getenv("LLM_MODEL")
+	if strings.TrimSpace(model) == "" {
+		model = os.Getenv("CLAUDE_MODEL")
+	}
 	if strings.TrimSpace(model) == "" {
 		model = "claude-sonnet-4-20250514"
 	}
@@ -95,19 +106,94 @@ func NewAnalyzer() (*Analyzer, error) {
 		}
 	}
 
+	// Rate limits of the API account; the defaults match Anthropic's entry tier
+	requestsPerMinute, tokensPerMinute := 0, 0
+	if providerName == "" || providerName == llm.ProviderAnthropic {
+		requestsPerMinute, tokensPerMinute = 50, 30000
+	}
+	if v := os.Getenv("LLM_RPM"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			requestsPerMinute = n
+		}
+	}
+	if v := os.Getenv("LLM_TPM"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			tokensPerMinute = n
+		}
+	}
+
+	provider, err := llm.New(llm.Config{
+		Provider:          providerName,
+		BaseURL:           os.Getenv("LLM_BASE_URL"),
+		APIKey:            apiKey,
+		Timeout:           time.Duration(timeoutSeconds) * time.Second,
+		ReplayDir:         os.Getenv("LLM_REPLAY_DIR"),
+		RecordDir:         os.Getenv("LLM_RECORD_DIR"),
+		RequestsPerMinute: requestsPerMinute,
+		TokensPerMinute:   tokensPerMinute,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a := NewAnalyzerWithProvider(provider, model)
+	a.maxTokens = maxTokens
+	return a, nil
+}
+
+// NewAnalyzerWithProvider creates an analyzer that sends its prompts to provider
+func NewAnalyzerWithProvider(provider llm.Provider, model string) *Analyzer {
 	return &Analyzer{
-		apiKey:         apiKey,
+		provider:       provider,
 		model:          model,
-		maxTokens:      maxTokens,
-		httpClient:     &http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second},
+		maxTokens:      2048,
 		dryRun:         false,
 		debugDir:       "",
 		onlyFirstChunk: false,
 		maxRequests:    0,
 		requestsMade:   0,
-	}, nil
+		concurrency:    1,
+		slots:          make(chan struct{}, 1),
+		categories:     taxonomy.Default(),
+	}
+}
+
+// SetConcurrency sets how many model calls may run at once; chunks of a statement
+// and categorization batches are processed in parallel up to this limit
+func (a *Analyzer) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.concurrency = n
+	a.slots = make(chan struct{}, n)
+}
+
+// SetCache enables the on-disk response cache (nil disables it)
+func (a *Analyzer) SetCache(c *llm.Cache) { a.cache = c }
+
+// SetUsageTracker records the token usage and cost of every call and enforces its budget
+func (a *Analyzer) SetUsageTracker(t *usage.Tracker) { a.usage = t }
+
+// SetTaxonomy sets the category taxonomy offered to the model and used to validate its answers
+func (a *Analyzer) SetTaxonomy(t *taxonomy.Taxonomy) { a.categories = t }
+
+// SetRules sets the categorization rules applied before transactions are sent to the model
+func (a *Analyzer) SetRules(r *rules.Set) { a.rules = r }
+
+// SetCorrections sets what was learned from the user's category corrections; merchants
+// with a learned rule skip the model and the other corrections are shown to it as examples
+func (a *Analyzer) SetCorrections(k *corrections.Knowledge) { a.learned = k }
+
+// SetBatchObserver registers fn to be called after each categorization batch finishes,
+// with the batch number, its transactions and the error if it failed. Batches run
+// concurrently, so fn must be safe for concurrent use.
+func (a *Analyzer) SetBatchObserver(fn func(batch int, transactions []*models.Transaction, err error)) {
+	a.batchDone = fn
 }
 
+// ProviderName returns the name of the LLM provider prompts are sent to
+func (a *Analyzer) ProviderName() string { return a.provider.Name() }
+
 // EnableDryRun toggles dry-run mode (no external API calls; responses are mocked)
 func (a *Analyzer) EnableDryRun(d bool) { a.dryRun = d }
 
@@ -124,6 +210,9 @@ func (a *Analyzer) SetMaxRequests(n int) {
 	}
 }
 
+// SetExchangeRates sets the FX table used to convert report totals to its base currency
+func (a *Analyzer) SetExchangeRates(rates *fx.Table) { a.rates = rates }
+
 func (a *Analyzer) saveDebugFile(prefix string, data []byte) {
 	if strings.TrimSpace(a.debugDir) == "" {
 		return
@@ -134,7 +223,36 @@ func (a *Analyzer) saveDebugFile(prefix string, data []byte) {
 }
 
 // CategorizeTransactions uses Claude API to categorize all transactions
-func (a *Analyzer) CategorizeTransactions(transactions []*models.Transaction) error {
+func (a *Analyzer) CategorizeTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	all := transactions
+
+	// Rules categorize the merchants they know; only the rest is sent to the model
+	if a.rules != nil {
+		remaining := a.rules.Apply(transactions)
+		if n := len(transactions) - len(remaining); n > 0 {
+			fmt.Printf("📏 Categorized %d transactions with rules; %d left for the model\n", n, len(remaining))
+		}
+		transactions = remaining
+	}
+	if a.learned != nil {
+		remaining := a.learned.Apply(transactions)
+		if n := len(transactions) - len(remaining); n > 0 {
+			fmt.Printf("🧠 Categorized %d transactions with rules learned from corrections; %d left for the model\n", n, len(remaining))
+		}
+		transactions = remaining
+	}
+	// Rule categories may be written as IDs or names; store them as the taxonomy does
+	unknown := 0
+	for _, t := range all {
+		if t.Category != "" && t.CategoryID == "" && !a.categories.Normalize(t) {
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		fmt.Printf("⚠️  Warning: %d transactions matched a rule whose category is not in the category taxonomy and got %s\n",
+			unknown, a.categories.FallbackCategory().Name)
+	}
+
 	if len(transactions) == 0 {
 		fmt.Println("No transactions to categorize")
 		return nil
@@ -142,22 +260,30 @@ func (a *Analyzer) CategorizeTransactions(transactions []*models.Transaction) er
 
 	fmt.Printf("Categorizing %d transactions using Claude API...\n", len(transactions))
 
-	// Process transactions in batches to avoid rate limits and token overflows
+	// Process transactions in batches to avoid token overflows; batches run
+	// concurrently and the provider's rate limiter paces the requests
 	batchSize := 30
-	for i := 0; i < len(transactions); i += batchSize {
-		end := i + batchSize
-		if end > len(transactions) {
-			end = len(transactions)
+	batches := (len(transactions) + batchSize - 1) / batchSize
+	errs := make([]error, batches)
+	if err := workpool.Run(ctx, a.concurrency, batches, func(b int) {
+		// After a stop request, batches in flight finish but no new ones start
+		if workpool.Stopped(ctx) {
+			errs[b] = workpool.ErrStopped
+			return
 		}
-
-		batch := transactions[i:end]
-		if err := a.categorizeBatch(batch); err != nil {
-			return fmt.Errorf("failed to categorize batch %d: %v", i/batchSize+1, err)
+		batch := transactions[b*batchSize : min((b+1)*batchSize, len(transactions))]
+		errs[b] = a.categorizeBatch(ctx, batch)
+		if a.batchDone != nil {
+			a.batchDone(b+1, batch, errs[b])
 		}
+	}); err != nil {
+		return fmt.Errorf("categorization interrupted: %w", err)
+	}
 
-		// Small delay between batches to be respectful to the API
-		if end < len(transactions) {
-			time.Sleep(1 * time.Second)
+	// Report the first failed batch; the others keep their categories
+	for b, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to categorize batch %d: %w", b+1, err)
 		}
 	}
 
@@ -166,7 +292,50 @@ func (a *Analyzer) CategorizeTransactions(transactions []*models.Transaction) er
 }
 
 // ExtractTransactionsFromText uses Claude API to extract transactions from raw PDF text
-func (a *Analyzer) ExtractTransactionsFromText(text string, source string) ([]*models.Transaction, error) {
+func (a *Analyzer) ExtractTransactionsFromText(ctx context.Context, text string, source string) ([]*models.Transaction, error) {
+	chunks, err := a.ExtractChunks(ctx, text, source)
+	if err != nil {
+		return nil, err
+	}
+	return Transactions(chunks), nil
+}
+
+// Chunk is one part of a statement's text sent for extraction, with the transactions found in it
+type Chunk struct {
+	// Index and Total number the chunk among the chunks of the statement, from 1
+	Index int
+	Total int
+
+	Text         string
+	Transactions []*models.Transaction
+}
+
+// Pages returns the page numbers of the "--- Page N ---" markers in the chunk
+func (c Chunk) Pages() []int {
+	var pages []int
+	for _, m := range pageMarker.FindAllStringSubmatch(c.Text, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			pages = append(pages, n)
+		}
+	}
+	return pages
+}
+
+// pageMarker matches the marker text extractors write before each page
+var pageMarker = regexp.MustCompile(`(?m)^--- Page (\d+) ---`)
+
+// Transactions returns the transactions of the chunks in statement order
+func Transactions(chunks []Chunk) []*models.Transaction {
+	var transactions []*models.Transaction
+	for _, c := range chunks {
+		transactions = append(transactions, c.Transactions...)
+	}
+	return transactions
+}
+
+// ExtractChunks extracts the transactions of a statement's text chunk by chunk, so
+// chunks whose transactions do not reconcile can be extracted again (see ReextractChunk)
+func (a *Analyzer) ExtractChunks(ctx context.Context, text string, source string) ([]Chunk, error) {
 	fmt.Printf("Extracting transactions from PDF text using Claude API...\n")
 
 	// For very large statements, split into manageable chunks to avoid timeouts
@@ -184,25 +353,39 @@ func (a *Analyzer) ExtractTransactionsFromText(text string, source string) ([]*m
 		fmt.Printf("Large statement detected. Splitting into %d chunks...\n", len(chunks))
 	}
 
-	var allTransactions []*models.Transaction
-	for i, chunk := range chunks {
+	// Chunks are extracted concurrently and merged in statement order.
+	// A stop request does not interrupt them: the statement is finished as one item.
+	results := make([][]*models.Transaction, len(chunks))
+	errs := make([]error, len(chunks))
+	if err := workpool.Run(ctx, a.concurrency, len(chunks), func(i int) {
 		if len(chunks) > 1 {
-			fmt.Printf("Processing chunk %d/%d...\n", i+1, len(chunks))
+			fmt.Printf("Processing chunk %d/%d of %s...\n", i+1, len(chunks), source)
 		}
+		results[i], errs[i] = a.extractFromChunk(ctx, chunks[i], source, i+1, len(chunks), "", 0)
+	}); err != nil {
+		return nil, fmt.Errorf("extraction interrupted: %w", err)
+	}
 
-		transactions, err := a.extractFromChunkRecursive(chunk, source, i+1, len(chunks), 0)
-		if err != nil {
-			return nil, err
-		}
-		allTransactions = append(allTransactions, transactions...)
-
-		if i < len(chunks)-1 {
-			time.Sleep(200 * time.Millisecond)
+	result := make([]Chunk, len(chunks))
+	for i, transactions := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
 		}
+		result[i] = Chunk{Index: i + 1, Total: len(chunks), Text: chunks[i], Transactions: transactions}
 	}
 
-	fmt.Printf("✓ Extracted %d transactions from PDF text\n", len(allTransactions))
-	return allTransactions, nil
+	fmt.Printf("✓ Extracted %d transactions from PDF text\n", len(Transactions(result)))
+	return result, nil
+}
+
+// ReextractChunk extracts the transactions of a chunk again after they did not
+// reconcile with the statement. The prompt tells the model what did not add up,
+// which also keeps the first answer from being served from the response cache.
+func (a *Analyzer) ReextractChunk(ctx context.Context, chunk Chunk, source string, problem string) ([]*models.Transaction, error) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("A previous extraction of this text found %d transactions that do not reconcile with the statement: %s.\n", len(chunk.Transactions), problem))
+	sb.WriteString("Read every row again carefully: include each transaction exactly once, keep its sign, and leave out subtotals, balances and summary lines.\n\n")
+	return a.extractFromChunk(ctx, chunk.Text, source, chunk.Index, chunk.Total, sb.String(), 0)
 }
 
 // buildExtractionPrompt creates a prompt for transaction extraction from PDF text
@@ -214,32 +397,28 @@ func (a *Analyzer) buildExtractionPrompt(text string, source string) string {
 	sb.WriteString("1. Date (in YYYY-MM-DD format)\n")
 	sb.WriteString("2. Description (merchant name, transaction details)\n")
 	sb.WriteString("3. Amount (positive for income/credits, negative for expenses/debits)\n")
-	sb.WriteString("4. Transaction type (debit/credit)\n\n")
+	sb.WriteString("4. Transaction type (debit/credit)\n")
+	sb.WriteString("5. Currency of the amount as billed on the statement (ISO 4217 code, e.g. COP, USD)\n")
+	sb.WriteString("6. For international charges, the original amount and its currency as printed on the statement\n")
+	sb.WriteString("7. The running balance after the transaction, when the statement has a balance column\n\n")
 
 	sb.WriteString("Statement source: " + source + "\n\n")
 	sb.WriteString("Statement text:\n")
 	sb.WriteString(text)
 	sb.WriteString("\n\n")
 
-	sb.WriteString("Extract all transactions and respond ONLY with a JSON array (no preface, no explanation, no code fences). Format exactly like this:\n")
-	sb.WriteString("[\n")
-	sb.WriteString("  {\n")
-	sb.WriteString("    \"date\": \"2025-01-15\",\n")
-	sb.WriteString("    \"description\": \"RESTAURANT ABC\",\n")
-	sb.WriteString("    \"amount\": -125000.00,\n")
-	sb.WriteString("    \"type\": \"debit\"\n")
-	sb.WriteString("  }\n")
-	sb.WriteString("]\n\n")
+	sb.WriteString("Record all transactions with the " + extractionTool.Name + " tool.\n\n")
 
 	sb.WriteString("CRITICAL RULES:\n")
 	sb.WriteString("- Handle Colombian Peso (COP) amounts with comma as decimal separator (e.g., 125.000,50)\n")
 	sb.WriteString("- Convert amounts to standard format (e.g., 125000.50)\n")
+	sb.WriteString(fmt.Sprintf("- If the statement does not state a currency, use %s\n", models.DefaultCurrency))
+	sb.WriteString("- Only include original_amount and original_currency when the charge was made in a different currency than it was billed in\n")
 	sb.WriteString("- Only extract actual financial transactions, not summary information\n")
 	sb.WriteString("- If you see duplicate transactions with opposite signs for the same merchant on the same date, only include the NET transaction\n")
 	sb.WriteString("- For example: if you see 'RESTAURANT ABC -1000' and 'RESTAURANT ABC +1000' on the same date, skip both\n")
 	sb.WriteString("- If you see 'RESTAURANT ABC -1000' and 'RESTAURANT ABC +500' on the same date, include only the net: 'RESTAURANT ABC -500'\n")
-	sb.WriteString("- If no transactions are found, return an empty array []\n")
-	sb.WriteString("- Do not include any commentary, headings, or markdown. Output must be a JSON array only.\n")
+	sb.WriteString("- If no transactions are found, record an empty list\n")
 
 	return sb.String()
 }
@@ -252,235 +431,78 @@ func (a *Analyzer) buildExtractionPromptWithChunk(text string, source string, ch
 	return sb.String()
 }
 
-// extractFromChunkRecursive attempts to extract transactions from a text chunk.
-// If the model returns non-JSON output, it splits the chunk and retries recursively up to a small depth.
-func (a *Analyzer) extractFromChunkRecursive(chunk string, source string, chunkIndex int, totalChunks int, depth int) ([]*models.Transaction, error) {
-	// Safety: limit recursion depth
-	if depth > 3 {
-		return nil, fmt.Errorf("failed to parse extraction response after multiple attempts")
-	}
-
-	prompt := a.buildExtractionPromptWithChunk(chunk, source, chunkIndex, totalChunks)
-	request := ClaudeAPIRequest{
+// extractFromChunk extracts the transactions of a text chunk; note, if any, is put
+// before the prompt. When the reply is cut off at the max tokens limit, the chunk is
+// split in half and each half is extracted separately, up to a small depth.
+func (a *Analyzer) extractFromChunk(ctx context.Context, chunk string, source string, chunkIndex int, totalChunks int, note string, depth int) ([]*models.Transaction, error) {
+	prompt := note + a.buildExtractionPromptWithChunk(chunk, source, chunkIndex, totalChunks)
+	request := llm.Request{
 		Model:       a.model,
 		MaxTokens:   a.maxTokens,
 		Temperature: 0.1,
-		Messages: []Message{{
+		Messages: []llm.Message{{
 			Role:    "user",
 			Content: prompt,
 		}},
+		Tools:      []llm.Tool{extractionTool},
+		ToolChoice: extractionTool.Name,
 	}
 
-	response, err := a.callClaudeAPI(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Claude API: %v", err)
+	var transactions []*models.Transaction
+	var parseErr error
+	response, err := a.complete(ctx, request, usage.PhaseExtraction, []string{source}, func(response *llm.Response) error {
+		transactions, parseErr = a.parseExtractionResponse(response, source)
+		return parseErr
+	})
+	if err != nil && err == parseErr {
+		return nil, fmt.Errorf("failed to parse extraction response: %v", parseErr)
 	}
-
-	transactions, parseErr := a.parseExtractionResponse(response, source)
-	if parseErr == nil {
-		return transactions, nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the model: %w", err)
 	}
 
-	// If parse failed and the chunk is large enough, split and retry recursively
-	if len(chunk) > 6000 {
-		fmt.Printf("Parse failed on chunk (len=%d). Splitting and retrying...\n", len(chunk))
+	if response.Truncated() && depth < 3 && len(chunk) > 2000 {
+		fmt.Printf("Response for chunk (len=%d) hit the max tokens limit. Splitting and retrying...\n", len(chunk))
 		mid := len(chunk) / 2
-		left := chunk[:mid]
-		right := chunk[mid:]
-		leftTx, _ := a.extractFromChunkRecursive(left, source, chunkIndex, totalChunks, depth+1)
-		rightTx, _ := a.extractFromChunkRecursive(right, source, chunkIndex, totalChunks, depth+1)
-		combined := append(leftTx, rightTx...)
-		if len(combined) > 0 {
-			return combined, nil
-		}
-	}
-
-	// Log a small snippet for diagnostics
-	if len(response.Content) > 0 {
-		msg := response.Content[0].Text
-		if len(msg) > 200 {
-			msg = msg[:200]
-		}
-		fmt.Printf("Model returned non-JSON output (first 200 chars): %s\n", strings.ReplaceAll(msg, "\n", " "))
-	}
-
-	return nil, fmt.Errorf("failed to parse extraction response: %v", parseErr)
-}
-
-// Add this function to extract JSON from Claude's response
-func extractJSONFromResponse(content string) (string, error) {
-	content = strings.TrimSpace(content)
-
-	// Helper: extract first well-formed JSON array by bracket matching, ignoring text inside quotes
-	findJSONArray := func(s string) (string, bool) {
-		inString := false
-		escaped := false
-		depth := 0
-		start := -1
-		for i, r := range s {
-			ch := byte(r)
-			if inString {
-				if escaped {
-					escaped = false
-					continue
-				}
-				if ch == '\\' {
-					escaped = true
-					continue
-				}
-				if ch == '"' {
-					inString = false
-				}
-				continue
-			}
-			if ch == '"' {
-				inString = true
-				continue
-			}
-			if ch == '[' {
-				if depth == 0 {
-					start = i
-				}
-				depth++
-			} else if ch == ']' {
-				if depth > 0 {
-					depth--
-					if depth == 0 && start != -1 {
-						return s[start : i+1], true
-					}
-				}
-			}
-		}
-		return "", false
-	}
-
-	if arr, ok := findJSONArray(content); ok {
-		return arr, nil
-	}
-
-	// Fallback: look inside fenced code blocks
-	if start := strings.Index(content, "```json"); start != -1 {
-		if end := strings.Index(content[start+7:], "```"); end != -1 { // 7 = len("```json")
-			block := content[start+7 : start+7+end]
-			if arr, ok := findJSONArray(block); ok {
-				return arr, nil
-			}
-		}
-	}
-	if start := strings.Index(content, "```"); start != -1 {
-		if end := strings.Index(content[start+3:], "```"); end != -1 { // 3 = len("```")
-			block := content[start+3 : start+3+end]
-			if arr, ok := findJSONArray(block); ok {
-				return arr, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no JSON array found in response")
-}
-
-// buildJSONArrayFromObjects scans the content for standalone JSON objects and
-// returns a synthesized JSON array string composed of those objects.
-// This is a last-resort fallback when the model outputs multiple objects without wrapping them in an array.
-func buildJSONArrayFromObjects(content string) (string, error) {
-	// Strip code fences if present to reduce noise
-	cleaned := content
-	if i := strings.Index(cleaned, "```"); i != -1 {
-		// remove all fenced blocks
-		re := regexp.MustCompile("```[a-zA-Z]*[\\s\\S]*?```")
-		cleaned = re.ReplaceAllString(cleaned, "")
-	}
-
-	inString := false
-	escaped := false
-	depth := 0
-	start := -1
-	var objects []string
-	for i := 0; i < len(cleaned); i++ {
-		ch := cleaned[i]
-		if inString {
-			if escaped {
-				escaped = false
-				continue
-			}
-			if ch == '\\' {
-				escaped = true
-				continue
-			}
-			if ch == '"' {
-				inString = false
-			}
-			continue
-		}
-		if ch == '"' {
-			inString = true
-			continue
+		left, err := a.extractFromChunk(ctx, chunk[:mid], source, chunkIndex, totalChunks, note, depth+1)
+		if err != nil {
+			return nil, err
 		}
-		if ch == '{' {
-			if depth == 0 {
-				start = i
-			}
-			depth++
-		} else if ch == '}' {
-			if depth > 0 {
-				depth--
-				if depth == 0 && start != -1 {
-					objects = append(objects, cleaned[start:i+1])
-					start = -1
-				}
-			}
+		right, err := a.extractFromChunk(ctx, chunk[mid:], source, chunkIndex, totalChunks, note, depth+1)
+		if err != nil {
+			return nil, err
 		}
+		return append(left, right...), nil
 	}
-
-	if len(objects) == 0 {
-		return "", fmt.Errorf("no standalone JSON objects found")
+	if response.Truncated() {
+		_, err := a.parseExtractionResponse(response, source)
+		return nil, fmt.Errorf("failed to parse extraction response: %v", err)
 	}
-
-	// Join objects into a JSON array
-	array := "[" + strings.Join(objects, ",") + "]"
-	// quick sanity check
-	var tmp []map[string]interface{}
-	if err := json.Unmarshal([]byte(array), &tmp); err != nil {
-		return "", fmt.Errorf("failed to validate synthesized JSON array: %v", err)
-	}
-	return array, nil
+	return transactions, nil
 }
 
-// parseExtractionResponse parses the Claude API response to extract transactions
-func (a *Analyzer) parseExtractionResponse(response *ClaudeAPIResponse, source string) ([]*models.Transaction, error) {
-	if len(response.Content) == 0 {
-		return nil, fmt.Errorf("no content in API response")
-	}
-
-	// Extract the text content from the response
-	content := response.Content[0].Text
-
-	// Extract just the JSON part
-	jsonContent, err := extractJSONFromResponse(content)
-	if err != nil {
-		// Fallback: try to build an array from individual JSON objects
-		if fallback, ferr := buildJSONArrayFromObjects(content); ferr == nil {
-			jsonContent = fallback
-		} else {
-			return nil, fmt.Errorf("failed to extract JSON from response: %v", err)
-		}
-	}
-
-	// Try to parse as JSON
-	var transactions []struct {
-		Date        string  `json:"date"`
-		Description string  `json:"description"`
-		Amount      float64 `json:"amount"`
-		Type        string  `json:"type"`
+// parseExtractionResponse converts the record_transactions tool input into transactions
+func (a *Analyzer) parseExtractionResponse(response *llm.Response, source string) ([]*models.Transaction, error) {
+	// Amounts are decoded as json.Number so they can be parsed exactly
+	var output struct {
+		Transactions []struct {
+			Date             string      `json:"date"`
+			Description      string      `json:"description"`
+			Amount           json.Number `json:"amount"`
+			Type             string      `json:"type"`
+			Currency         string      `json:"currency"`
+			OriginalAmount   json.Number `json:"original_amount"`
+			OriginalCurrency string      `json:"original_currency"`
+			Balance          json.Number `json:"balance"`
+		} `json:"transactions"`
 	}
-
-	if err := json.Unmarshal([]byte(jsonContent), &transactions); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %v\nJSON content: %s", err, jsonContent)
+	if err := extractionTool.Decode(response, &output); err != nil {
+		return nil, err
 	}
 
 	// Convert to our Transaction model
 	var result []*models.Transaction
-	for _, t := range transactions {
+	for _, t := range output.Transactions {
 		// Parse the date
 		date, err := time.Parse("2006-01-02", t.Date)
 		if err != nil {
@@ -488,19 +510,50 @@ func (a *Analyzer) parseExtractionResponse(response *ClaudeAPIResponse, source s
 			continue
 		}
 
+		currency := strings.TrimSpace(t.Currency)
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		amount, err := parseAmountNumber(t.Amount, currency)
+		if err != nil {
+			fmt.Printf("Warning: Could not parse amount '%s', skipping transaction\n", t.Amount)
+			continue
+		}
+
+		// The original amount is optional and only meaningful in another currency
+		var original models.Money
+		if t.OriginalAmount != "" && t.OriginalCurrency != "" && !strings.EqualFold(t.OriginalCurrency, currency) {
+			if v, err := parseAmountNumber(t.OriginalAmount, t.OriginalCurrency); err == nil {
+				original = v.Abs()
+				if amount.IsNegative() {
+					original = original.Neg()
+				}
+			}
+		}
+
 		// Determine transaction type
 		transactionType := models.Debit
 		if t.Type == "credit" {
 			transactionType = models.Credit
 		}
 
+		// The running balance is only printed by some statements; it is used for reconciliation
+		balance := models.Money{Currency: currency}
+		if t.Balance != "" {
+			if v, err := parseAmountNumber(t.Balance, currency); err == nil {
+				balance = v
+			}
+		}
+
 		transaction := &models.Transaction{
-			Date:        date,
-			Description: t.Description,
-			Amount:      t.Amount,
-			Type:        transactionType,
-			Source:      source,
-			RawText:     t.Description, // Use description as raw text for now
+			Date:           date,
+			Description:    t.Description,
+			Amount:         amount,
+			OriginalAmount: original,
+			Type:           transactionType,
+			Balance:        balance,
+			Source:         source,
+			RawText:        t.Description, // Use description as raw text for now
 		}
 
 		result = append(result, transaction)
@@ -509,165 +562,196 @@ func (a *Analyzer) parseExtractionResponse(response *ClaudeAPIResponse, source s
 	return result, nil
 }
 
+// parseAmountNumber converts a JSON number into Money without a float64 round trip,
+// falling back to float parsing for exponent notation
+func parseAmountNumber(n json.Number, currency string) (models.Money, error) {
+	if m, err := models.ParseMoney(n.String(), currency); err == nil {
+		return m, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return models.Money{}, err
+	}
+	return models.MoneyFromFloat(f, currency), nil
+}
+
 // categorizeBatch categorizes a batch of transactions
-func (a *Analyzer) categorizeBatch(transactions []*models.Transaction) error {
+func (a *Analyzer) categorizeBatch(ctx context.Context, transactions []*models.Transaction) error {
 	// Build the prompt for categorization
 	prompt := a.buildCategorizationPrompt(transactions)
 
 	// Create the API request
-	request := ClaudeAPIRequest{
+	request := llm.Request{
 		Model:       a.model, // use same configured model for consistency
 		MaxTokens:   a.maxTokens,
 		Temperature: 0.2, // Slightly lower temp for deterministic labels
-		Messages: []Message{
+		Messages: []llm.Message{
 			{
 				Role:    "user",
 				Content: prompt,
 			},
 		},
+		Tools:      []llm.Tool{categorizationTool},
+		ToolChoice: categorizationTool.Name,
 	}
 
-	// Make the API call
-	response, err := a.callClaudeAPI(request)
+	// Make the API call; its usage is shared between the files of the batch
+	sources := make([]string, len(transactions))
+	for i, tx := range transactions {
+		sources[i] = tx.Source
+	}
+	response, err := a.complete(ctx, request, usage.PhaseCategorization, sources, func(response *llm.Response) error {
+		return a.parseCategorizationResponse(response, transactions)
+	})
 	if err != nil {
 		return err
 	}
-
-	// Parse the response and update transactions
-	return a.parseCategorizationResponse(response, transactions)
+	if response.Truncated() {
+		// Reports that the reply was cut off
+		return a.parseCategorizationResponse(response, transactions)
+	}
+	return nil
 }
 
 // buildCategorizationPrompt creates a prompt for transaction categorization
 func (a *Analyzer) buildCategorizationPrompt(transactions []*models.Transaction) string {
 	var sb strings.Builder
 
-	sb.WriteString("You are a financial transaction categorizer. Analyze the following transactions and categorize each one with a main category and subcategory. If you are not completely sure about the category, use 'Other'. Use standard financial categories like:\n\n")
-	sb.WriteString("- Food & Dining (Restaurants, Groceries, Fast Food, Coffee)\n")
-	sb.WriteString("- Transportation (Gas, Public Transit, Ride Sharing, Parking)\n")
-	sb.WriteString("- Shopping (Clothing, Electronics, Home & Garden, Online Shopping)\n")
-	sb.WriteString("- Entertainment (Movies, Games, Streaming Services, Events)\n")
-	sb.WriteString("- Health & Fitness (Medical, Gym, Pharmacy, Wellness)\n")
-	sb.WriteString("- Bills & Utilities (Electricity, Water, Internet, Phone)\n")
-	sb.WriteString("- Income (Salary, Freelance, Investment, Refunds)\n")
-	sb.WriteString("- Banking (ATM, Fees, Transfers)\n")
-	sb.WriteString("- Travel (Flights, Hotels, Car Rental, Tourism)\n")
-	sb.WriteString("- Education (Tuition, Books, Courses)\n")
-	sb.WriteString("- Insurance (Health, Auto, Home, Life)\n")
-	sb.WriteString("- Other (Uncategorized)\n\n")
+	fallback := a.categories.FallbackCategory()
+	sb.WriteString("You are a financial transaction categorizer. Analyze the following transactions and categorize each one using only the categories below. ")
+	sb.WriteString(fmt.Sprintf("If you are not completely sure about the category, use '%s'.\n\n", fallback.ID))
+	sb.WriteString("Categories as \"id: name (kind)\"; subcategories are indented under their parent. ")
+	sb.WriteString("The kind says whether the category counts as expense, income or a transfer between the user's own accounts:\n\n")
+	sb.WriteString(a.categories.PromptList())
+	sb.WriteString("\n")
 
 	sb.WriteString("For each transaction, provide:\n")
-	sb.WriteString("1. Main category (from the list above)\n")
-	sb.WriteString("2. Subcategory (specific type within the main category)\n")
+	sb.WriteString("1. category: the id of the top-level category\n")
+	sb.WriteString("2. subcategory: the id of the most specific category that applies (the top-level id if it has no fitting subcategory)\n")
 	sb.WriteString("3. Confidence level (0.0 to 1.0, where 1.0 is very confident)\n\n")
 
-	sb.WriteString("Respond ONLY with a JSON array (no preface, no explanation). Format like this:\n")
-	sb.WriteString("[\n")
-	sb.WriteString("  {\n")
-	sb.WriteString("    \"index\": 0,\n")
-	sb.WriteString("    \"category\": \"Food & Dining\",\n")
-	sb.WriteString("    \"subcategory\": \"Restaurants\",\n")
-	sb.WriteString("    \"confidence\": 0.95\n")
-	sb.WriteString("  }\n")
-	sb.WriteString("]\n\n")
+	sb.WriteString("Record the results with the " + categorizationTool.Name + " tool.\n\n")
+
+	// Categories the user corrected before, so the same mistakes are not repeated
+	if examples := a.learned.Examples(); len(examples) > 0 {
+		sb.WriteString("The user corrected these categorizations before. Categorize similar transactions the same way:\n")
+		for _, c := range examples {
+			sb.WriteString(fmt.Sprintf("- %s | Amount: %s | Type: %s -> %s / %s",
+				c.Description, c.Amount, c.Type, c.Category, c.Subcategory))
+			if c.FromCategory != "" && c.FromCategory != c.Category {
+				sb.WriteString(fmt.Sprintf(" (not %s)", c.FromCategory))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
 
 	sb.WriteString("Here are the transactions to categorize in index order (use the index to map your output):\n\n")
 
 	for i, tx := range transactions {
-		sb.WriteString(fmt.Sprintf("%d. Date: %s | Description: %s | Amount: %.2f | Type: %s\n",
+		sb.WriteString(fmt.Sprintf("%d. Date: %s | Description: %s | Amount: %s | Type: %s\n",
 			i, tx.Date.Format("2006-01-02"), tx.Description, tx.Amount, tx.Type))
 	}
 
 	return sb.String()
 }
 
-// callClaudeAPI makes a request to the Claude API
-func (a *Analyzer) callClaudeAPI(request ClaudeAPIRequest) (*ClaudeAPIResponse, error) {
+// complete sends a request to the configured LLM provider and passes the reply to decode.
+// Its usage is recorded against phase and the source file of every item in the request.
+// Only replies that decode are cached, so a reply the caller rejects is asked for again
+// on the next run. Truncated replies are returned without decoding; callers retry them.
+func (a *Analyzer) complete(ctx context.Context, request llm.Request, phase string, sources []string, decode func(*llm.Response) error) (*llm.Response, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
+	// Identical requests are answered from the cache without calling the model
+	if a.cache != nil {
+		if cached, ok := a.cache.Get(request); ok {
+			respBytes, _ := json.Marshal(cached)
+			a.saveDebugFile("response_cached", respBytes)
+			if err := decode(cached); err == nil {
+				if a.usage != nil {
+					a.usage.Record(phase, sources, request.Model, cached.Usage, true)
+				}
+				return cached, nil
+			}
+			// Entries written before replies were checked may not decode; ask again
+			a.cache.Delete(request)
+		}
+	}
+
 	// Cost-control: respect max requests cap
+	a.requestsMu.Lock()
 	if a.maxRequests > 0 && a.requestsMade >= a.maxRequests {
+		a.requestsMu.Unlock()
 		return nil, fmt.Errorf("request limit reached (%d)", a.maxRequests)
 	}
 	a.requestsMade++
+	a.requestsMu.Unlock()
 
 	// Debug: save request
 	a.saveDebugFile("request", jsonData)
 
 	if a.dryRun {
-		// Return an empty array as a harmless mock to test the pipeline without cost
-		mock := &ClaudeAPIResponse{
-			Type: "message",
-			Content: []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			}{
-				{Type: "text", Text: "[]"},
-			},
+		// Return an empty result as a harmless mock to test the pipeline without cost
+		mock := &llm.Response{Text: "[]"}
+		for _, tool := range request.Tools {
+			if tool.Name == request.ToolChoice {
+				mock = &llm.Response{ToolCalls: []llm.ToolCall{{Name: tool.Name, Input: tool.InputSchema.Empty()}}}
+			}
 		}
 		respBytes, _ := json.Marshal(mock)
 		a.saveDebugFile("response_mock", respBytes)
-		return mock, nil
+		return mock, decode(mock)
 	}
 
-	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
-		req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("x-api-key", a.apiKey)
-		req.Header.Set("anthropic-version", "2023-06-01")
-
-		resp, err := a.httpClient.Do(req)
-		if err != nil {
-			var netErr net.Error
-			if errors.As(err, &netErr) && netErr.Timeout() && attempt < 3 {
-				backoff := time.Duration(attempt*2) * time.Second
-				fmt.Printf("Transient timeout from Claude API (attempt %d). Retrying in %s...\n", attempt, backoff)
-				time.Sleep(backoff)
-				lastErr = err
-				continue
-			}
-			return nil, fmt.Errorf("failed to make API request: %v", err)
+	// Cost-control: refuse requests that could exceed the budget
+	var reservation usage.Reservation
+	if a.usage != nil {
+		if reservation, err = a.usage.Reserve(request); err != nil {
+			return nil, err
 		}
+	}
 
-		body, readErr := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if readErr != nil {
-			return nil, fmt.Errorf("failed to read response body: %v", readErr)
+	select {
+	case a.slots <- struct{}{}:
+	case <-ctx.Done():
+		if a.usage != nil {
+			a.usage.Release(reservation)
 		}
-
-		if resp.StatusCode == http.StatusOK {
-			var response ClaudeAPIResponse
-			if err := json.Unmarshal(body, &response); err != nil {
-				return nil, fmt.Errorf("failed to decode response: %v", err)
-			}
-			// Debug: save response
-			a.saveDebugFile("response", body)
-			return &response, nil
+		return nil, ctx.Err()
+	}
+	response, err := a.provider.Complete(ctx, request)
+	<-a.slots
+	if err != nil {
+		if a.usage != nil {
+			a.usage.Release(reservation)
 		}
+		return nil, fmt.Errorf("%s: %v", a.provider.Name(), err)
+	}
+	if a.usage != nil {
+		a.usage.Settle(reservation, phase, sources, request.Model, response.Usage)
+	}
 
-		// Retry on 429/5xx
-		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) && attempt < 3 {
-			backoff := time.Duration(attempt*2) * time.Second
-			fmt.Printf("Claude API returned status %d (attempt %d). Retrying in %s...\n", resp.StatusCode, attempt, backoff)
-			time.Sleep(backoff)
-			lastErr = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
-			continue
-		}
+	// Debug: save response
+	respBytes, _ := json.Marshal(response)
+	a.saveDebugFile("response", respBytes)
 
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	// Truncated replies are not cached since they are retried differently
+	if response.Truncated() {
+		return response, nil
 	}
-
-	if lastErr == nil {
-		lastErr = fmt.Errorf("unknown error during Claude API call")
+	if err := decode(response); err != nil {
+		return response, err
 	}
-	return nil, lastErr
+	if a.cache != nil {
+		if err := a.cache.Put(request, response); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to cache response: %v\n", err)
+		}
+	}
+	return response, nil
 }
 
 // splitTextForExtraction splits text into chunks not exceeding approx chunkSize runes.
@@ -719,38 +803,40 @@ type CategorizationResult struct {
 	Confidence  float64 `json:"confidence"`
 }
 
-// parseCategorizationResponse parses the Claude API response and updates transactions
-func (a *Analyzer) parseCategorizationResponse(response *ClaudeAPIResponse, transactions []*models.Transaction) error {
-	if len(response.Content) == 0 {
-		return fmt.Errorf("no content in API response")
+// parseCategorizationResponse applies the record_categories tool input to the transactions
+func (a *Analyzer) parseCategorizationResponse(response *llm.Response, transactions []*models.Transaction) error {
+	var output struct {
+		Categories []CategorizationResult `json:"categories"`
 	}
-
-	// Extract the text content
-	content := response.Content[0].Text
-
-	// Apply the same JSON extraction as we did for transactions
-	jsonContent, err := extractJSONFromResponse(content)
-	if err != nil {
-		return fmt.Errorf("failed to extract JSON from categorization response: %v", err)
+	if err := categorizationTool.Decode(response, &output); err != nil {
+		return fmt.Errorf("failed to parse categorization response: %v", err)
 	}
+	results := output.Categories
 
-	fmt.Printf("DEBUG - Extracted categorization JSON: %s\n", jsonContent) // Temporary debug
-
-	// Try to parse as JSON
-	var results []CategorizationResult
-	if err := json.Unmarshal([]byte(jsonContent), &results); err != nil {
-		return fmt.Errorf("failed to parse categorization response: %v\nJSON content: %s", err, jsonContent)
-	}
-
-	// Update transactions with categorization results
+	// Update transactions with categorization results; answers outside the taxonomy get the fallback
+	unmapped := 0
+	var unknown []string
+	seen := make(map[string]bool)
 	for _, result := range results {
 		if result.Index >= 0 && result.Index < len(transactions) {
 			tx := transactions[result.Index]
-			tx.Category = result.Category
-			tx.Subcategory = result.Subcategory
+			category, ok := a.categories.Resolve(result.Category, result.Subcategory)
+			if !ok {
+				unmapped++
+				if label := result.Category + " / " + result.Subcategory; !seen[label] {
+					seen[label] = true
+					unknown = append(unknown, label)
+				}
+			}
+			taxonomy.Assign(tx, category)
 			tx.Confidence = result.Confidence
+			tx.CategorizedBy = "model:" + a.model
 		}
 	}
+	if unmapped > 0 {
+		fmt.Printf("⚠️  Warning: %d answers were not in the category taxonomy and were mapped to %s: %s\n",
+			unmapped, a.categories.FallbackCategory().Name, strings.Join(unknown, ", "))
+	}
 
 	return nil
 }
@@ -791,27 +877,55 @@ func (a *Analyzer) generateCSVReport(transactions []*models.Transaction, outputD
 	}
 	defer file.Close()
 
-	// Write CSV header
-	file.WriteString("Date,Description,Amount,Type,Category,Subcategory,Confidence,Source\n")
+	rates := a.exchangeRates()
+
+	// Values are quoted by the CSV writer, so commas and quotes in descriptions or
+	// category names never shift the columns the corrections import reads back
+	w := csv.NewWriter(file)
+	w.Write([]string{"Date", "Description", "Payee", "Amount", "Currency", "OriginalAmount", "OriginalCurrency",
+		"BaseAmount", "BaseCurrency", "Type", "Category", "Subcategory", "Confidence", "Tags", "CategorizedBy",
+		"Transfer", "TransferID", "Account", "Source"})
 
-	// Write transaction data
+	// Write transaction data; amounts are exact decimals in the currency's minor unit.
+	// BaseAmount is left empty when no exchange rate is available.
 	for _, tx := range transactions {
-		line := fmt.Sprintf("%s,\"%s\",%.2f,%s,%s,%s,%.2f,%s\n",
+		var originalAmount, baseAmount string
+		if tx.OriginalAmount.Currency != "" {
+			originalAmount = tx.OriginalAmount.Decimal()
+		}
+		if converted, err := rates.Convert(tx.Amount, tx.Date); err == nil {
+			baseAmount = converted.Decimal()
+		}
+		w.Write([]string{
 			tx.Date.Format("2006-01-02"),
-			strings.ReplaceAll(tx.Description, "\"", "\"\""), // Escape quotes
-			tx.Amount,
-			tx.Type,
+			tx.Description,
+			tx.Payee,
+			tx.Amount.Decimal(),
+			tx.Amount.Currency,
+			originalAmount,
+			tx.OriginalAmount.Currency,
+			baseAmount,
+			rates.Base(),
+			tx.Type.String(),
 			tx.Category,
 			tx.Subcategory,
-			tx.Confidence,
-			tx.Source)
-		file.WriteString(line)
+			fmt.Sprintf("%.2f", tx.Confidence),
+			strings.Join(tx.Tags, ";"),
+			tx.CategorizedBy,
+			tx.TransferKind,
+			tx.TransferID,
+			accountLabel(tx),
+			tx.Source,
+		})
 	}
 
-	return nil
+	w.Flush()
+	return w.Error()
 }
 
-// generateSummaryReport creates a summary analysis report
+// generateSummaryReport creates a summary analysis report.
+// Totals are converted to the base currency; totals in the original currencies
+// of the charges are listed alongside them.
 func (a *Analyzer) generateSummaryReport(transactions []*models.Transaction, outputDir string) error {
 	filename := fmt.Sprintf("summary_%s.txt", time.Now().Format("20060102"))
 	filepath := fmt.Sprintf("%s/%s", outputDir, filename)
@@ -822,51 +936,387 @@ func (a *Analyzer) generateSummaryReport(transactions []*models.Transaction, out
 	}
 	defer file.Close()
 
+	rates := a.exchangeRates()
+	converted, missing := convertTransactions(transactions, rates)
+
 	// Calculate summary statistics
-	summary := a.calculateSummary(transactions)
+	summary := a.calculateSummary(converted)
 
 	// Write summary report
 	file.WriteString("FINANCIAL ANALYSIS SUMMARY\n")
 	file.WriteString("=========================\n\n")
 	file.WriteString(fmt.Sprintf("Analysis Date: %s\n", time.Now().Format("2006-01-02 15:04:05")))
 	file.WriteString(fmt.Sprintf("Total Transactions: %d\n", len(transactions)))
+	file.WriteString(fmt.Sprintf("Base Currency: %s\n", rates.Base()))
 	file.WriteString(fmt.Sprintf("Date Range: %s to %s\n\n", summary.StartDate, summary.EndDate))
 
 	file.WriteString("SPENDING BY CATEGORY\n")
 	file.WriteString("===================\n")
 	for category, amount := range summary.CategoryTotals {
-		file.WriteString(fmt.Sprintf("%s: $%.2f\n", category, amount))
+		file.WriteString(fmt.Sprintf("%s: %s\n", category, amount))
 	}
 
 	file.WriteString("\nINCOME SUMMARY\n")
 	file.WriteString("==============\n")
-	file.WriteString(fmt.Sprintf("Total Income: $%.2f\n", summary.TotalIncome))
-	file.WriteString(fmt.Sprintf("Total Expenses: $%.2f\n", summary.TotalExpenses))
-	file.WriteString(fmt.Sprintf("Net: $%.2f\n", summary.NetAmount))
+	file.WriteString(fmt.Sprintf("Total Income: %s\n", summary.TotalIncome))
+	file.WriteString(fmt.Sprintf("Total Expenses: %s\n", summary.TotalExpenses))
+	file.WriteString(fmt.Sprintf("Net: %s\n", summary.NetAmount))
+	if summary.Transfers > 0 {
+		file.WriteString(fmt.Sprintf("Transfers (%d, not counted as income or expenses): %s\n", summary.Transfers, summary.TotalTransfers))
+	}
+
+	// Amounts in different currencies cannot be added; total each original currency separately
+	byCurrency := groupByCurrency(originalAmounts(transactions))
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	if len(currencies) > 1 || (len(currencies) == 1 && currencies[0] != rates.Base()) {
+		file.WriteString("\nORIGINAL CURRENCY TOTALS\n")
+		file.WriteString("========================\n")
+		for _, currency := range currencies {
+			original := a.calculateSummary(byCurrency[currency])
+			file.WriteString(fmt.Sprintf("%s (%d transactions): income %s, expenses %s, net %s\n",
+				currency, len(byCurrency[currency]), original.TotalIncome, original.TotalExpenses, original.NetAmount))
+		}
+	}
+
+	writeTransfers(file, converted)
+	a.writeTopMerchants(file, converted)
+	a.writeAccountSummaries(file, converted)
+	a.writeStatementSummaries(file, transactions)
+	writeCategorizationSources(file, transactions)
+
+	if len(missing) > 0 {
+		file.WriteString("\nMISSING EXCHANGE RATES\n")
+		file.WriteString("======================\n")
+		file.WriteString(fmt.Sprintf("%d transactions could not be converted to %s and are excluded from the totals above:\n", len(missing), rates.Base()))
+		for _, tx := range missing {
+			file.WriteString(fmt.Sprintf("%s %s %s (%s)\n", tx.Date.Format("2006-01-02"), tx.Amount, tx.Description, tx.Source))
+		}
+	}
 
 	return nil
 }
 
-// SummaryStats holds summary statistics
+// topMerchants is how many payees the TOP MERCHANTS section lists
+const topMerchants = 10
+
+// writeTransfers writes the transfers paired between the user's own accounts, credit
+// card payments apart from other transfers, in the base currency. Pairs with a leg
+// outside the report are left out.
+func writeTransfers(file *os.File, converted []*models.Transaction) {
+	type pair struct{ out, in *models.Transaction }
+	var ids []string
+	pairs := make(map[string]*pair)
+	for _, tx := range converted {
+		if tx.TransferID == "" {
+			continue
+		}
+		p, ok := pairs[tx.TransferID]
+		if !ok {
+			p = &pair{}
+			pairs[tx.TransferID] = p
+			ids = append(ids, tx.TransferID)
+		}
+		if tx.Amount.IsNegative() {
+			p.out = tx
+		} else {
+			p.in = tx
+		}
+	}
+
+	// Spreadsheet exports have no account; their file stands in for it
+	label := func(tx *models.Transaction) string {
+		if tx.Statement == nil {
+			return tx.Source
+		}
+		return accountLabel(tx)
+	}
+	header := false
+	for _, kind := range []struct{ kind, title string }{
+		{models.TransferCardPayment, "Credit card payments"},
+		{models.TransferOwnAccounts, "Between own accounts"},
+	} {
+		var listed []*pair
+		var total models.Money
+		for _, id := range ids {
+			if p := pairs[id]; p.out != nil && p.in != nil && p.in.TransferKind == kind.kind {
+				listed = append(listed, p)
+				total = total.Add(p.in.Amount)
+			}
+		}
+		if len(listed) == 0 {
+			continue
+		}
+		if !header {
+			file.WriteString("\nTRANSFERS BETWEEN OWN ACCOUNTS\n")
+			file.WriteString("==============================\n")
+			header = true
+		}
+		file.WriteString(fmt.Sprintf("%s (%d): %s\n", kind.title, len(listed), total))
+		for _, p := range listed {
+			file.WriteString(fmt.Sprintf("  %s %s -> %s: %s\n",
+				p.out.Date.Format("2006-01-02"), label(p.out), label(p.in), p.in.Amount))
+		}
+	}
+}
+
+// writeTopMerchants writes the payees the most was spent at, in the base currency.
+// Transfers between the user's own accounts are not spending and are left out.
+func (a *Analyzer) writeTopMerchants(file *os.File, converted []*models.Transaction) {
+	type merchant struct {
+		payee string
+		spent models.Money
+		count int
+	}
+	byPayee := make(map[string]*merchant)
+	for _, tx := range converted {
+		if tx.Type != models.Debit || tx.Payee == "" || tx.TransferID != "" || a.categories.KindOf(tx) == taxonomy.KindTransfer {
+			continue
+		}
+		m, ok := byPayee[tx.Payee]
+		if !ok {
+			m = &merchant{payee: tx.Payee, spent: models.Money{Currency: tx.Amount.Currency}}
+			byPayee[tx.Payee] = m
+		}
+		m.spent = m.spent.Add(tx.Amount.Abs())
+		m.count++
+	}
+	if len(byPayee) == 0 {
+		return
+	}
+	merchants := make([]*merchant, 0, len(byPayee))
+	for _, m := range byPayee {
+		merchants = append(merchants, m)
+	}
+	sort.Slice(merchants, func(i, j int) bool {
+		if merchants[i].spent.Minor != merchants[j].spent.Minor {
+			return merchants[i].spent.Minor > merchants[j].spent.Minor
+		}
+		return merchants[i].payee < merchants[j].payee
+	})
+	if len(merchants) > topMerchants {
+		merchants = merchants[:topMerchants]
+	}
+
+	file.WriteString("\nTOP MERCHANTS\n")
+	file.WriteString("=============\n")
+	for i, m := range merchants {
+		file.WriteString(fmt.Sprintf("%2d. %s: %s (%d transactions)\n", i+1, m.payee, m.spent, m.count))
+	}
+}
+
+// writeAccountSummaries writes income, expenses and net per account, in the base currency
+func (a *Analyzer) writeAccountSummaries(file *os.File, converted []*models.Transaction) {
+	byAccount := make(map[string][]*models.Transaction)
+	for _, tx := range converted {
+		label := accountLabel(tx)
+		byAccount[label] = append(byAccount[label], tx)
+	}
+	labels := make([]string, 0, len(byAccount))
+	for label := range byAccount {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	file.WriteString("\nBY ACCOUNT\n")
+	file.WriteString("==========\n")
+	for _, label := range labels {
+		summary := a.calculateSummary(byAccount[label])
+		file.WriteString(fmt.Sprintf("%s (%d transactions): income %s, expenses %s, net %s\n",
+			label, len(byAccount[label]), summary.TotalIncome, summary.TotalExpenses, summary.NetAmount))
+	}
+}
+
+// writeStatementSummaries writes the header figures and totals of every statement,
+// in the currencies its transactions were listed in
+func (a *Analyzer) writeStatementSummaries(file *os.File, transactions []*models.Transaction) {
+	var statements []*models.Statement
+	byStatement := make(map[*models.Statement][]*models.Transaction)
+	for _, tx := range transactions {
+		if tx.Statement == nil {
+			continue
+		}
+		if _, ok := byStatement[tx.Statement]; !ok {
+			statements = append(statements, tx.Statement)
+		}
+		byStatement[tx.Statement] = append(byStatement[tx.Statement], tx)
+	}
+	if len(statements) == 0 {
+		return
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		if li, lj := statementAccountLabel(statements[i]), statementAccountLabel(statements[j]); li != lj {
+			return li < lj
+		}
+		return statements[i].PeriodEnd.Before(statements[j].PeriodEnd)
+	})
+
+	file.WriteString("\nSTATEMENTS\n")
+	file.WriteString("==========\n")
+	for _, stmt := range statements {
+		// A card billed in one currency may list charges in another; total each currency separately
+		byCurrency := groupByCurrency(byStatement[stmt])
+		currencies := make([]string, 0, len(byCurrency))
+		for currency := range byCurrency {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		totals := make([]string, 0, len(currencies))
+		for _, currency := range currencies {
+			summary := a.calculateSummary(byCurrency[currency])
+			totals = append(totals, fmt.Sprintf("credits %s, debits %s", summary.TotalIncome, summary.TotalExpenses))
+		}
+		file.WriteString(fmt.Sprintf("%s [%s]\n", stmt.Label(), stmt.Source))
+		file.WriteString(fmt.Sprintf("  Transactions: %d, %s\n", len(byStatement[stmt]), strings.Join(totals, "; ")))
+		file.WriteString(fmt.Sprintf("  Opening balance: %s, closing balance: %s\n", stmt.OpeningBalance, stmt.ClosingBalance))
+		if !stmt.TotalDebits.IsZero() || !stmt.TotalCredits.IsZero() {
+			file.WriteString(fmt.Sprintf("  Printed totals: debits %s, credits %s\n", stmt.TotalDebits, stmt.TotalCredits))
+		}
+		if !stmt.DueDate.IsZero() || !stmt.MinimumPayment.IsZero() {
+			file.WriteString(fmt.Sprintf("  Due date: %s, minimum payment: %s\n", formatDate(stmt.DueDate), stmt.MinimumPayment))
+		}
+	}
+}
+
+// writeCategorizationSources writes how many transactions each rule and the model categorized
+func writeCategorizationSources(file *os.File, transactions []*models.Transaction) {
+	counts := make(map[string]int)
+	for _, tx := range transactions {
+		switch {
+		case tx.Category == "":
+			counts["uncategorized"]++
+		case tx.CategorizedBy == "":
+			counts["unknown (categorized before sources were tracked)"]++
+		default:
+			counts[tx.CategorizedBy]++
+		}
+	}
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if counts[sources[i]] != counts[sources[j]] {
+			return counts[sources[i]] > counts[sources[j]]
+		}
+		return sources[i] < sources[j]
+	})
+
+	file.WriteString("\nCATEGORIZED BY\n")
+	file.WriteString("==============\n")
+	for _, source := range sources {
+		file.WriteString(fmt.Sprintf("%s: %d transactions\n", source, counts[source]))
+	}
+}
+
+// accountLabel returns the label of the transaction's account, or "Unassigned"
+func accountLabel(tx *models.Transaction) string {
+	if tx.Statement == nil {
+		return "Unassigned"
+	}
+	return statementAccountLabel(tx.Statement)
+}
+
+func statementAccountLabel(s *models.Statement) string {
+	if s.Account == nil {
+		return "Unassigned"
+	}
+	return s.Account.Label()
+}
+
+// formatDate formats d as YYYY-MM-DD, or "n/a" for the zero time
+func formatDate(d time.Time) string {
+	if d.IsZero() {
+		return "n/a"
+	}
+	return d.Format("2006-01-02")
+}
+
+// exchangeRates returns the configured FX table, or an empty one in the default currency
+func (a *Analyzer) exchangeRates() *fx.Table {
+	if a.rates == nil {
+		return fx.NewTable(models.DefaultCurrency)
+	}
+	return a.rates
+}
+
+// convertTransactions returns copies of the transactions with amounts converted to
+// the base currency, and the transactions for which no rate was available
+func convertTransactions(transactions []*models.Transaction, rates *fx.Table) ([]*models.Transaction, []*models.Transaction) {
+	var converted, missing []*models.Transaction
+	for _, tx := range transactions {
+		amount, err := rates.Convert(tx.Amount, tx.Date)
+		if err != nil {
+			missing = append(missing, tx)
+			continue
+		}
+		c := *tx
+		c.Amount = amount
+		converted = append(converted, &c)
+	}
+	return converted, missing
+}
+
+// originalAmounts returns copies of the transactions carrying the amount in the
+// currency each charge was made in
+func originalAmounts(transactions []*models.Transaction) []*models.Transaction {
+	result := make([]*models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		c := *tx
+		if tx.OriginalAmount.Currency != "" {
+			c.Amount = tx.OriginalAmount
+		}
+		result = append(result, &c)
+	}
+	return result
+}
+
+// groupByCurrency splits transactions by the currency of their amount
+func groupByCurrency(transactions []*models.Transaction) map[string][]*models.Transaction {
+	groups := make(map[string][]*models.Transaction)
+	for _, tx := range transactions {
+		currency := tx.Amount.Currency
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		groups[currency] = append(groups[currency], tx)
+	}
+	return groups
+}
+
+// SummaryStats holds summary statistics for transactions in a single currency.
+// TotalExpenses is negative, following the amount sign convention, so NetAmount
+// is TotalIncome + TotalExpenses. Transactions in a transfer category or paired
+// as a transfer between own accounts are counted in Transfers and TotalTransfers
+// instead of income or expenses.
 type SummaryStats struct {
 	StartDate      string
 	EndDate        string
-	CategoryTotals map[string]float64
-	TotalIncome    float64
-	TotalExpenses  float64
-	NetAmount      float64
+	CategoryTotals map[string]models.Money
+	TotalIncome    models.Money
+	TotalExpenses  models.Money
+	NetAmount      models.Money
+	Transfers      int
+	TotalTransfers models.Money
 }
 
-// calculateSummary calculates summary statistics from transactions
+// calculateSummary calculates summary statistics from transactions sharing one currency
 func (a *Analyzer) calculateSummary(transactions []*models.Transaction) SummaryStats {
 	summary := SummaryStats{
-		CategoryTotals: make(map[string]float64),
+		CategoryTotals: make(map[string]models.Money),
 	}
 
 	if len(transactions) == 0 {
 		return summary
 	}
 
+	// Start the totals in the group's currency so that empty totals still print it
+	zero := models.Money{Currency: transactions[0].Amount.Currency}
+	summary.TotalIncome, summary.TotalExpenses, summary.TotalTransfers = zero, zero, zero
+
 	// Find date range
 	startDate := transactions[0].Date
 	endDate := transactions[0].Date
@@ -879,22 +1329,29 @@ func (a *Analyzer) calculateSummary(transactions []*models.Transaction) SummaryS
 			endDate = tx.Date
 		}
 
-		// Calculate category totals
-		if tx.Category != "" {
-			summary.CategoryTotals[tx.Category] += tx.Amount
+		// Calculate category totals; the paired legs of a transfer are not spending in whatever category they got
+		if tx.Category != "" && tx.TransferID == "" {
+			summary.CategoryTotals[tx.Category] = summary.CategoryTotals[tx.Category].Add(tx.Amount)
+		}
+
+		// Money moved between the user's own accounts is neither income nor expense
+		if tx.TransferID != "" || a.categories.KindOf(tx) == taxonomy.KindTransfer {
+			summary.Transfers++
+			summary.TotalTransfers = summary.TotalTransfers.Add(tx.Amount)
+			continue
 		}
 
 		// Calculate income vs expenses
 		if tx.Type == models.Credit {
-			summary.TotalIncome += tx.Amount
+			summary.TotalIncome = summary.TotalIncome.Add(tx.Amount)
 		} else {
-			summary.TotalExpenses += tx.Amount
+			summary.TotalExpenses = summary.TotalExpenses.Add(tx.Amount)
 		}
 	}
 
 	summary.StartDate = startDate.Format("2006-01-02")
 	summary.EndDate = endDate.Format("2006-01-02")
-	summary.NetAmount = summary.TotalIncome - summary.TotalExpenses
+	summary.NetAmount = summary.TotalIncome.Add(summary.TotalExpenses)
 
 	return summary
 }
@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/corrections"
+	"github.com/KerynSuoress/finance-manager/internal/llm"
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func TestWriteStatementSummariesMixedCurrencies(t *testing.T) {
+	stmt := &models.Statement{
+		Account:        &models.Account{Kind: models.AccountCreditCard, Number: "7002", Name: "Mastercard", Currency: "COP"},
+		Source:         "card.pdf",
+		ClosingBalance: models.NewMoney(15000000, "COP"),
+	}
+	date := time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	transactions := []*models.Transaction{
+		{Date: date, Description: "EXITO", Amount: models.NewMoney(-5000000, "COP"), Type: models.Debit, Statement: stmt},
+		{Date: date, Description: "PAGO", Amount: models.NewMoney(2000000, "COP"), Type: models.Credit, Statement: stmt},
+		{Date: date, Description: "NETFLIX.COM", Amount: models.NewMoney(-1599, "USD"), Type: models.Debit, Statement: stmt},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.txt")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewAnalyzerWithProvider(nil, "").writeStatementSummaries(file, transactions)
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Transactions: 3, credits 20000.00 COP, debits -50000.00 COP; credits 0.00 USD, debits -15.99 USD"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("statement summary missing %q:\n%s", want, data)
+	}
+}
+
+func TestExtractAndCategorizeWithReplay(t *testing.T) {
+	provider, err := llm.NewReplay("testdata/replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Server().Close()
+
+	text, err := os.ReadFile("testdata/statement.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAnalyzerWithProvider(provider, "replay-model")
+	ctx := context.Background()
+	transactions, err := a.ExtractTransactionsFromText(ctx, string(text), "savings_june.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CategorizeTransactions(ctx, transactions); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		date        string
+		description string
+		amount      models.Money
+		kind        models.TransactionType
+		balance     models.Money
+		categoryID  string
+	}{
+		{"2025-06-03", "EXITO COLINA", models.NewMoney(-12500050, "COP"), models.Debit, models.NewMoney(87499950, "COP"), "food.groceries"},
+		{"2025-06-05", "NOMINA ACME SAS", models.NewMoney(300000000, "COP"), models.Credit, models.NewMoney(387499950, "COP"), "income.salary"},
+		{"2025-06-09", "UBER TRIP", models.NewMoney(-1840000, "COP"), models.Debit, models.NewMoney(385659950, "COP"), "transportation.ride-sharing"},
+	}
+	if len(transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(want))
+	}
+	for i, w := range want {
+		tx := transactions[i]
+		if got := tx.Date.Format("2006-01-02"); got != w.date || tx.Description != w.description ||
+			tx.Amount != w.amount || tx.Type != w.kind || tx.Balance != w.balance {
+			t.Errorf("transaction %d = %s %q %s %s balance %s, want %s %q %s %s balance %s", i,
+				got, tx.Description, tx.Amount, tx.Type, tx.Balance, w.date, w.description, w.amount, w.kind, w.balance)
+		}
+		if tx.CategoryID != w.categoryID || tx.Source != "savings_june.pdf" {
+			t.Errorf("transaction %d categorized %q from %q, want %q", i, tx.CategoryID, tx.Source, w.categoryID)
+		}
+	}
+
+	// Every prompt must have its own recording; re-record them after changing a prompt
+	if served, missed := provider.Server().Stats(); served != 2 || len(missed) > 0 {
+		t.Errorf("replay served %d requests, missed %v", served, missed)
+	}
+}
+
+func TestCSVReportRoundTripsThroughCorrections(t *testing.T) {
+	tx := &models.Transaction{
+		Date:        time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Description: `PAGO "PSE", CLARO`,
+		Amount:      models.NewMoney(-8990000, "COP"),
+		Type:        models.Debit,
+		Category:    "Bills, Utilities & \"Home\"",
+		Subcategory: "Phone, Internet",
+		Tags:        []string{"essentials", "home, office"},
+		Source:      "statement, june.pdf",
+	}
+	dir := t.TempDir()
+	if err := NewAnalyzerWithProvider(nil, "").generateCSVReport([]*models.Transaction{tx}, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "transactions_*.csv"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("report files %v, %v", paths, err)
+	}
+	rows, err := corrections.ReadReport(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	row := rows[0]
+	if row.Description != tx.Description || row.Amount != tx.Amount || row.Category != tx.Category ||
+		row.Subcategory != tx.Subcategory || row.Source != tx.Source {
+		t.Errorf("row read back as %+v", row)
+	}
+}
+
+// scriptedProvider answers requests with its replies in turn
+type scriptedProvider struct {
+	replies []*llm.Response
+	calls   int
+}
+
+func (p *scriptedProvider) Name() string { return "scripted" }
+
+func (p *scriptedProvider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	reply := p.replies[p.calls]
+	p.calls++
+	return reply, nil
+}
+
+func TestRejectedRepliesAreNotCached(t *testing.T) {
+	reply := func(input string) *llm.Response {
+		return &llm.Response{StopReason: "tool_use", ToolCalls: []llm.ToolCall{{Name: extractionTool.Name, Input: []byte(input)}}}
+	}
+	provider := &scriptedProvider{replies: []*llm.Response{
+		reply(`{"transactions": [{"date": "2025-06-03", "description": "EXITO COLINA"}]}`),
+		reply(`{"transactions": [{"date": "2025-06-03", "description": "EXITO COLINA", "amount": -125000.50, "type": "debit"}]}`),
+	}}
+	cache, err := llm.OpenCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAnalyzerWithProvider(provider, "scripted-model")
+	a.SetCache(cache)
+	ctx := context.Background()
+
+	if _, err := a.ExtractTransactionsFromText(ctx, "03/06 EXITO COLINA -125.000,50", "june.pdf"); err == nil {
+		t.Fatal("reply without amount and type was accepted")
+	}
+	if writes := cache.Stats().Writes; writes != 0 {
+		t.Fatalf("rejected reply was cached (%d writes)", writes)
+	}
+
+	// The rerun asks the model again and caches the reply that decodes
+	for run := 0; run < 2; run++ {
+		transactions, err := a.ExtractTransactionsFromText(ctx, "03/06 EXITO COLINA -125.000,50", "june.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 1 {
+			t.Fatalf("got %d transactions, want 1", len(transactions))
+		}
+	}
+	if provider.calls != 2 {
+		t.Errorf("provider called %d times, want 2", provider.calls)
+	}
+}
@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/llm"
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/usage"
+)
+
+// headerTextLimit is how much of the statement text is sent for header extraction;
+// the account and period figures are printed on the first page
+const headerTextLimit = 8000
+
+// ExtractStatementHeader asks Claude for the account and statement figures printed
+// in the statement header: account, billing period, due date, balances, minimum payment
+// and the period's debit and credit totals
+func (a *Analyzer) ExtractStatementHeader(ctx context.Context, text string, source string) (*models.Statement, error) {
+	if len(text) > headerTextLimit {
+		text = text[:headerTextLimit]
+	}
+
+	request := llm.Request{
+		Model:       a.model,
+		MaxTokens:   a.maxTokens,
+		Temperature: 0.1,
+		Messages: []llm.Message{{
+			Role:    "user",
+			Content: a.buildStatementHeaderPrompt(text, source),
+		}},
+		Tools:      []llm.Tool{statementHeaderTool},
+		ToolChoice: statementHeaderTool.Name,
+	}
+
+	var stmt *models.Statement
+	var parseErr error
+	response, err := a.complete(ctx, request, usage.PhaseExtraction, []string{source}, func(response *llm.Response) error {
+		stmt, parseErr = parseStatementHeaderResponse(response, source)
+		return parseErr
+	})
+	if err != nil && err == parseErr {
+		return nil, parseErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the model: %w", err)
+	}
+	if response.Truncated() {
+		return parseStatementHeaderResponse(response, source)
+	}
+	return stmt, nil
+}
+
+// buildStatementHeaderPrompt creates the prompt for statement header extraction
+func (a *Analyzer) buildStatementHeaderPrompt(text string, source string) string {
+	var sb strings.Builder
+
+	sb.WriteString("You are reading the header of a bank or credit card statement. Identify the account and the statement figures.\n\n")
+	sb.WriteString("Statement source: " + source + "\n\n")
+	sb.WriteString("Statement text (first page):\n")
+	sb.WriteString(text)
+	sb.WriteString("\n\n")
+
+	sb.WriteString("Record the header with the " + statementHeaderTool.Name + " tool.\n\n")
+
+	sb.WriteString("RULES:\n")
+	sb.WriteString("- Dates use YYYY-MM-DD; amounts use standard format (e.g., 125000.50)\n")
+	sb.WriteString("- For credit cards, closing_balance is the total amount owed and due_date the payment due date\n")
+	sb.WriteString("- total_debits and total_credits are the period totals printed in the statement summary, not sums you compute\n")
+	sb.WriteString(fmt.Sprintf("- If the statement does not state a currency, use %s\n", models.DefaultCurrency))
+	sb.WriteString("- Leave out dates and amounts that are not printed\n")
+
+	return sb.String()
+}
+
+// parseStatementHeaderResponse converts the record_statement_header tool input into a Statement
+func parseStatementHeaderResponse(response *llm.Response, source string) (*models.Statement, error) {
+	var h struct {
+		Institution    string      `json:"institution"`
+		AccountType    string      `json:"account_type"`
+		AccountNumber  string      `json:"account_number"`
+		AccountName    string      `json:"account_name"`
+		Currency       string      `json:"currency"`
+		PeriodStart    string      `json:"period_start"`
+		PeriodEnd      string      `json:"period_end"`
+		DueDate        string      `json:"due_date"`
+		OpeningBalance json.Number `json:"opening_balance"`
+		ClosingBalance json.Number `json:"closing_balance"`
+		MinimumPayment json.Number `json:"minimum_payment"`
+		TotalDebits    json.Number `json:"total_debits"`
+		TotalCredits   json.Number `json:"total_credits"`
+	}
+	if err := statementHeaderTool.Decode(response, &h); err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(h.Currency))
+	if len(currency) != 3 {
+		currency = models.DefaultCurrency
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(h.AccountType))
+	switch kind {
+	case models.AccountCreditCard, models.AccountSavings, models.AccountChecking, models.AccountLoan:
+	default:
+		kind = models.AccountChecking
+	}
+
+	stmt := &models.Statement{
+		Source: source,
+		Account: &models.Account{
+			Institution: strings.TrimSpace(h.Institution),
+			Kind:        kind,
+			Number:      strings.TrimSpace(h.AccountNumber),
+			Name:        strings.TrimSpace(h.AccountName),
+			Currency:    currency,
+		},
+	}
+
+	// Dates and amounts that cannot be parsed are left at zero
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", strings.TrimSpace(s))
+		return d
+	}
+	amount := func(n json.Number) models.Money {
+		if n != "" {
+			if m, err := parseAmountNumber(n, currency); err == nil {
+				return m
+			}
+		}
+		return models.Money{Currency: currency}
+	}
+	stmt.PeriodStart = date(h.PeriodStart)
+	stmt.PeriodEnd = date(h.PeriodEnd)
+	stmt.DueDate = date(h.DueDate)
+	stmt.OpeningBalance = amount(h.OpeningBalance)
+	stmt.ClosingBalance = amount(h.ClosingBalance)
+	stmt.MinimumPayment = amount(h.MinimumPayment)
+	stmt.TotalDebits = amount(h.TotalDebits).Abs()
+	stmt.TotalCredits = amount(h.TotalCredits).Abs()
+
+	return stmt, nil
+}
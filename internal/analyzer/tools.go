@@ -0,0 +1,86 @@
+package analyzer
+
+import "github.com/KerynSuoress/finance-manager/internal/llm"
+
+// Tools the model is forced to call, so extraction and categorization results
+// arrive as JSON that follows a schema instead of being scraped from free text
+
+// extractionTool records the transactions found in a statement chunk
+var extractionTool = llm.Tool{
+	Name:        "record_transactions",
+	Description: "Record every financial transaction listed in the statement text.",
+	InputSchema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"transactions"},
+		Properties: map[string]*llm.Schema{
+			"transactions": {
+				Type:        "array",
+				Description: "The transactions in the order they appear; empty when there are none.",
+				Items: &llm.Schema{
+					Type:     "object",
+					Required: []string{"date", "description", "amount", "type"},
+					Properties: map[string]*llm.Schema{
+						"date":              {Type: "string", Description: "Transaction date as YYYY-MM-DD."},
+						"description":       {Type: "string", Description: "Merchant name and transaction details."},
+						"amount":            {Type: "number", Description: "Amount in standard format (e.g. 125000.50): negative for expenses/debits, positive for income/credits."},
+						"type":              {Type: "string", Enum: []string{"debit", "credit"}},
+						"currency":          {Type: "string", Description: "ISO 4217 code of the amount as billed, e.g. COP or USD."},
+						"original_amount":   {Type: "number", Description: "For international charges, the amount in the currency it was made in."},
+						"original_currency": {Type: "string", Description: "ISO 4217 code of original_amount."},
+						"balance":           {Type: "number", Description: "Running balance printed on the row after the transaction, if the statement has a balance column."},
+					},
+				},
+			},
+		},
+	},
+}
+
+// categorizationTool records the category of each transaction in a batch
+var categorizationTool = llm.Tool{
+	Name:        "record_categories",
+	Description: "Record the category, subcategory and confidence of each transaction.",
+	InputSchema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"categories"},
+		Properties: map[string]*llm.Schema{
+			"categories": {
+				Type: "array",
+				Items: &llm.Schema{
+					Type:     "object",
+					Required: []string{"index", "category", "subcategory", "confidence"},
+					Properties: map[string]*llm.Schema{
+						"index":       {Type: "integer", Description: "Index of the transaction in the list.", Minimum: llm.Float(0)},
+						"category":    {Type: "string", Description: "Main category."},
+						"subcategory": {Type: "string", Description: "Specific type within the main category."},
+						"confidence":  {Type: "number", Description: "Confidence from 0.0 to 1.0.", Minimum: llm.Float(0), Maximum: llm.Float(1)},
+					},
+				},
+			},
+		},
+	},
+}
+
+// statementHeaderTool records the account and statement figures of a statement header
+var statementHeaderTool = llm.Tool{
+	Name:        "record_statement_header",
+	Description: "Record the account and the figures printed in the statement header.",
+	InputSchema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"account_type"},
+		Properties: map[string]*llm.Schema{
+			"institution":     {Type: "string", Description: "Issuing bank, e.g. Bancolombia."},
+			"account_type":    {Type: "string", Enum: []string{"credit-card", "savings", "checking", "loan"}},
+			"account_number":  {Type: "string", Description: "Card or account number as printed; only the visible digits of masked numbers."},
+			"account_name":    {Type: "string", Description: "Product name, e.g. Mastercard Black."},
+			"currency":        {Type: "string", Description: "ISO 4217 code the account is held in."},
+			"period_start":    {Type: "string", Description: "Start of the billing period as YYYY-MM-DD."},
+			"period_end":      {Type: "string", Description: "End of the billing period as YYYY-MM-DD."},
+			"due_date":        {Type: "string", Description: "Payment due date as YYYY-MM-DD."},
+			"opening_balance": {Type: "number", Description: "Balance at the start of the period."},
+			"closing_balance": {Type: "number", Description: "Balance at the end of the period; for credit cards the total amount owed."},
+			"minimum_payment": {Type: "number", Description: "Minimum payment due."},
+			"total_debits":    {Type: "number", Description: "Total of the period's charges, withdrawals and debits as printed in the statement summary (a positive number)."},
+			"total_credits":   {Type: "number", Description: "Total of the period's payments, deposits and credits as printed in the statement summary (a positive number)."},
+		},
+	},
+}
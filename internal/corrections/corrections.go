@@ -0,0 +1,123 @@
+// Package corrections reads categories the user fixed in an edited transactions
+// report and learns from them, so the same categorization mistake is not made twice.
+//
+// Learning:
+// - A merchant corrected to the same category repeatedly becomes a merchant-level rule
+// - Other corrections become few-shot examples in the categorization prompt
+package corrections
+
+import (
+	"sort"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/payee"
+)
+
+// SourcePrefix marks categories assigned by a learned merchant rule in Transaction.CategorizedBy
+const SourcePrefix = "learned:"
+
+// MaxExamples caps the corrections shown to the model, most recent first
+const MaxExamples = 20
+
+// MerchantKey returns the merchant the corrections of a transaction are learned under:
+// its canonical payee (see payee.Registry.Assign), so corrections of one merchant
+// apply to all its aliases, or its cleaned description when it has no payee yet.
+// Example: "DLO*UBER RIDES" and "UBER *TRIP HELP.UBER.COM" both give "Uber" when
+// the payee table lists them
+func MerchantKey(t *models.Transaction) string {
+	if t.Payee != "" {
+		return t.Payee
+	}
+	return payee.Clean(t.Description, nil)
+}
+
+// Knowledge is what was learned from the stored corrections
+type Knowledge struct {
+	// rules maps a merchant to the correction its transactions follow
+	rules map[string]*models.Correction
+
+	// examples are the corrections not covered by a rule, most recent first
+	examples []*models.Correction
+}
+
+// Learn builds the knowledge from corrections, oldest first. A merchant becomes a
+// rule once its latest category was chosen in at least repeats corrections.
+func Learn(corrections []*models.Correction, repeats int) *Knowledge {
+	if repeats < 1 {
+		repeats = 1
+	}
+	k := &Knowledge{rules: make(map[string]*models.Correction)}
+
+	byMerchant := make(map[string][]*models.Correction)
+	var merchants []string
+	for _, c := range corrections {
+		if c.Merchant == "" {
+			continue
+		}
+		if _, ok := byMerchant[c.Merchant]; !ok {
+			merchants = append(merchants, c.Merchant)
+		}
+		byMerchant[c.Merchant] = append(byMerchant[c.Merchant], c)
+	}
+
+	for _, merchant := range merchants {
+		history := byMerchant[merchant]
+		latest := history[len(history)-1]
+		agreeing := 0
+		for _, c := range history {
+			if c.Category == latest.Category && c.Subcategory == latest.Subcategory {
+				agreeing++
+			}
+		}
+		if agreeing >= repeats {
+			k.rules[merchant] = latest
+		} else {
+			k.examples = append(k.examples, latest)
+		}
+	}
+
+	sort.SliceStable(k.examples, func(i, j int) bool {
+		return k.examples[i].CreatedAt.After(k.examples[j].CreatedAt)
+	})
+	if len(k.examples) > MaxExamples {
+		k.examples = k.examples[:MaxExamples]
+	}
+	return k
+}
+
+// Rules returns the number of learned merchant rules
+func (k *Knowledge) Rules() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.rules)
+}
+
+// Examples returns the corrections to show the model, most recent first
+func (k *Knowledge) Examples() []*models.Correction {
+	if k == nil {
+		return nil
+	}
+	return k.examples
+}
+
+// Apply categorizes the transactions of merchants with a learned rule and returns the rest
+func (k *Knowledge) Apply(transactions []*models.Transaction) []*models.Transaction {
+	if k == nil || len(k.rules) == 0 {
+		return transactions
+	}
+	var unmatched []*models.Transaction
+	for _, t := range transactions {
+		merchant := MerchantKey(t)
+		c, ok := k.rules[merchant]
+		if !ok || c.Type != t.Type {
+			unmatched = append(unmatched, t)
+			continue
+		}
+		t.Category = c.Category
+		t.Subcategory = c.Subcategory
+		t.Confidence = 1.0
+		t.CategorizedBy = SourcePrefix + merchant
+	}
+	return unmatched
+}
@@ -0,0 +1,115 @@
+package corrections
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func TestMerchantKeyUsesPayee(t *testing.T) {
+	ride := &models.Transaction{Description: "DLO*UBER RIDES", Payee: "Uber"}
+	trip := &models.Transaction{Description: "UBER *TRIP HELP.UBER.COM", Payee: "Uber"}
+	if MerchantKey(ride) != "Uber" || MerchantKey(trip) != "Uber" {
+		t.Errorf("MerchantKey = %q and %q, want Uber", MerchantKey(ride), MerchantKey(trip))
+	}
+	if got := MerchantKey(&models.Transaction{Description: "RAPPI*COL 8842"}); got != "RAPPI" {
+		t.Errorf("MerchantKey without payee = %q, want RAPPI", got)
+	}
+}
+
+func TestLearnedRuleAppliesAcrossAliases(t *testing.T) {
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	var history []*models.Correction
+	for _, description := range []string{"DLO*UBER RIDES", "UBER *TRIP HELP.UBER.COM"} {
+		t := &models.Transaction{Description: description, Payee: "Uber", Type: models.Debit, Category: "Other"}
+		history = append(history, NewCorrection(t, "Transportation", "Ride Sharing", now))
+	}
+	k := Learn(history, 2)
+
+	alias := &models.Transaction{Description: "UBER   BV", Payee: "Uber", Type: models.Debit}
+	if rest := k.Apply([]*models.Transaction{alias}); len(rest) != 0 {
+		t.Fatal("learned rule did not apply to another alias of the payee")
+	}
+	if alias.Category != "Transportation" || alias.CategorizedBy != SourcePrefix+"Uber" {
+		t.Errorf("categorized %q by %q", alias.Category, alias.CategorizedBy)
+	}
+}
+
+// correction returns a correction of merchant to category made on day of July 2025
+func correction(merchant, category string, day int) *models.Correction {
+	return &models.Correction{
+		Merchant:  merchant,
+		Type:      models.Debit,
+		Category:  category,
+		CreatedAt: time.Date(2025, 7, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestLearn(t *testing.T) {
+	k := Learn([]*models.Correction{
+		correction("Uber", "Transportation", 1),
+		correction("Rappi", "Shopping", 2),
+		correction("Uber", "Transportation", 3),
+		// Changing its mind restarts the count: only the latest category agrees
+		correction("Rappi", "Food & Dining", 4),
+		correction("Farmatodo", "Health", 5),
+		correction("", "Other", 6),
+	}, 2)
+
+	if k.Rules() != 1 {
+		t.Errorf("Rules() = %d, want 1", k.Rules())
+	}
+	examples := k.Examples()
+	if len(examples) != 2 {
+		t.Fatalf("examples = %d, want 2", len(examples))
+	}
+	// Most recent first, and the latest correction of each merchant
+	if examples[0].Merchant != "Farmatodo" || examples[1].Merchant != "Rappi" || examples[1].Category != "Food & Dining" {
+		t.Errorf("examples = %s/%s, %s/%s, want Farmatodo/Health, Rappi/Food & Dining",
+			examples[0].Merchant, examples[0].Category, examples[1].Merchant, examples[1].Category)
+	}
+
+	uber := &models.Transaction{Description: "UBER *TRIP", Payee: "Uber", Type: models.Debit}
+	refund := &models.Transaction{Description: "UBER *TRIP", Payee: "Uber", Type: models.Credit}
+	rappi := &models.Transaction{Description: "RAPPI*COL", Payee: "Rappi", Type: models.Debit}
+	rest := k.Apply([]*models.Transaction{uber, refund, rappi})
+	// A rule only applies to transactions of the type that was corrected
+	if len(rest) != 2 || rest[0] != refund || rest[1] != rappi {
+		t.Errorf("unmatched = %d transactions, want the refund and Rappi", len(rest))
+	}
+	if uber.Category != "Transportation" || uber.Confidence != 1.0 {
+		t.Errorf("Uber categorized %q with confidence %v", uber.Category, uber.Confidence)
+	}
+}
+
+func TestLearnSingleRepeat(t *testing.T) {
+	// Fewer than one repeat is treated as one: every merchant becomes a rule
+	k := Learn([]*models.Correction{correction("Uber", "Transportation", 1)}, 0)
+	if k.Rules() != 1 || len(k.Examples()) != 0 {
+		t.Errorf("Rules() = %d, examples = %d, want 1 and 0", k.Rules(), len(k.Examples()))
+	}
+}
+
+func TestLearnKeepsMaxExamples(t *testing.T) {
+	var history []*models.Correction
+	for day := 1; day <= MaxExamples+5; day++ {
+		history = append(history, correction(fmt.Sprintf("merchant %d", day), "Other", day))
+	}
+	examples := Learn(history, 2).Examples()
+	if len(examples) != MaxExamples {
+		t.Fatalf("examples = %d, want %d", len(examples), MaxExamples)
+	}
+	if want := fmt.Sprintf("merchant %d", MaxExamples+5); examples[0].Merchant != want {
+		t.Errorf("first example = %s, want %s", examples[0].Merchant, want)
+	}
+}
+
+func TestNilKnowledge(t *testing.T) {
+	var k *Knowledge
+	transactions := []*models.Transaction{{Description: "UBER"}}
+	if k.Rules() != 0 || k.Examples() != nil || len(k.Apply(transactions)) != 1 {
+		t.Error("nil knowledge learned something")
+	}
+}
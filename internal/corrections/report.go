@@ -0,0 +1,168 @@
+package corrections
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Row is one transaction of an edited transactions report
+type Row struct {
+	// Line is the line number in the report, for messages
+	Line int
+
+	Date        time.Time
+	Description string
+	Amount      models.Money
+	Category    string
+	Subcategory string
+	Source      string
+}
+
+// Change is a stored transaction whose category was edited in the report
+type Change struct {
+	Transaction *models.Transaction
+	Row         Row
+}
+
+// reportColumns are the report columns a correction needs; Subcategory and Currency are optional
+var reportColumns = []string{"Date", "Description", "Amount", "Category", "Source"}
+
+// ReadReport reads a transactions CSV report as written by the analyzer and edited by the user.
+// Columns are found by their header, so they may be reordered or removed if not needed.
+func ReadReport(path string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report %s: %v", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header of %s: %v", path, err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, name := range reportColumns {
+		if _, ok := columns[strings.ToLower(name)]; !ok {
+			return nil, fmt.Errorf("report %s has no %s column", path, name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		date, err := time.Parse("2006-01-02", field(record, "Date"))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid date %q", path, line, field(record, "Date"))
+		}
+		currency := field(record, "Currency")
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		amount, err := models.ParseMoney(field(record, "Amount"), currency)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+		rows = append(rows, Row{
+			Line:        line,
+			Date:        date,
+			Description: field(record, "Description"),
+			Amount:      amount,
+			Category:    field(record, "Category"),
+			Subcategory: field(record, "Subcategory"),
+			Source:      field(record, "Source"),
+		})
+	}
+	return rows, nil
+}
+
+// Diff matches the report rows of one statement file to its stored transactions by
+// date, description, amount and occurrence among identical rows. It returns the rows
+// whose category or subcategory was edited, and how many rows matched no stored transaction.
+// Rows with a blank category are not treated as corrections.
+func Diff(rows []Row, stored []*models.Transaction) ([]Change, int) {
+	byKey := make(map[string][]*models.Transaction)
+	for _, t := range stored {
+		key := rowKey(t.Date, t.Description, t.Amount)
+		byKey[key] = append(byKey[key], t)
+	}
+
+	var changes []Change
+	unmatched := 0
+	for _, row := range rows {
+		key := rowKey(row.Date, row.Description, row.Amount)
+		candidates := byKey[key]
+		if len(candidates) == 0 {
+			unmatched++
+			continue
+		}
+		t := candidates[0]
+		byKey[key] = candidates[1:]
+
+		if row.Category == "" {
+			continue
+		}
+		if row.Category != t.Category || row.Subcategory != t.Subcategory {
+			changes = append(changes, Change{Transaction: t, Row: row})
+		}
+	}
+	return changes, unmatched
+}
+
+// Correction returns the correction made by the change
+func (c Change) Correction(now time.Time) *models.Correction {
+	return NewCorrection(c.Transaction, c.Row.Category, c.Row.Subcategory, now)
+}
+
+// NewCorrection records that the user set the category of t, before it is changed.
+// A category confirmed unchanged is recorded too and counts toward a learned rule.
+func NewCorrection(t *models.Transaction, category, subcategory string, now time.Time) *models.Correction {
+	return &models.Correction{
+		Merchant:        MerchantKey(t),
+		Description:     t.Description,
+		Amount:          t.Amount,
+		Type:            t.Type,
+		Date:            t.Date,
+		Source:          t.Source,
+		FromCategory:    t.Category,
+		FromSubcategory: t.Subcategory,
+		Category:        category,
+		Subcategory:     subcategory,
+		CreatedAt:       now,
+	}
+}
+
+func rowKey(date time.Time, description string, amount models.Money) string {
+	currency := amount.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	return fmt.Sprintf("%s|%s|%d|%s", date.Format("2006-01-02"), strings.TrimSpace(description), amount.Minor, currency)
+}
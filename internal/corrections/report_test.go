@@ -0,0 +1,115 @@
+package corrections
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// writeReport writes a report CSV to a temporary directory and returns its path
+func writeReport(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "transactions.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadReport(t *testing.T) {
+	// Reordered columns, a byte order mark, an extra column and a Currency column
+	path := writeReport(t, "\ufeffSource,Amount,Notes,Date,Category,Description,Subcategory,Currency\n"+
+		"statement.pdf,-45000.00,lunch,2025-06-03,Food & Dining,RAPPI*COL 8842,Delivery,\n"+
+		"statement.pdf,-12.99,,2025-06-05,Entertainment,NETFLIX.COM,,USD\n")
+
+	rows, err := ReadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+
+	want := Row{
+		Line:        2,
+		Date:        time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC),
+		Description: "RAPPI*COL 8842",
+		Amount:      models.NewMoney(-4500000, models.DefaultCurrency),
+		Category:    "Food & Dining",
+		Subcategory: "Delivery",
+		Source:      "statement.pdf",
+	}
+	if rows[0] != want {
+		t.Errorf("row = %+v, want %+v", rows[0], want)
+	}
+	if got := rows[1].Amount; got != models.NewMoney(-1299, "USD") {
+		t.Errorf("amount = %v, want USD -12.99", got)
+	}
+}
+
+func TestReadReportErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"missing column", "Date,Description,Amount,Source\n", "no Category column"},
+		{"invalid date", "Date,Description,Amount,Category,Source\n03/06/2025,RAPPI,-45000,Food,a.pdf\n", "line 2: invalid date"},
+		{"invalid amount", "Date,Description,Amount,Category,Source\n2025-06-03,RAPPI,lots,Food,a.pdf\n", "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadReport(writeReport(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadReport() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	date := time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)
+	cop := func(minor int64) models.Money { return models.NewMoney(minor, "COP") }
+
+	coffee1 := &models.Transaction{Date: date, Description: "JUAN VALDEZ", Amount: cop(-1200000), Category: "Food & Dining", Subcategory: "Coffee"}
+	coffee2 := &models.Transaction{Date: date, Description: "JUAN VALDEZ", Amount: cop(-1200000), Category: "Food & Dining", Subcategory: "Coffee"}
+	rappi := &models.Transaction{Date: date, Description: "RAPPI*COL 8842", Amount: cop(-4500000), Category: "Shopping"}
+	uber := &models.Transaction{Date: date, Description: "UBER *TRIP", Amount: cop(-1800000), Category: "Transportation"}
+	stored := []*models.Transaction{coffee1, coffee2, rappi, uber}
+
+	rows := []Row{
+		// Identical rows match the stored transactions in order: only the second was edited
+		{Date: date, Description: "JUAN VALDEZ", Amount: cop(-1200000), Category: "Food & Dining", Subcategory: "Coffee"},
+		{Date: date, Description: "JUAN VALDEZ", Amount: cop(-1200000), Category: "Business", Subcategory: "Meals"},
+		// Descriptions are compared trimmed; a missing currency is the default one
+		{Date: date, Description: " RAPPI*COL 8842 ", Amount: models.Money{Minor: -4500000}, Category: "Food & Dining"},
+		// A blank category is not a correction
+		{Date: date, Description: "UBER *TRIP", Amount: cop(-1800000)},
+		// Another amount, another date: no stored transaction
+		{Date: date, Description: "UBER *TRIP", Amount: cop(-1900000), Category: "Transportation"},
+		{Date: date.AddDate(0, 0, 1), Description: "UBER *TRIP", Amount: cop(-1800000), Category: "Transportation"},
+	}
+
+	changes, unmatched := Diff(rows, stored)
+	if unmatched != 2 {
+		t.Errorf("unmatched = %d, want 2", unmatched)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %d, want 2", len(changes))
+	}
+	if changes[0].Transaction != coffee2 || changes[0].Row.Category != "Business" {
+		t.Errorf("first change = %s, want the second JUAN VALDEZ", changes[0].Transaction.Description)
+	}
+	if changes[1].Transaction != rappi {
+		t.Errorf("second change = %s, want RAPPI", changes[1].Transaction.Description)
+	}
+
+	c := changes[0].Correction(date)
+	if c.FromCategory != "Food & Dining" || c.FromSubcategory != "Coffee" || c.Category != "Business" || c.Subcategory != "Meals" {
+		t.Errorf("correction = %s/%s -> %s/%s", c.FromCategory, c.FromSubcategory, c.Category, c.Subcategory)
+	}
+}
@@ -0,0 +1,229 @@
+// Package dedupe finds transactions listed in more than one statement file, e.g. when
+// statement periods overlap or a card's charges appear both in the card statement and
+// in the bank account statement.
+//
+// Matching:
+// - Only transactions of different files with the same amount and currency are compared
+// - Dates may be up to a window of days apart
+// - Descriptions are compared after payee cleaning, by bigram similarity
+//
+// Exact duplicates (same bank transaction ID, or same date and near-identical
+// description) are marked and merged into the transaction they duplicate. Likely
+// duplicates are only reported, for the user to confirm.
+package dedupe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/payee"
+)
+
+// Options tune the matching
+type Options struct {
+	// WindowDays is how many days apart the dates of likely duplicates may be
+	WindowDays int
+
+	// ExactSimilarity is the description similarity (0..1) at which transactions of
+	// the same date are exact duplicates
+	ExactSimilarity float64
+
+	// LikelySimilarity is the description similarity at which transactions within
+	// the window are reported as likely duplicates
+	LikelySimilarity float64
+}
+
+// DefaultOptions are used by the manager unless overridden with flags
+var DefaultOptions = Options{WindowDays: 3, ExactSimilarity: 0.9, LikelySimilarity: 0.5}
+
+// Match pairs a transaction with an earlier one it duplicates
+type Match struct {
+	// Kept is the transaction listed first; Duplicate is the later copy
+	Kept      *models.Transaction
+	Duplicate *models.Transaction
+
+	// Exact is true when Duplicate was marked and merged into Kept
+	Exact bool
+
+	// Similarity of the descriptions (0..1) and how many days apart the dates are
+	Similarity float64
+	Days       int
+}
+
+// Result lists the duplicates found
+type Result struct {
+	Exact  []Match
+	Likely []Match
+}
+
+// Find compares the transactions in order and pairs each with at most one earlier
+// transaction of another file. Exact duplicates get DuplicateOf set to the file of
+// the transaction they duplicate, which receives the details only the copy had.
+// Marks left by an earlier pass are cleared first.
+func Find(transactions []*models.Transaction, opts Options) Result {
+	for _, t := range transactions {
+		t.DuplicateOf = ""
+	}
+
+	var result Result
+	earlier := make(map[string][]*models.Transaction)
+	paired := make(map[*models.Transaction]bool)
+	cleaned := make(map[*models.Transaction]string)
+	clean := func(t *models.Transaction) string {
+		s, ok := cleaned[t]
+		if !ok {
+			s = payee.Clean(t.Description, nil)
+			cleaned[t] = s
+		}
+		return s
+	}
+
+	for _, t := range transactions {
+		key := amountKey(t)
+		var best *Match
+		for _, c := range earlier[key] {
+			if c.Source == t.Source || paired[c] {
+				continue
+			}
+			days := daysApart(c.Date, t.Date)
+			if days > opts.WindowDays {
+				continue
+			}
+			m := Match{Kept: c, Duplicate: t, Days: days, Similarity: similarity(c, t, clean)}
+			m.Exact = (c.ExternalID != "" && c.ExternalID == t.ExternalID) ||
+				(days == 0 && m.Similarity >= opts.ExactSimilarity)
+			if !m.Exact && m.Similarity < opts.LikelySimilarity {
+				continue
+			}
+			if best == nil || better(m, *best) {
+				best = &m
+			}
+		}
+
+		if best == nil {
+			earlier[key] = append(earlier[key], t)
+			continue
+		}
+		paired[best.Kept], paired[t] = true, true
+		if best.Exact {
+			merge(best.Kept, t)
+			t.DuplicateOf = best.Kept.Source
+			result.Exact = append(result.Exact, *best)
+		} else {
+			result.Likely = append(result.Likely, *best)
+			earlier[key] = append(earlier[key], t)
+		}
+	}
+	return result
+}
+
+// Unique returns the transactions not marked as duplicates
+func Unique(transactions []*models.Transaction) []*models.Transaction {
+	unique := make([]*models.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if t.DuplicateOf == "" {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// better prefers exact matches, then similar descriptions, then close dates
+func better(a, b Match) bool {
+	if a.Exact != b.Exact {
+		return a.Exact
+	}
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	return a.Days < b.Days
+}
+
+// merge copies onto kept the details only its duplicate has
+func merge(kept, duplicate *models.Transaction) {
+	if kept.Counterparty == "" {
+		kept.Counterparty = duplicate.Counterparty
+	}
+	if kept.RemittanceInfo == "" {
+		kept.RemittanceInfo = duplicate.RemittanceInfo
+	}
+	if kept.ValueDate.IsZero() {
+		kept.ValueDate = duplicate.ValueDate
+	}
+	if kept.OriginalAmount.Currency == "" {
+		kept.OriginalAmount = duplicate.OriginalAmount
+	}
+	if kept.Category == "" && duplicate.Category != "" {
+		kept.Category, kept.Subcategory, kept.CategoryID = duplicate.Category, duplicate.Subcategory, duplicate.CategoryID
+		kept.Confidence, kept.Tags, kept.CategorizedBy = duplicate.Confidence, duplicate.Tags, duplicate.CategorizedBy
+	}
+}
+
+func amountKey(t *models.Transaction) string {
+	currency := t.Amount.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	return fmt.Sprintf("%d|%s", t.Amount.Minor, currency)
+}
+
+func daysApart(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(b.Sub(a).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
+
+// samePayee is the similarity of different descriptions of the same payee, so an
+// identical description is still the better match
+const samePayee = 0.95
+
+// similarity compares two descriptions: 1 when identical, samePayee for the same
+// payee, otherwise the Dice coefficient of the character bigrams of the cleaned descriptions
+func similarity(a, b *models.Transaction, clean func(*models.Transaction) string) float64 {
+	switch {
+	case a.Description == b.Description:
+		return 1
+	case a.Payee != "" && a.Payee == b.Payee:
+		return samePayee
+	}
+	return dice(clean(a), clean(b))
+}
+
+func dice(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(ba))
+	for _, g := range ba {
+		counts[g]++
+	}
+	shared := 0
+	for _, g := range bb {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ba)+len(bb))
+}
+
+func bigrams(s string) []string {
+	r := []rune(s)
+	if len(r) < 2 {
+		return nil
+	}
+	grams := make([]string, 0, len(r)-1)
+	for i := 0; i+1 < len(r); i++ {
+		grams = append(grams, string(r[i:i+2]))
+	}
+	return grams
+}
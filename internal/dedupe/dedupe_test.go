@@ -0,0 +1,100 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func tx(source, date, description string, minor int64) *models.Transaction {
+	d, _ := time.Parse("2006-01-02", date)
+	return &models.Transaction{Date: d, Description: description, Amount: models.NewMoney(minor, "COP"), Source: source}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []*models.Transaction
+		exact        int
+		likely       int
+	}{
+		{
+			name: "overlapping periods list the same row",
+			transactions: []*models.Transaction{
+				tx("may.pdf", "2025-05-31", "EXITO COLINA", -125000),
+				tx("june.pdf", "2025-05-31", "EXITO COLINA", -125000),
+			},
+			exact: 1,
+		},
+		{
+			name: "same bank ID on different dates",
+			transactions: func() []*models.Transaction {
+				a, b := tx("a.ofx", "2025-06-01", "TRANSFER", -5000), tx("b.ofx", "2025-06-03", "TRANSFER OUT", -5000)
+				a.ExternalID, b.ExternalID = "FIT-1", "FIT-1"
+				return []*models.Transaction{a, b}
+			}(),
+			exact: 1,
+		},
+		{
+			name: "similar description a day apart is only likely",
+			transactions: []*models.Transaction{
+				tx("card.pdf", "2025-06-10", "RAPPI COLOMBIA SAS", -45000),
+				tx("savings.pdf", "2025-06-11", "RAPPI COLOMBIA", -45000),
+			},
+			likely: 1,
+		},
+		{
+			name: "rows of the same file are never duplicates",
+			transactions: []*models.Transaction{
+				tx("june.pdf", "2025-06-10", "UBER TRIP", -18400),
+				tx("june.pdf", "2025-06-10", "UBER TRIP", -18400),
+			},
+		},
+		{
+			name: "different amounts or dates outside the window",
+			transactions: []*models.Transaction{
+				tx("a.pdf", "2025-06-10", "NETFLIX", -38900),
+				tx("b.pdf", "2025-06-10", "NETFLIX", -39900),
+				tx("c.pdf", "2025-06-20", "NETFLIX", -38900),
+			},
+		},
+		{
+			name: "unrelated merchants with the same amount",
+			transactions: []*models.Transaction{
+				tx("a.pdf", "2025-06-10", "CINE COLOMBIA", -20000),
+				tx("b.pdf", "2025-06-10", "FARMATODO", -20000),
+			},
+		},
+	}
+	for _, tt := range tests {
+		result := Find(tt.transactions, DefaultOptions)
+		if len(result.Exact) != tt.exact || len(result.Likely) != tt.likely {
+			t.Errorf("%s: %d exact and %d likely duplicates, want %d and %d",
+				tt.name, len(result.Exact), len(result.Likely), tt.exact, tt.likely)
+		}
+		if got, want := len(Unique(tt.transactions)), len(tt.transactions)-tt.exact; got != want {
+			t.Errorf("%s: %d unique transactions, want %d", tt.name, got, want)
+		}
+	}
+}
+
+func TestFindMergesAndClearsMarks(t *testing.T) {
+	kept := tx("card.pdf", "2025-06-10", "AMAZON MKTPLACE", -150000)
+	dup := tx("bank.ofx", "2025-06-10", "AMAZON MKTPLACE", -150000)
+	dup.Counterparty, dup.Category, dup.CategoryID = "Amazon", "Shopping", "shopping"
+
+	Find([]*models.Transaction{kept, dup}, DefaultOptions)
+	if dup.DuplicateOf != "card.pdf" {
+		t.Errorf("DuplicateOf = %q, want card.pdf", dup.DuplicateOf)
+	}
+	if kept.Counterparty != "Amazon" || kept.CategoryID != "shopping" {
+		t.Errorf("kept transaction did not receive the duplicate's details: %+v", kept)
+	}
+
+	// Without the other file, the mark of an earlier pass is cleared
+	Find([]*models.Transaction{dup}, DefaultOptions)
+	if dup.DuplicateOf != "" {
+		t.Errorf("DuplicateOf = %q after a pass without a match, want empty", dup.DuplicateOf)
+	}
+}
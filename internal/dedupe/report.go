@@ -0,0 +1,45 @@
+package dedupe
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+// WriteReport writes the duplicates found to a CSV file: exact duplicates that were
+// dropped, for reference, and likely duplicates the user should check
+func WriteReport(path string, result Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create duplicates report %s: %v", path, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"Status", "Similarity", "DaysApart", "Amount", "Currency",
+		"Date", "Description", "Source", "DuplicateDate", "DuplicateDescription", "DuplicateSource"})
+	write := func(status string, matches []Match) {
+		for _, m := range matches {
+			w.Write([]string{
+				status,
+				fmt.Sprintf("%.2f", m.Similarity),
+				fmt.Sprintf("%d", m.Days),
+				m.Kept.Amount.Decimal(),
+				m.Kept.Amount.Currency,
+				m.Kept.Date.Format("2006-01-02"),
+				m.Kept.Description,
+				m.Kept.Source,
+				m.Duplicate.Date.Format("2006-01-02"),
+				m.Duplicate.Description,
+				m.Duplicate.Source,
+			})
+		}
+	}
+	write("likely", result.Likely)
+	write("dropped", result.Exact)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write duplicates report %s: %v", path, err)
+	}
+	return nil
+}
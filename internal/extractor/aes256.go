@@ -0,0 +1,750 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// The PDF library only implements the RC4 and AES-128 security handlers, so AES-256
+// files (standard security handler V5, revisions 5 and 6) are decrypted here first:
+// every object is read, its strings and streams are decrypted with the file key,
+// object streams are unpacked and the result is written out as an unencrypted PDF
+// with a plain cross-reference table, which the library then reads as usual.
+
+// Values of the PDF object syntax
+type (
+	pdfDict struct {
+		keys   []string
+		values map[string]interface{}
+	}
+	pdfArray  []interface{}
+	pdfString []byte
+	pdfName   string
+	pdfRef    struct{ num, gen int }
+
+	// pdfToken is a number, boolean, null or other keyword, kept as written
+	pdfToken string
+)
+
+func newDict() *pdfDict { return &pdfDict{values: make(map[string]interface{})} }
+
+// get returns the value of key, or nil
+func (d *pdfDict) get(key string) interface{} {
+	if d == nil {
+		return nil
+	}
+	return d.values[key]
+}
+
+// set adds or replaces key, keeping the order keys were first set in
+func (d *pdfDict) set(key string, v interface{}) {
+	if _, ok := d.values[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.values[key] = v
+}
+
+// pdfObject is an indirect object; stream is nil unless the object is a stream
+type pdfObject struct {
+	ref    pdfRef
+	value  interface{}
+	stream []byte
+}
+
+// pdfParser reads PDF objects from data
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool { return strings.IndexByte("()<>[]{}/%", c) >= 0 }
+
+// peek returns the byte n positions ahead, or 0 past the end
+func (p *pdfParser) peek(n int) byte {
+	if p.pos+n >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos+n]
+}
+
+// skipSpace skips whitespace and comments
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+// regular reads a run of regular characters: a number, keyword or name
+func (p *pdfParser) regular() string {
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// value reads the value at the current position
+func (p *pdfParser) value(depth int) (interface{}, error) {
+	if depth > 100 {
+		return nil, fmt.Errorf("objects nested too deeply at offset %d", p.pos)
+	}
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	switch c := p.data[p.pos]; {
+	case c == '/':
+		p.pos++
+		return pdfName(p.regular()), nil
+	case c == '(':
+		return p.literalString()
+	case c == '<' && p.peek(1) == '<':
+		return p.dict(depth)
+	case c == '<':
+		return p.hexString()
+	case c == '[':
+		p.pos++
+		a := pdfArray{}
+		for {
+			p.skipSpace()
+			if p.pos >= len(p.data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if p.data[p.pos] == ']' {
+				p.pos++
+				return a, nil
+			}
+			v, err := p.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case isPDFDelimiter(c):
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+	}
+
+	token := p.regular()
+	if num, err := strconv.Atoi(token); err == nil && num >= 0 {
+		// "num gen R" is a reference
+		save := p.pos
+		p.skipSpace()
+		if gen, err := strconv.Atoi(p.regular()); err == nil && gen >= 0 {
+			p.skipSpace()
+			if p.regular() == "R" {
+				return pdfRef{num, gen}, nil
+			}
+		}
+		p.pos = save
+	}
+	return pdfToken(token), nil
+}
+
+func (p *pdfParser) dict(depth int) (*pdfDict, error) {
+	p.pos += 2
+	d := newDict()
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if p.data[p.pos] == '>' && p.peek(1) == '>' {
+			p.pos += 2
+			return d, nil
+		}
+		key, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key at offset %d is not a name", p.pos)
+		}
+		v, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		d.set(string(name), v)
+	}
+}
+
+// literalString reads a (string), resolving escapes; other bytes are kept as they
+// are, since encrypted strings are binary
+func (p *pdfParser) literalString() (pdfString, error) {
+	p.pos++
+	s := pdfString{}
+	nesting := 0
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			if nesting == 0 {
+				return s, nil
+			}
+			nesting--
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// A backslash at the end of a line continues the string
+				if p.peek(0) == '\n' {
+					p.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				c = e
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && p.peek(0) >= '0' && p.peek(0) <= '7'; i++ {
+						v = v*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+func (p *pdfParser) hexString() (pdfString, error) {
+	p.pos++
+	var digits []byte
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		if c == '>' {
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			s := make(pdfString, len(digits)/2)
+			if _, err := hex.Decode(s, digits); err != nil {
+				return nil, fmt.Errorf("invalid hex string: %v", err)
+			}
+			return s, nil
+		}
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+// objectOrTrailer finds the next "num gen obj" header or trailer keyword
+var objectOrTrailer = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b|\btrailer\b`)
+
+// readObjects reads every indirect object of the file in order, and the entries
+// of its trailers and cross-reference streams, later ones winning
+func readObjects(data []byte) ([]*pdfObject, *pdfDict) {
+	var objects []*pdfObject
+	trailer := newDict()
+	merge := func(d *pdfDict) {
+		for _, key := range []string{"Root", "Info", "Encrypt"} {
+			if v := d.get(key); v != nil {
+				trailer.set(key, v)
+			}
+		}
+	}
+
+	p := &pdfParser{data: data}
+	for p.pos < len(data) {
+		m := objectOrTrailer.FindSubmatchIndex(data[p.pos:])
+		if m == nil {
+			break
+		}
+		start := p.pos
+		p.pos = start + m[1]
+		v, err := p.value(0)
+		if err != nil {
+			// Not an object after all; carry on after the header
+			continue
+		}
+		if m[2] < 0 {
+			if d, ok := v.(*pdfDict); ok {
+				merge(d)
+			}
+			continue
+		}
+
+		num, _ := strconv.Atoi(string(data[start+m[2] : start+m[3]]))
+		gen, _ := strconv.Atoi(string(data[start+m[4] : start+m[5]]))
+		o := &pdfObject{ref: pdfRef{num, gen}, value: v}
+		p.skipSpace()
+		if bytes.HasPrefix(data[p.pos:], []byte("stream")) {
+			d, _ := v.(*pdfDict)
+			if o.stream, err = p.streamData(d); err != nil {
+				break
+			}
+			if pdfNameOf(d.get("Type")) == "XRef" {
+				merge(d)
+			}
+		}
+		p.skipSpace()
+		if bytes.HasPrefix(data[p.pos:], []byte("endobj")) {
+			p.pos += len("endobj")
+		}
+		objects = append(objects, o)
+	}
+	return objects, trailer
+}
+
+// streamData reads the data following the stream keyword, trusting /Length only
+// when endstream follows it
+func (p *pdfParser) streamData(d *pdfDict) ([]byte, error) {
+	p.pos += len("stream")
+	if p.peek(0) == '\r' {
+		p.pos++
+	}
+	if p.peek(0) == '\n' {
+		p.pos++
+	}
+	start := p.pos
+
+	if length := p.streamLength(d.get("Length")); length >= 0 && start+length <= len(p.data) {
+		end := &pdfParser{data: p.data, pos: start + length}
+		end.skipSpace()
+		if bytes.HasPrefix(p.data[end.pos:], []byte("endstream")) {
+			p.pos = end.pos + len("endstream")
+			return p.data[start : start+length], nil
+		}
+	}
+
+	i := bytes.Index(p.data[start:], []byte("endstream"))
+	if i < 0 {
+		return nil, fmt.Errorf("stream at offset %d has no endstream", start)
+	}
+	p.pos = start + i + len("endstream")
+	// The end-of-line marker before endstream is not part of the data
+	stream := bytes.TrimSuffix(p.data[start:start+i], []byte("\n"))
+	return bytes.TrimSuffix(stream, []byte("\r")), nil
+}
+
+// streamLength returns a direct or indirect /Length, or -1
+func (p *pdfParser) streamLength(v interface{}) int {
+	if ref, ok := v.(pdfRef); ok {
+		m := regexp.MustCompile(fmt.Sprintf(`(?:^|\s)%d\s+%d\s+obj\s+(\d+)`, ref.num, ref.gen)).FindSubmatch(p.data)
+		if m == nil {
+			return -1
+		}
+		v = pdfToken(m[1])
+	}
+	return pdfInt(v, -1)
+}
+
+// pdfNameOf returns the name v holds, or ""
+func pdfNameOf(v interface{}) string {
+	name, _ := v.(pdfName)
+	return string(name)
+}
+
+// pdfInt returns the integer v holds, or def
+func pdfInt(v interface{}, def int) int {
+	token, ok := v.(pdfToken)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(string(token))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// aes256Handler decrypts the strings and streams of a V5 file
+type aes256Handler struct {
+	block           cipher.Block
+	strings         bool
+	streams         bool
+	encryptMetadata bool
+}
+
+// newAES256Handler finds the file key of the standard security handler enc with the
+// first of passwords that opens it: the empty password, then each password in turn.
+// tried is the position of that password in passwords, or 0 for the empty password.
+func newAES256Handler(enc *pdfDict, passwords []string) (h *aes256Handler, tried int, err error) {
+	if filter := pdfNameOf(enc.get("Filter")); filter != "Standard" {
+		return nil, 0, fmt.Errorf("security handler %q is not supported", filter)
+	}
+	revision := pdfInt(enc.get("R"), 0)
+	if revision != 5 && revision != 6 {
+		return nil, 0, fmt.Errorf("revision %d of the AES-256 security handler is not supported", revision)
+	}
+	o, _ := enc.get("O").(pdfString)
+	u, _ := enc.get("U").(pdfString)
+	oe, _ := enc.get("OE").(pdfString)
+	ue, _ := enc.get("UE").(pdfString)
+	if len(o) < 48 || len(u) < 48 || len(oe) < 32 || len(ue) < 32 {
+		return nil, 0, fmt.Errorf("the encryption dictionary is incomplete")
+	}
+
+	h = &aes256Handler{encryptMetadata: enc.get("EncryptMetadata") != pdfToken("false")}
+	filters, _ := enc.get("CF").(*pdfDict)
+	for _, f := range []struct {
+		entry string
+		use   *bool
+	}{{"StrF", &h.strings}, {"StmF", &h.streams}} {
+		// Without a crypt filter the data is not encrypted
+		name := pdfNameOf(enc.get(f.entry))
+		if name == "" || name == "Identity" {
+			continue
+		}
+		filter, _ := filters.get(name).(*pdfDict)
+		if method := pdfNameOf(filter.get("CFM")); method != "AESV3" {
+			return nil, 0, fmt.Errorf("crypt filter method %q is not supported", method)
+		}
+		*f.use = true
+	}
+
+	for i, password := range append([]string{""}, passwords...) {
+		key := aes256FileKey([]byte(password), o[:48], u[:48], oe[:32], ue[:32], revision)
+		if key == nil {
+			continue
+		}
+		h.block, err = aes.NewCipher(key)
+		if err != nil {
+			return nil, 0, err
+		}
+		return h, i, nil
+	}
+	return nil, 0, pdf.ErrInvalidPassword
+}
+
+// aes256FileKey checks password as the user password, then as the owner password,
+// and returns the file key it unlocks, or nil (ISO 32000-2, algorithms 2.A and 11-12)
+func aes256FileKey(password, o, u, oe, ue []byte, revision int) []byte {
+	if len(password) > 127 {
+		password = password[:127]
+	}
+	// The validation salt checks the password, the key salt derives the key that wraps the file key
+	var intermediate, wrapped []byte
+	switch {
+	case bytes.Equal(hashAES256(password, u[32:40], nil, revision), u[:32]):
+		intermediate, wrapped = hashAES256(password, u[40:48], nil, revision), ue
+	case bytes.Equal(hashAES256(password, o[32:40], u, revision), o[:32]):
+		intermediate, wrapped = hashAES256(password, o[40:48], u, revision), oe
+	default:
+		return nil
+	}
+	block, err := aes.NewCipher(intermediate)
+	if err != nil {
+		return nil
+	}
+	key := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	return key
+}
+
+// hashAES256 computes the password hash of revision 5 (SHA-256) or revision 6
+// (ISO 32000-2, algorithm 2.B); udata is the user key when checking the owner password
+func hashAES256(password, salt, udata []byte, revision int) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(udata)
+	k := h.Sum(nil)
+	if revision < 6 {
+		return k
+	}
+
+	// At least 64 rounds, then until the last byte of E is at most round-32
+	var e []byte
+	for round := 0; round < 64 || int(e[len(e)-1]) > round-32; round++ {
+		seq := append(append(append([]byte{}, password...), k...), udata...)
+		k1 := bytes.Repeat(seq, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		// The first 16 bytes of E as a big-endian number, modulo 3, pick the next hash
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		switch sum % 3 {
+		case 0:
+			s := sha256.Sum256(e)
+			k = s[:]
+		case 1:
+			s := sha512.Sum384(e)
+			k = s[:]
+		default:
+			s := sha512.Sum512(e)
+			k = s[:]
+		}
+	}
+	return k[:32]
+}
+
+// decrypt decrypts a 16-byte initialization vector followed by AES-256-CBC data
+// with PKCS#7 padding; data that is not a whole number of blocks is returned as is
+func (h *aes256Handler) decrypt(data []byte) []byte {
+	if len(data) < aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return data
+	}
+	plain := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(h.block, data[:aes.BlockSize]).CryptBlocks(plain, data[aes.BlockSize:])
+	if n := len(plain); n > 0 {
+		if pad := int(plain[n-1]); pad > 0 && pad <= aes.BlockSize && pad <= n {
+			plain = plain[:n-pad]
+		}
+	}
+	return plain
+}
+
+// decryptStrings decrypts the strings in v, in place where v is a container
+func (h *aes256Handler) decryptStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case pdfString:
+		return pdfString(h.decrypt(v))
+	case pdfArray:
+		for i := range v {
+			v[i] = h.decryptStrings(v[i])
+		}
+	case *pdfDict:
+		for _, key := range v.keys {
+			v.values[key] = h.decryptStrings(v.values[key])
+		}
+	}
+	return v
+}
+
+// unpackObjectStream returns the objects held by a decrypted object stream
+func unpackObjectStream(d *pdfDict, stream []byte) ([]*pdfObject, error) {
+	filter := d.get("Filter")
+	if a, ok := filter.(pdfArray); ok && len(a) == 1 {
+		filter = a[0]
+	}
+	switch pdfNameOf(filter) {
+	case "":
+	case "FlateDecode":
+		zr, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress object stream: %v", err)
+		}
+		if stream, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("failed to decompress object stream: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("object stream filter %v is not supported", filter)
+	}
+
+	n, first := pdfInt(d.get("N"), 0), pdfInt(d.get("First"), 0)
+	header := &pdfParser{data: stream}
+	objects := make([]*pdfObject, 0, n)
+	for i := 0; i < n; i++ {
+		header.skipSpace()
+		num, err := strconv.Atoi(header.regular())
+		if err != nil {
+			return nil, fmt.Errorf("invalid object stream header")
+		}
+		header.skipSpace()
+		offset, err := strconv.Atoi(header.regular())
+		if err != nil || first+offset >= len(stream) {
+			return nil, fmt.Errorf("invalid object stream header")
+		}
+		p := &pdfParser{data: stream, pos: first + offset}
+		v, err := p.value(0)
+		if err != nil {
+			return nil, fmt.Errorf("object %d of object stream: %v", num, err)
+		}
+		// Strings in an object stream were decrypted with the stream
+		objects = append(objects, &pdfObject{ref: pdfRef{num, 0}, value: v})
+	}
+	return objects, nil
+}
+
+// decryptAES256 returns an unencrypted copy of a PDF encrypted with the AES-256
+// standard security handler, and the position of the password that opened it
+// among passwords (0 for the empty password). It fails with pdf.ErrInvalidPassword
+// when no password opens the file.
+func decryptAES256(data []byte, passwords []string) ([]byte, int, error) {
+	objects, trailer := readObjects(data)
+	latest := make(map[int]*pdfObject)
+	for _, o := range objects {
+		latest[o.ref.num] = o
+	}
+
+	encRef, indirect := trailer.get("Encrypt").(pdfRef)
+	enc, _ := trailer.get("Encrypt").(*pdfDict)
+	if indirect {
+		if o := latest[encRef.num]; o != nil {
+			enc, _ = o.value.(*pdfDict)
+		}
+	}
+	if enc == nil {
+		return nil, 0, fmt.Errorf("encryption dictionary not found")
+	}
+	h, tried, err := newAES256Handler(enc, passwords)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Later definitions of an object, as in incremental updates, replace earlier ones
+	output := make(map[int]*pdfObject)
+	for _, o := range objects {
+		if indirect && o.ref == encRef {
+			continue
+		}
+		d, _ := o.value.(*pdfDict)
+		kind := pdfNameOf(d.get("Type"))
+		if o.stream != nil && kind == "XRef" {
+			continue
+		}
+		if h.strings {
+			o.value = h.decryptStrings(o.value)
+		}
+		if o.stream != nil && h.streams && (h.encryptMetadata || kind != "Metadata") {
+			o.stream = h.decrypt(o.stream)
+		}
+		if o.stream != nil && kind == "ObjStm" {
+			unpacked, err := unpackObjectStream(d, o.stream)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, c := range unpacked {
+				output[c.ref.num] = c
+			}
+			continue
+		}
+		output[o.ref.num] = o
+	}
+	delete(output, 0)
+	if len(output) == 0 {
+		return nil, 0, fmt.Errorf("no objects found")
+	}
+	return writeUnencrypted(output, trailer), tried, nil
+}
+
+// writeUnencrypted writes objects as a PDF file with a cross-reference table
+func writeUnencrypted(objects map[int]*pdfObject, trailer *pdfDict) []byte {
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	size := nums[len(nums)-1] + 1
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, size)
+	for _, num := range nums {
+		o := objects[num]
+		offsets[num] = out.Len()
+		fmt.Fprintf(&out, "%d %d obj\n", num, o.ref.gen)
+		d, isDict := o.value.(*pdfDict)
+		if o.stream != nil && isDict {
+			d.set("Length", pdfToken(strconv.Itoa(len(o.stream))))
+		}
+		writeValue(&out, o.value)
+		if o.stream != nil && isDict {
+			out.WriteString("\nstream\n")
+			out.Write(o.stream)
+			out.WriteString("\nendstream")
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n", size)
+	for num := 0; num < size; num++ {
+		if o, ok := objects[num]; ok {
+			fmt.Fprintf(&out, "%010d %05d n \n", offsets[num], o.ref.gen)
+		} else {
+			out.WriteString("0000000000 65535 f \n")
+		}
+	}
+	t := newDict()
+	t.set("Size", pdfToken(strconv.Itoa(size)))
+	for _, key := range []string{"Root", "Info"} {
+		if v := trailer.get(key); v != nil {
+			t.set(key, v)
+		}
+	}
+	out.WriteString("trailer\n")
+	writeValue(&out, t)
+	fmt.Fprintf(&out, "\nstartxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes()
+}
+
+// writeValue writes v in PDF syntax; strings are written in hex
+func writeValue(out *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case *pdfDict:
+		out.WriteString("<<")
+		for _, key := range v.keys {
+			out.WriteString(" /" + key + " ")
+			writeValue(out, v.values[key])
+		}
+		out.WriteString(" >>")
+	case pdfArray:
+		out.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			writeValue(out, item)
+		}
+		out.WriteString("]")
+	case pdfString:
+		out.WriteString("<" + hex.EncodeToString(v) + ">")
+	case pdfName:
+		out.WriteString("/" + string(v))
+	case pdfRef:
+		fmt.Fprintf(out, "%d %d R", v.num, v.gen)
+	case pdfToken:
+		out.WriteString(string(v))
+	default:
+		out.WriteString("null")
+	}
+}
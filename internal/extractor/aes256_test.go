@@ -0,0 +1,207 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// fixtureKey is the file key of the PDFs built by encryptedPDF
+var fixtureKey = []byte("0123456789abcdef0123456789abcdef")
+
+// encryptAESV3 encrypts a string or stream as the AES-256 handler stores it: an
+// initialization vector followed by AES-256-CBC data with PKCS#7 padding
+func encryptAESV3(data []byte) []byte {
+	block, _ := aes.NewCipher(fixtureKey)
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	padded := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	iv := []byte("fixed iv 16bytes")
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+	return append(iv, out...)
+}
+
+// wrapKey encrypts the file key with an intermediate key, as stored in /UE and /OE
+func wrapKey(intermediate []byte) []byte {
+	block, _ := aes.NewCipher(intermediate)
+	out := make([]byte, len(fixtureKey))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, fixtureKey)
+	return out
+}
+
+// literal writes s as a literal string, escaping the bytes that need it
+func literal(s []byte) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`)
+	return "(" + r.Replace(string(s)) + ")"
+}
+
+// encryptedPDF builds a one-page statement encrypted with the AES-256 standard
+// security handler. With objectStream, the catalog, page tree and page are stored
+// in a compressed object stream.
+func encryptedPDF(revision int, userPassword, ownerPassword string, method string, objectStream bool) []byte {
+	uvs, uks, ovs, oks := []byte("uservali"), []byte("userkeys"), []byte("ownvalid"), []byte("ownerkey")
+	u := append(append(hashAES256([]byte(userPassword), uvs, nil, revision), uvs...), uks...)
+	ue := wrapKey(hashAES256([]byte(userPassword), uks, nil, revision))
+	o := append(append(hashAES256([]byte(ownerPassword), ovs, u, revision), ovs...), oks...)
+	oe := wrapKey(hashAES256([]byte(ownerPassword), oks, u, revision))
+
+	content := encryptAESV3([]byte("BT /F1 12 Tf 72 720 Td (SALDO ANTERIOR 1.250.000) Tj ET"))
+	objects := map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		2: "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		3: "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		4: "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		// The length of the content stream is an indirect object
+		5: "<< /Length 9 0 R >>\nstream\n" + string(content) + "\nendstream",
+		6: "<< /Title " + literal(encryptAESV3([]byte("Extracto (junio)"))) + " /Producer <" + hex.EncodeToString(encryptAESV3([]byte("Banco"))) + "> >>",
+		7: fmt.Sprintf("<< /Filter /Standard /V 5 /R %d /Length 256 /CF << /StdCF << /AuthEvent /DocOpen /CFM /%s /Length 32 >> >> "+
+			"/StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /OE <%x> /UE <%x> /P -1028 /Perms <%x> >>",
+			revision, method, o, u, oe, ue, bytes.Repeat([]byte{0}, 16)),
+		9: fmt.Sprint(len(content)),
+	}
+	if objectStream {
+		var header, body strings.Builder
+		for _, num := range []int{1, 2, 3} {
+			fmt.Fprintf(&header, "%d %d ", num, body.Len())
+			body.WriteString(objects[num] + "\n")
+			delete(objects, num)
+		}
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write([]byte(header.String() + body.String()))
+		zw.Close()
+		stream := encryptAESV3(compressed.Bytes())
+		objects[8] = fmt.Sprintf("<< /Type /ObjStm /N 3 /First %d /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			header.Len(), len(stream), stream)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, 10)
+	for num := 1; num < 10; num++ {
+		if obj, ok := objects[num]; ok {
+			offsets[num] = out.Len()
+			fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", num, obj)
+		}
+	}
+	xref := out.Len()
+	out.WriteString("xref\n0 10\n0000000000 65535 f \n")
+	for num := 1; num < 10; num++ {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offsets[num])
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size 10 /Root 1 0 R /Info 6 0 R /Encrypt 7 0 R /ID [<00112233> <00112233>] >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes()
+}
+
+func TestHashAES256(t *testing.T) {
+	udata := make([]byte, 48)
+	for i := range udata {
+		udata[i] = byte(i)
+	}
+	// Expected values computed independently from ISO 32000-2, algorithm 2.B
+	tests := []struct {
+		password, salt, udata []byte
+		want                  string
+	}{
+		{[]byte("finanzas"), []byte{1, 2, 3, 4, 5, 6, 7, 8}, nil, "63cedc21c517b9232ba800dfab5571e70e7efbd187285d19514d1f5c50e0b441"},
+		{[]byte("owner"), []byte{9, 10, 11, 12, 13, 14, 15, 16}, udata, "4eb75f38767dc103b18e488e95b0b0580f96497d839ff3095e34ee8cb5a4f346"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(hashAES256(tt.password, tt.salt, tt.udata, 6)); got != tt.want {
+			t.Errorf("hashAES256(%q) = %s, want %s", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestDecryptAES256(t *testing.T) {
+	tests := []struct {
+		name         string
+		revision     int
+		objectStream bool
+		user         string
+		passwords    []string
+		tried        int
+	}{
+		{"R6 user password", 6, false, "finanzas", []string{"wrong", "finanzas"}, 2},
+		{"R6 owner password", 6, false, "finanzas", []string{"owner"}, 1},
+		{"R6 empty user password", 6, false, "", nil, 0},
+		{"R6 object stream", 6, true, "finanzas", []string{"finanzas"}, 1},
+		{"R5 user password", 5, false, "finanzas", []string{"finanzas"}, 1},
+		{"R5 object stream", 5, true, "", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encryptedPDF(tt.revision, tt.user, "owner", "AESV3", tt.objectStream)
+			plain, tried, err := decryptAES256(data, tt.passwords)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tried != tt.tried {
+				t.Errorf("opened with password #%d, want #%d", tried, tt.tried)
+			}
+			if bytes.Contains(plain, []byte("/Encrypt")) {
+				t.Error("the decrypted copy still has an /Encrypt entry")
+			}
+			for _, s := range []string{"Extracto (junio)", "Banco"} {
+				if !bytes.Contains(plain, []byte(hex.EncodeToString([]byte(s)))) {
+					t.Errorf("string %q was not decrypted", s)
+				}
+			}
+
+			reader, err := pdf.NewReader(bytes.NewReader(plain), int64(len(plain)))
+			if err != nil {
+				t.Fatalf("the decrypted copy does not open: %v", err)
+			}
+			if reader.NumPage() != 1 {
+				t.Fatalf("pages = %d, want 1", reader.NumPage())
+			}
+			if got := pageText(reader.Page(1)); got != "SALDO ANTERIOR 1.250.000" {
+				t.Errorf("page text = %q", got)
+			}
+		})
+	}
+}
+
+func TestDecryptAES256WrongPassword(t *testing.T) {
+	data := encryptedPDF(6, "finanzas", "owner", "AESV3", false)
+	if _, _, err := decryptAES256(data, []string{"finanza", "Finanzas"}); err != pdf.ErrInvalidPassword {
+		t.Errorf("decryptAES256() error = %v, want %v", err, pdf.ErrInvalidPassword)
+	}
+}
+
+func TestExtractTextAES256(t *testing.T) {
+	path := writePDF(t, string(encryptedPDF(6, "finanzas", "owner", "AESV3", true)))
+	for _, key := range []string{"PASS_CC", "PASS_BIRTH", "PASS_BIRTH2", "PASS_SURNAME"} {
+		t.Setenv(key, "")
+	}
+
+	if _, err := NewNative().ExtractText(context.Background(), path); err == nil || !strings.Contains(err.Error(), "configured passwords") {
+		t.Errorf("ExtractText() without the password = %v, want a wrong password error", err)
+	}
+
+	t.Setenv("PASS_BIRTH", "finanzas")
+	text, err := NewNative().ExtractText(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "--- Page 1 ---\nSALDO ANTERIOR 1.250.000\n"; text != want {
+		t.Errorf("ExtractText() = %q, want %q", text, want)
+	}
+}
+
+func TestExtractTextUnsupportedCryptFilter(t *testing.T) {
+	path := writePDF(t, string(encryptedPDF(6, "", "owner", "AESV2", false)))
+	_, err := NewNative().ExtractText(context.Background(), path)
+	if !errors.Is(err, ErrUnsupportedEncryption) || !strings.Contains(err.Error(), "-extractor python") {
+		t.Errorf("ExtractText() = %v, want an unsupported encryption error pointing at the python extractor", err)
+	}
+}
@@ -1,51 +1,83 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
-// PDFExtractor handles PDF text extraction using Python
-type PDFExtractor struct {
-	pythonScript string
+// Extractor converts a PDF statement into plain text.
+// Implementations write one "--- Page N ---" marker before the text of each page,
+// which the analyzer relies on to split large statements on page boundaries.
+// Cancelling ctx stops the extraction and kills any helper process.
+type Extractor interface {
+	ExtractToFile(ctx context.Context, pdfPath, outputPath string) error
 }
 
-// New creates a new PDFExtractor instance
-func New() *PDFExtractor {
-	return &PDFExtractor{
-		pythonScript: "scripts/extract_text.py",
+// Backend names accepted by New
+const (
+	BackendNative = "native"
+	BackendPython = "python"
+	BackendAuto   = "auto"
+)
+
+// New creates the extractor for the given backend.
+// "native" uses the pure-Go implementation, "python" the legacy PyPDF2 script,
+// and "auto" tries the native extractor first and falls back to Python.
+func New(backend string) (Extractor, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendNative:
+		return NewNative(), nil
+	case BackendPython:
+		return NewPython(), nil
+	case BackendAuto:
+		return &FallbackExtractor{primary: NewNative(), fallback: NewPython()}, nil
+	default:
+		return nil, fmt.Errorf("unknown extractor backend %q (expected native, python or auto)", backend)
+	}
+}
+
+// FallbackExtractor tries a primary extractor and, if it fails, a fallback one
+type FallbackExtractor struct {
+	primary  Extractor
+	fallback Extractor
+}
+
+// ExtractToFile extracts text with the primary extractor, falling back on error
+func (e *FallbackExtractor) ExtractToFile(ctx context.Context, pdfPath, outputPath string) error {
+	err := e.primary.ExtractToFile(ctx, pdfPath, outputPath)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	fmt.Printf("Native extraction failed (%v). Falling back to Python...\n", err)
+	if ferr := e.fallback.ExtractToFile(ctx, pdfPath, outputPath); ferr != nil {
+		return fmt.Errorf("all extractors failed: %v; fallback: %w", err, ferr)
+	}
+	return nil
+}
+
+// passwordsFromEnv returns the candidate passwords for encrypted PDFs, in the order they are tried
+func passwordsFromEnv() []string {
+	var passwords []string
+	for _, key := range []string{"PASS_CC", "PASS_BIRTH", "PASS_BIRTH2", "PASS_SURNAME"} {
+		if v := os.Getenv(key); v != "" {
+			passwords = append(passwords, v)
+		}
 	}
+	return passwords
 }
 
-// ExtractToFile extracts text from PDF and saves to a text file
-func (e *PDFExtractor) ExtractToFile(pdfPath, outputPath string) error {
-	// Validate input file exists
+// prepareOutput validates the input file and ensures the output directory exists
+func prepareOutput(pdfPath, outputPath string) error {
 	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
 		return fmt.Errorf("PDF file does not exist: %s", pdfPath)
 	}
 
-	// Ensure output directory exists
 	outputDir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
-
-	// Execute Python script
-	cmd := exec.Command("python", e.pythonScript, pdfPath, outputPath)
-
-	// Capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("extraction failed: %w\nOutput: %s", err, string(output))
-	}
-
-	// Verify output file was created
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		return fmt.Errorf("output file was not created: %s", outputPath)
-	}
-
-	fmt.Printf("Extraction successful: %s\n", string(output))
 	return nil
 }
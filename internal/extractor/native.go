@@ -0,0 +1,220 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// NativeExtractor extracts PDF text in pure Go, without external tools.
+// Encrypted files are opened with the standard security handler (RC4, AES-128
+// and AES-256), trying the empty password and then each password configured in
+// the environment in turn.
+type NativeExtractor struct{}
+
+// ErrUnsupportedEncryption is returned for AES-256 PDFs using features the native
+// extractor does not implement, such as other security handlers or crypt filters
+var ErrUnsupportedEncryption = errors.New("unsupported PDF encryption")
+
+// NewNative creates a new NativeExtractor instance
+func NewNative() *NativeExtractor {
+	return &NativeExtractor{}
+}
+
+// ExtractToFile extracts text from PDF and saves to a text file
+func (e *NativeExtractor) ExtractToFile(ctx context.Context, pdfPath, outputPath string) error {
+	if err := prepareOutput(pdfPath, outputPath); err != nil {
+		return err
+	}
+
+	text, err := e.ExtractText(ctx, pdfPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write extracted text: %w", err)
+	}
+
+	fmt.Printf("Extraction successful: %s\n", outputPath)
+	return nil
+}
+
+// ExtractText returns the text of every page, each preceded by a "--- Page N ---" marker.
+// Cancelling ctx stops it between pages.
+func (e *NativeExtractor) ExtractText(ctx context.Context, pdfPath string) (text string, err error) {
+	// The PDF parser panics on some malformed inputs; surface those as errors
+	defer func() {
+		if r := recover(); r != nil {
+			text = ""
+			err = fmt.Errorf("failed to parse PDF %s: %v", pdfPath, r)
+		}
+	}()
+
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	passwords := passwordsFromEnv()
+	tried := 0
+	nextPassword := func() string {
+		if tried >= len(passwords) {
+			return ""
+		}
+		tried++
+		return passwords[tried-1]
+	}
+
+	var reader *pdf.Reader
+	if v, r := encryptionVersion(data); v >= 5 || r >= 5 {
+		// The PDF library cannot open AES-256 files; it reads a decrypted copy instead
+		var plain []byte
+		plain, tried, err = decryptAES256(data, passwords)
+		if err != nil && err != pdf.ErrInvalidPassword {
+			return "", fmt.Errorf("%w: %s is encrypted with AES-256 (V=%d, R=%d): %v; use -extractor python or -extractor auto",
+				ErrUnsupportedEncryption, filepath.Base(pdfPath), v, r, err)
+		}
+		if err == nil {
+			reader, err = pdf.NewReader(bytes.NewReader(plain), int64(len(plain)))
+		}
+	} else {
+		reader, err = pdf.NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), nextPassword)
+	}
+	if err != nil {
+		if err == pdf.ErrInvalidPassword {
+			return "", fmt.Errorf("failed to decrypt PDF with any of the %d configured passwords", len(passwords))
+		}
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if tried > 0 {
+		fmt.Printf("Decrypted with password #%d\n", tried)
+	}
+
+	pages := make([]string, 0, reader.NumPage())
+	for i := 1; i <= reader.NumPage(); i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		page := reader.Page(i)
+		pages = append(pages, fmt.Sprintf("--- Page %d ---\n%s\n", i, pageText(page)))
+	}
+	return strings.Join(pages, "\n"), nil
+}
+
+// Patterns locating the /V and /R entries of the encryption dictionary, which the
+// trailer either references or holds inline
+var (
+	encryptKey     = regexp.MustCompile(`/Encrypt\s*(?:<<|\d)`)
+	encryptRef     = regexp.MustCompile(`/Encrypt\s*(\d+)\s+(\d+)\s+R\b`)
+	encryptVersion = regexp.MustCompile(`/V\s+(\d+)`)
+	encryptRev     = regexp.MustCompile(`/R\s+(\d+)`)
+)
+
+// encryptionVersion returns the /V and /R entries of the encryption dictionary,
+// or zeros for unencrypted files
+func encryptionVersion(data []byte) (v, r int) {
+	keys := encryptKey.FindAllIndex(data, -1)
+	if keys == nil {
+		return 0, 0
+	}
+
+	// The last trailer wins, as in incremental updates
+	dict := data[keys[len(keys)-1][0]:]
+	if m := encryptRef.FindSubmatch(dict); m != nil && bytes.HasPrefix(dict, m[0]) {
+		start := regexp.MustCompile(`(?:^|\s)` + string(m[1]) + `\s+` + string(m[2]) + `\s+obj\b`).FindIndex(data)
+		if start == nil {
+			return 0, 0
+		}
+		dict = data[start[1]:]
+		if end := bytes.Index(dict, []byte("endobj")); end >= 0 {
+			dict = dict[:end]
+		}
+	} else if end := bytes.Index(dict, []byte("startxref")); end >= 0 {
+		dict = dict[:end]
+	}
+
+	if m := encryptVersion.FindSubmatch(dict); m != nil {
+		v, _ = strconv.Atoi(string(m[1]))
+	}
+	if m := encryptRev.FindSubmatch(dict); m != nil {
+		r, _ = strconv.Atoi(string(m[1]))
+	}
+	return v, r
+}
+
+// pageText lays out the glyphs of a page as lines of text.
+// Glyphs are grouped into lines by baseline and ordered left to right; a space is
+// inserted wherever the horizontal gap between glyphs is wider than a fraction
+// of the font size, and a double space marks a wide, column-like gap.
+func pageText(page pdf.Page) string {
+	if page.V.IsNull() {
+		return ""
+	}
+	glyphs := page.Content().Text
+	if len(glyphs) == 0 {
+		return ""
+	}
+
+	type line struct {
+		y      float64
+		glyphs []pdf.Text
+	}
+	var lines []*line
+	for _, g := range glyphs {
+		tolerance := math.Max(g.FontSize*0.4, 1)
+		var target *line
+		for _, l := range lines {
+			if math.Abs(l.y-g.Y) <= tolerance {
+				target = l
+				break
+			}
+		}
+		if target == nil {
+			target = &line{y: g.Y}
+			lines = append(lines, target)
+		}
+		target.glyphs = append(target.glyphs, g)
+	}
+
+	// PDF coordinates grow upwards, so the top line has the largest Y
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].y > lines[j].y })
+
+	var sb strings.Builder
+	for li, l := range lines {
+		sort.SliceStable(l.glyphs, func(i, j int) bool { return l.glyphs[i].X < l.glyphs[j].X })
+
+		var lb strings.Builder
+		for i, g := range l.glyphs {
+			if i > 0 {
+				prev := l.glyphs[i-1]
+				gap := g.X - (prev.X + prev.W)
+				size := math.Max(prev.FontSize, 1)
+				separated := strings.HasSuffix(prev.S, " ") || strings.HasPrefix(g.S, " ")
+				switch {
+				case gap > size*1.5:
+					lb.WriteString("  ")
+				case gap > size*0.15 && !separated:
+					lb.WriteString(" ")
+				}
+			}
+			lb.WriteString(g.S)
+		}
+
+		if li > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.TrimRight(lb.String(), " "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,37 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePDF(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "statement.pdf")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEncryptionVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  string
+		v, r int
+	}{
+		{"not encrypted", "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Size 2 >>\nstartxref\n0\n%%EOF", 0, 0},
+		{"AES-128", "%PDF-1.6\n5 0 obj\n<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /AESV2 >> >> >>\nendobj\n" +
+			"trailer\n<< /Root 1 0 R /Encrypt 5 0 R >>\nstartxref\n0\n%%EOF", 4, 4},
+		{"AES-256 referenced", "%PDF-1.7\n15 0 obj\n<< /Filter /Standard /V 5 /R 6 /Length 256 /EncryptMetadata true >>\nendobj\n" +
+			"trailer\n<< /Root 1 0 R /Encrypt 15 0 R >>\nstartxref\n0\n%%EOF", 5, 6},
+		{"AES-256 inline", "%PDF-1.7\ntrailer\n<< /Root 1 0 R /Encrypt << /Filter /Standard /V 5 /R 5 /Length 256 >> >>\nstartxref\n0\n%%EOF", 5, 5},
+	}
+	for _, tt := range tests {
+		v, r := encryptionVersion([]byte(tt.pdf))
+		if v != tt.v || r != tt.r {
+			t.Errorf("%s: encryptionVersion() = V%d R%d, want V%d R%d", tt.name, v, r, tt.v, tt.r)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// PythonExtractor handles PDF text extraction using the PyPDF2 helper script
+type PythonExtractor struct {
+	python       string
+	pythonScript string
+}
+
+// NewPython creates a new PythonExtractor instance.
+// The interpreter and script can be overridden with the PYTHON and
+// PDF_EXTRACT_SCRIPT environment variables.
+func NewPython() *PythonExtractor {
+	python := os.Getenv("PYTHON")
+	if python == "" {
+		python = "python"
+	}
+	return &PythonExtractor{
+		python:       python,
+		pythonScript: resolveScript(),
+	}
+}
+
+// resolveScript locates extract_text.py relative to the working directory or,
+// failing that, relative to the running executable.
+func resolveScript() string {
+	if v := os.Getenv("PDF_EXTRACT_SCRIPT"); v != "" {
+		return v
+	}
+
+	script := filepath.Join("scripts", "extract_text.py")
+	if _, err := os.Stat(script); err == nil {
+		return script
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), script)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return script
+}
+
+// ExtractToFile extracts text from PDF and saves to a text file
+func (e *PythonExtractor) ExtractToFile(ctx context.Context, pdfPath, outputPath string) error {
+	if err := prepareOutput(pdfPath, outputPath); err != nil {
+		return err
+	}
+
+	// Execute Python script; it is killed if ctx is cancelled
+	cmd := exec.CommandContext(ctx, e.python, e.pythonScript, pdfPath, outputPath)
+
+	// Capture both stdout and stderr
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w\nOutput: %s", err, string(output))
+	}
+
+	// Verify output file was created
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return fmt.Errorf("output file was not created: %s", outputPath)
+	}
+
+	fmt.Printf("Extraction successful: %s\n", string(output))
+	return nil
+}
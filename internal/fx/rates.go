@@ -0,0 +1,154 @@
+// Package fx converts amounts between currencies using a local, offline table
+// of exchange rates, so that reports can total multi-currency statements.
+package fx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// rate is the value of one unit of a currency in the base currency, from a given date on
+type rate struct {
+	date  time.Time
+	value *big.Rat
+}
+
+// Table holds exchange rates into a single base currency
+type Table struct {
+	base  string
+	rates map[string][]rate // per currency, oldest first
+}
+
+// NewTable creates an empty table for the given base currency.
+// An empty table only converts amounts that are already in the base currency.
+func NewTable(base string) *Table {
+	return &Table{base: strings.ToUpper(strings.TrimSpace(base)), rates: make(map[string][]rate)}
+}
+
+// Load reads a CSV rate file with the columns date (YYYY-MM-DD), currency and rate,
+// where rate is the value of one unit of currency in the base currency.
+// A header row and lines starting with # are ignored. A missing file is not an
+// error; it yields an empty table.
+func Load(path, base string) (*Table, error) {
+	t := NewTable(base)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FX rate file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read FX rate file %s: %v", path, err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("%s line %d: expected date,currency,rate", path, line)
+		}
+		if strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+		if err := t.add(record[0], record[1], record[2]); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+	}
+
+	for currency := range t.rates {
+		list := t.rates[currency]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].date.Before(list[j].date) })
+	}
+	return t, nil
+}
+
+func (t *Table) add(dateText, currency, value string) error {
+	date, err := time.Parse(dateLayout, strings.TrimSpace(dateText))
+	if err != nil {
+		return fmt.Errorf("invalid date %q", dateText)
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	v, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || v.Sign() <= 0 {
+		return fmt.Errorf("invalid rate %q", value)
+	}
+	t.rates[currency] = append(t.rates[currency], rate{date: date, value: v})
+	return nil
+}
+
+// Base returns the currency amounts are converted into
+func (t *Table) Base() string { return t.base }
+
+// Convert converts m into the base currency using the most recent rate dated on
+// or before date. The result is rounded half to even to the base currency's minor unit.
+func (t *Table) Convert(m models.Money, date time.Time) (models.Money, error) {
+	if m.Currency == "" || m.Currency == t.base {
+		return models.NewMoney(m.Minor, t.base), nil
+	}
+
+	r, ok := t.lookup(m.Currency, date)
+	if !ok {
+		return models.Money{}, fmt.Errorf("no %s rate on or before %s", m.Currency, date.Format(dateLayout))
+	}
+
+	// minor units of m -> major units -> base major units -> base minor units
+	v := new(big.Rat).SetInt64(m.Minor)
+	v.Mul(v, r)
+	v.Mul(v, pow10(models.CurrencyExponent(t.base)))
+	v.Quo(v, pow10(models.CurrencyExponent(m.Currency)))
+	return models.NewMoney(roundHalfEven(v), t.base), nil
+}
+
+// lookup returns the latest rate for currency dated on or before date
+func (t *Table) lookup(currency string, date time.Time) (*big.Rat, bool) {
+	list := t.rates[currency]
+	i := sort.Search(len(list), func(i int) bool { return list[i].date.After(date) })
+	if i == 0 {
+		return nil, false
+	}
+	return list[i-1].value, true
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+// roundHalfEven rounds v to the nearest integer, ties to even
+func roundHalfEven(v *big.Rat) int64 {
+	num, den := v.Num(), v.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	// Compare twice the remainder's magnitude with the denominator
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(den); c > 0 || (c == 0 && q.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
@@ -0,0 +1,97 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func TestConvert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx_rates.csv")
+	content := "# rates into COP\ndate,currency,rate\n2025-08-01,USD,4055.80\n2025-07-01,USD,4012.35\n2025-07-01,JPY,27.5\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := Load(path, "cop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Base() != "COP" {
+		t.Errorf("Base() = %s, want COP", table.Base())
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tests := []struct {
+		amount models.Money
+		date   string
+		want   models.Money
+	}{
+		{models.NewMoney(-1599, "USD"), "2025-07-15", models.NewMoney(-6415748, "COP")},
+		// The most recent rate on or before the date applies
+		{models.NewMoney(1000, "USD"), "2025-08-01", models.NewMoney(4055800, "COP")},
+		{models.NewMoney(1000, "JPY"), "2025-07-02", models.NewMoney(2750000, "COP")},
+		// Amounts in the base currency or without one are not converted
+		{models.NewMoney(12345, "COP"), "2020-01-01", models.NewMoney(12345, "COP")},
+		{models.Money{Minor: 700}, "2020-01-01", models.NewMoney(700, "COP")},
+	}
+	for _, tt := range tests {
+		got, err := table.Convert(tt.amount, day(tt.date))
+		if err != nil {
+			t.Errorf("Convert(%s, %s): %v", tt.amount, tt.date, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Convert(%s, %s) = %s, want %s", tt.amount, tt.date, got, tt.want)
+		}
+	}
+
+	if _, err := table.Convert(models.NewMoney(100, "USD"), day("2025-06-30")); err == nil {
+		t.Error("Convert before the first USD rate succeeded, want an error")
+	}
+	if _, err := table.Convert(models.NewMoney(100, "EUR"), day("2025-07-15")); err == nil {
+		t.Error("Convert without EUR rates succeeded, want an error")
+	}
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	// Halving odd cents gives exact ties
+	table := NewTable("USD")
+	if err := table.add("2025-01-01", "CHF", "0.5"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		minor int64
+		want  int64
+	}{
+		{1, 0},   // 0.005 rounds to the even 0.00
+		{3, 2},   // 0.015 rounds to the even 0.02
+		{-3, -2}, // and likewise below zero
+		{5, 2},   // 0.025 rounds to 0.02
+		{7, 4},   // 0.035 rounds to 0.04
+	}
+	for _, tt := range tests {
+		got, err := table.Convert(models.NewMoney(tt.minor, "CHF"), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Minor != tt.want {
+			t.Errorf("%d CHF cents at 0.5 = %d cents, want %d", tt.minor, got.Minor, tt.want)
+		}
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	table, err := Load(filepath.Join(t.TempDir(), "missing.csv"), "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Convert(models.NewMoney(100, "EUR"), time.Now()); err == nil {
+		t.Error("an empty table converted EUR, want an error")
+	}
+}
@@ -0,0 +1,304 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// CAMTImporter reads ISO 20022 camt.053 bank-to-customer statements.
+// Element names are matched without namespaces, so every camt.053 version
+// (001.02 through 001.08 and later) is accepted.
+type CAMTImporter struct{}
+
+// Name returns "camt.053"
+func (i *CAMTImporter) Name() string { return "camt.053" }
+
+// Accepts reports whether filename is an .xml file
+func (i *CAMTImporter) Accepts(filename string) bool {
+	return hasExtension(filename, ".xml")
+}
+
+// camtDocument maps the parts of a camt.053 document the importer uses
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	Account  camtAccount   `xml:"Acct"`
+	From     string        `xml:"FrToDt>FrDtTm"`
+	To       string        `xml:"FrToDt>ToDtTm"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtAccount struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Type     string `xml:"Tp>Cd"`
+	Currency string `xml:"Ccy"`
+	Name     string `xml:"Nm"`
+	// The servicer's BIC element was renamed from BIC to BICFI in later versions
+	BIC      string `xml:"Svcr>FinInstnId>BIC"`
+	BICFI    string `xml:"Svcr>FinInstnId>BICFI"`
+	Servicer string `xml:"Svcr>FinInstnId>Nm"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	CreditInd string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtEntry struct {
+	Reference      string          `xml:"NtryRef"`
+	Amount         camtAmount      `xml:"Amt"`
+	CreditInd      string          `xml:"CdtDbtInd"`
+	Reversal       bool            `xml:"RvslInd"`
+	BookingDate    camtDate        `xml:"BookgDt"`
+	ValueDate      camtDate        `xml:"ValDt"`
+	ServicerRef    string          `xml:"AcctSvcrRef"`
+	Details        []camtTxDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo string          `xml:"AddtlNtryInf"`
+}
+
+type camtTxDetails struct {
+	EndToEndID string `xml:"Refs>EndToEndId"`
+	// Party names moved under a Pty element in camt.053.001.08; both forms are read
+	CreditorName    string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPtyName string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	DebtorName      string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPtyName   string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Unstructured    []string `xml:"RmtInf>Ustrd"`
+	StructuredRef   string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalInfo  string   `xml:"AddtlTxInf"`
+}
+
+// Import reads every entry of every statement in the document
+func (i *CAMTImporter) Import(path, source string) ([]*models.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read camt.053 file %s: %v", path, err)
+	}
+
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse camt.053 file %s: %v", path, err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("%s is not a camt.053 statement: no BkToCstmrStmt/Stmt element", source)
+	}
+
+	var result []*models.Transaction
+	for _, stmt := range doc.Statements {
+		var statement []*models.Transaction
+		for _, entry := range stmt.Entries {
+			t, err := entry.toTransaction(source)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: %v", stmt.ID, err)
+			}
+			statement = append(statement, t)
+		}
+
+		header := stmt.header(source)
+		if closing, ok := stmt.balance("CLBD"); ok {
+			applyRunningBalance(statement, closing)
+			header.ClosingBalance = closing
+		}
+		header.Link(statement)
+		result = append(result, statement...)
+	}
+	return result, nil
+}
+
+// header builds the statement entity from the account and period elements
+func (s camtStatement) header(source string) *models.Statement {
+	currency := s.Account.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	kind := models.AccountChecking
+	switch s.Account.Type {
+	case "SVGS":
+		kind = models.AccountSavings
+	case "CARD":
+		kind = models.AccountCreditCard
+	case "LOAN":
+		kind = models.AccountLoan
+	}
+
+	stmt := &models.Statement{
+		Source: source,
+		Account: &models.Account{
+			Institution: firstNonEmpty(s.Account.Servicer, s.Account.BICFI, s.Account.BIC),
+			Kind:        kind,
+			Number:      firstNonEmpty(s.Account.IBAN, s.Account.Other),
+			Name:        s.Account.Name,
+			Currency:    currency,
+		},
+		OpeningBalance: models.Money{Currency: currency},
+		ClosingBalance: models.Money{Currency: currency},
+		MinimumPayment: models.Money{Currency: currency},
+	}
+	stmt.PeriodStart, _ = camtDate{DateTime: s.From}.parse()
+	stmt.PeriodEnd, _ = camtDate{DateTime: s.To}.parse()
+	// Opening booked balance, or the previous statement's closing balance
+	if opening, ok := s.balance("OPBD"); ok {
+		stmt.OpeningBalance = opening
+	} else if opening, ok := s.balance("PRCD"); ok {
+		stmt.OpeningBalance = opening
+	}
+	return stmt
+}
+
+// balance returns the statement balance with the given type code, e.g. CLBD for the booked closing balance
+func (s camtStatement) balance(code string) (models.Money, bool) {
+	for _, b := range s.Balances {
+		if b.Code != code {
+			continue
+		}
+		v, err := b.Amount.money()
+		if err != nil {
+			return models.Money{}, false
+		}
+		if b.CreditInd == "DBIT" {
+			v = v.Neg()
+		}
+		return v, true
+	}
+	return models.Money{}, false
+}
+
+// money parses the amount in its Ccy currency
+func (a camtAmount) money() (models.Money, error) {
+	currency := a.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	return models.ParseMoney(a.Value, currency)
+}
+
+func (e camtEntry) toTransaction(source string) (*models.Transaction, error) {
+	booking, err := e.BookingDate.parse()
+	if err != nil {
+		return nil, fmt.Errorf("entry %s: invalid booking date: %v", e.ServicerRef, err)
+	}
+	var valueDate time.Time
+	if e.ValueDate.Date != "" || e.ValueDate.DateTime != "" {
+		if valueDate, err = e.ValueDate.parse(); err != nil {
+			return nil, fmt.Errorf("entry %s: invalid value date: %v", e.ServicerRef, err)
+		}
+	}
+
+	amount, err := e.Amount.money()
+	if err != nil {
+		return nil, fmt.Errorf("entry %s: invalid amount %q", e.ServicerRef, e.Amount.Value)
+	}
+	// CdtDbtInd is the direction of the entry itself, reversals included
+	debit := e.CreditInd == "DBIT"
+	transactionType := models.Credit
+	if debit {
+		amount = amount.Neg()
+		transactionType = models.Debit
+	}
+
+	var counterparty string
+	var remittance []string
+	for _, d := range e.Details {
+		if counterparty == "" {
+			counterparty = d.counterparty(debit)
+		}
+		remittance = append(remittance, d.Unstructured...)
+		if d.StructuredRef != "" {
+			remittance = append(remittance, d.StructuredRef)
+		}
+	}
+	remittanceInfo := strings.Join(strings.Fields(strings.Join(remittance, " ")), " ")
+
+	externalID := e.ServicerRef
+	if externalID == "" {
+		externalID = e.Reference
+	}
+
+	// RvslInd only tells that the entry undoes an earlier one; say so in the description
+	description := describe(counterparty, remittanceInfo, e.AdditionalInfo)
+	if e.Reversal {
+		description = strings.TrimSpace("REVERSAL " + description)
+	}
+
+	return &models.Transaction{
+		Date:           booking,
+		ValueDate:      valueDate,
+		Description:    description,
+		Amount:         amount,
+		Type:           transactionType,
+		RawText:        strings.TrimSpace(fmt.Sprintf("%s %s %s %s", e.CreditInd, e.Amount.Value, e.Amount.Currency, e.AdditionalInfo)),
+		Source:         source,
+		ExternalID:     externalID,
+		Counterparty:   counterparty,
+		RemittanceInfo: remittanceInfo,
+	}, nil
+}
+
+// counterparty returns the other party: the creditor of a debit, the debtor of a credit
+func (d camtTxDetails) counterparty(debit bool) string {
+	if debit {
+		return firstNonEmpty(d.CreditorName, d.CreditorPtyName)
+	}
+	return firstNonEmpty(d.DebtorName, d.DebtorPtyName)
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		// ISO date-times may or may not carry a zone; only the date part is kept
+		s := strings.TrimSpace(d.DateTime)
+		if len(s) >= 10 {
+			return time.Parse("2006-01-02", s[:10])
+		}
+	}
+	return time.Time{}, fmt.Errorf("missing date")
+}
+
+// describe builds a transaction description from the counterparty and remittance
+// information, falling back to the bank's additional entry information
+func describe(counterparty, remittance, fallback string) string {
+	switch {
+	case counterparty != "" && remittance != "":
+		return counterparty + " - " + remittance
+	case counterparty != "":
+		return counterparty
+	case remittance != "":
+		return remittance
+	default:
+		return strings.TrimSpace(fallback)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/reconcile"
+)
+
+func TestCAMTImport(t *testing.T) {
+	transactions, err := (&CAMTImporter{}).Import("testdata/camt053.xml", "camt053.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		amount      models.Money
+		kind        models.TransactionType
+		externalID  string
+	}{
+		{"ACME GMBH - GEHALT JUNI", models.NewMoney(250000, "EUR"), models.Credit, "REF-1"},
+		{"STADTWERKE - LASTSCHRIFT STROM", models.NewMoney(-60000, "EUR"), models.Debit, "REF-2"},
+		// A reversal is booked in the direction its CdtDbtInd gives
+		{"REVERSAL STADTWERKE - RUECKLASTSCHRIFT STROM", models.NewMoney(60000, "EUR"), models.Credit, "REF-3"},
+		{"KARTENZAHLUNG", models.NewMoney(-55000, "EUR"), models.Debit, "REF-4"},
+	}
+	if len(transactions) != len(tests) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(tests))
+	}
+	for i, tt := range tests {
+		tx := transactions[i]
+		if tx.Description != tt.description || tx.Amount != tt.amount || tx.Type != tt.kind || tx.ExternalID != tt.externalID {
+			t.Errorf("transaction %d = %q %s %s %q, want %q %s %s %q", i,
+				tx.Description, tx.Amount, tx.Type, tx.ExternalID, tt.description, tt.amount, tt.kind, tt.externalID)
+		}
+	}
+
+	stmt := transactions[0].Statement
+	if stmt.Account.Number != "DE89370400440532013000" || stmt.Account.Institution != "COBADEFFXXX" {
+		t.Errorf("unexpected account %+v", stmt.Account)
+	}
+	if r := reconcile.Check(stmt, transactions, reconcile.DefaultTolerance); !r.CheckedBalance || !r.Reconciled() {
+		t.Errorf("statement does not reconcile: %s", r.Problem())
+	}
+}
+
+func TestCAMTImportEntryInOtherCurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "camt053.xml")
+	document := `<Document><BkToCstmrStmt><Stmt>
+  <Id>STMT-1</Id>
+  <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+  <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">900.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+  <Ntry><Amt Ccy="EUR">50.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2025-06-02</Dt></BookgDt></Ntry>
+  <Ntry><Amt Ccy="USD">20.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2025-06-05</Dt></BookgDt></Ntry>
+  <Ntry><Amt Ccy="EUR">100.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2025-06-09</Dt></BookgDt></Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+	if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := (&CAMTImporter{}).Import(path, "camt053.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the rows after the USD entry have a known balance
+	want := []models.Money{{}, {}, models.NewMoney(90000, "EUR")}
+	for i, tx := range transactions {
+		if tx.Balance != want[i] {
+			t.Errorf("transaction %d balance = %s, want %s", i, tx.Balance, want[i])
+		}
+	}
+}
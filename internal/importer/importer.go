@@ -0,0 +1,64 @@
+// Package importer reads structured bank exports (as opposed to PDF statements)
+// directly into transactions, without any text extraction or LLM calls.
+package importer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Importer converts one structured export format into transactions
+type Importer interface {
+	// Name identifies the format, e.g. "ofx"
+	Name() string
+
+	// Accepts reports whether the file name has an extension this importer reads
+	Accepts(filename string) bool
+
+	// Import reads the file at path; source is recorded on every transaction
+	Import(path, source string) ([]*models.Transaction, error)
+}
+
+// Registry holds the available importers in priority order
+type Registry struct {
+	importers []Importer
+}
+
+// NewRegistry creates a registry with the given importers
+func NewRegistry(importers ...Importer) *Registry {
+	return &Registry{importers: importers}
+}
+
+// Default returns a registry with every built-in importer.
+// Spreadsheet exports are mapped using the given column mapping profiles.
+func Default(profiles []Profile) *Registry {
+	return NewRegistry(
+		&OFXImporter{},
+		NewTabularImporter(profiles),
+		&CAMTImporter{},
+		&MT940Importer{},
+	)
+}
+
+// ForFile returns the importer for a file name, or nil if the format is not supported
+func (r *Registry) ForFile(filename string) Importer {
+	for _, imp := range r.importers {
+		if imp.Accepts(filename) {
+			return imp
+		}
+	}
+	return nil
+}
+
+// hasExtension reports whether filename ends with one of the given extensions, ignoring case
+func hasExtension(filename string, extensions ...string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// MT940Importer reads SWIFT MT940 customer statement messages.
+// A file may contain several messages; each :61: statement line becomes one
+// transaction, enriched with the :86: information line that follows it.
+type MT940Importer struct{}
+
+// Name returns "mt940"
+func (i *MT940Importer) Name() string { return "mt940" }
+
+// Accepts reports whether filename is an .sta, .mt940 or .940 file
+func (i *MT940Importer) Accepts(filename string) bool {
+	return hasExtension(filename, ".sta", ".mt940", ".940")
+}
+
+// mt940Field matches the start of a field such as ":61:" or ":28C:"
+var mt940Field = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+// mt940StatementLine parses the mandatory part of a :61: field:
+// value date YYMMDD, optional entry date MMDD, debit/credit mark (D, C, RD, RC),
+// optional funds code, amount with comma decimals, transaction type and reference.
+// The currency is not repeated per line; it comes from the statement's balance fields.
+var mt940StatementLine = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[DC])([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/\n]*)(?://([^\n]*))?`)
+
+// mt940Balance parses a balance field (:60F:, :62F: ...): mark, date, currency, amount
+var mt940Balance = regexp.MustCompile(`^([DC])(\d{6})([A-Z]{3})(\d+,\d*)`)
+
+// Import reads every statement line of every message in the file
+func (i *MT940Importer) Import(path, source string) ([]*models.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MT940 file %s: %v", path, err)
+	}
+	return parseMT940(string(data), source)
+}
+
+type mt940Tag struct {
+	name  string
+	value string
+}
+
+// parseMT940 walks the fields of the file in order
+func parseMT940(content, source string) ([]*models.Transaction, error) {
+	var (
+		result    []*models.Transaction
+		statement []*models.Transaction
+		last      *models.Transaction
+		header    *models.Statement
+		currency  = models.DefaultCurrency
+	)
+
+	flush := func() {
+		if header != nil {
+			header.Link(statement)
+		}
+		result = append(result, statement...)
+		statement, last, header = nil, nil, nil
+	}
+	// statementHeader returns the header of the current message, creating it on first use
+	statementHeader := func() *models.Statement {
+		if header == nil {
+			zero := models.Money{Currency: currency}
+			header = &models.Statement{
+				Source:         source,
+				Account:        &models.Account{Kind: models.AccountChecking, Currency: currency},
+				OpeningBalance: zero,
+				ClosingBalance: zero,
+				MinimumPayment: zero,
+			}
+		}
+		return header
+	}
+
+	for _, tag := range splitMT940(content) {
+		switch tag.name {
+		case "20":
+			// Transaction reference number: start of a new message
+			flush()
+		case "25":
+			// Account identification: "bank code/account number" or an IBAN
+			statementHeader().Account.Number = strings.TrimSpace(tag.value)
+		case "60F", "60M":
+			opening, date, err := parseMT940Balance(tag.value)
+			if err != nil {
+				return nil, err
+			}
+			currency = opening.Currency
+			h := statementHeader()
+			h.Account.Currency = currency
+			h.MinimumPayment = models.Money{Currency: currency}
+			h.OpeningBalance = opening
+			if h.PeriodStart.IsZero() {
+				h.PeriodStart = date
+			}
+		case "61":
+			t, err := parseMT940StatementLine(tag.value, source, currency)
+			if err != nil {
+				return nil, err
+			}
+			statement = append(statement, t)
+			last = t
+		case "86":
+			if last != nil {
+				applyMT940Information(last, tag.value)
+			}
+		case "62F", "62M":
+			closing, date, err := parseMT940Balance(tag.value)
+			if err != nil {
+				return nil, err
+			}
+			applyRunningBalance(statement, closing)
+			h := statementHeader()
+			h.ClosingBalance = closing
+			h.PeriodEnd = date
+			last = nil
+		}
+	}
+
+	flush()
+	if len(result) == 0 && !strings.Contains(content, ":61:") && !strings.Contains(content, ":20:") {
+		return nil, fmt.Errorf("%s is not an MT940 statement: no :20: or :61: fields", source)
+	}
+	return result, nil
+}
+
+// splitMT940 splits the message text into fields, joining continuation lines
+func splitMT940(content string) []mt940Tag {
+	var tags []mt940Tag
+	var current *mt940Tag
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if m := mt940Field.FindStringSubmatch(line); m != nil {
+			tags = append(tags, mt940Tag{name: m[1], value: line[len(m[0]):]})
+			current = &tags[len(tags)-1]
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		// Block delimiters ("{4:", "-}") and blank lines end the current field
+		if trimmed == "" || trimmed == "-}" || trimmed == "-" || strings.HasPrefix(trimmed, "{") {
+			current = nil
+			continue
+		}
+		if current != nil {
+			current.value += "\n" + line
+		}
+	}
+	return tags
+}
+
+func parseMT940StatementLine(value, source, currency string) (*models.Transaction, error) {
+	m := mt940StatementLine.FindStringSubmatch(value)
+	if m == nil {
+		return nil, fmt.Errorf("invalid :61: statement line %q", value)
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value date in :61: %q", value)
+	}
+	booking := valueDate
+	if m[2] != "" {
+		booking, err = time.Parse("20060102", fmt.Sprintf("%04d%s", valueDate.Year(), m[2]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry date in :61: %q", value)
+		}
+		// The entry date carries no year; keep it within a few months of the value date
+		if booking.Sub(valueDate) > 180*24*time.Hour {
+			booking = booking.AddDate(-1, 0, 0)
+		} else if valueDate.Sub(booking) > 180*24*time.Hour {
+			booking = booking.AddDate(1, 0, 0)
+		}
+	}
+
+	amount, err := models.ParseMoney(strings.Replace(m[5], ",", ".", 1), currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount in :61: %q", value)
+	}
+	// "D" debits and "RC" reversed credits take money out of the account
+	transactionType := models.Credit
+	if m[3] == "D" || m[3] == "RC" {
+		amount = amount.Neg()
+		transactionType = models.Debit
+	}
+
+	externalID := strings.TrimSpace(m[8])
+	if externalID == "" {
+		externalID = strings.TrimSpace(m[7])
+	}
+	if externalID == "NONREF" {
+		externalID = ""
+	}
+
+	return &models.Transaction{
+		Date:        booking,
+		ValueDate:   valueDate,
+		Description: strings.TrimSpace(m[7]),
+		Amount:      amount,
+		Type:        transactionType,
+		RawText:     strings.TrimSpace(value),
+		Source:      source,
+		ExternalID:  externalID,
+	}, nil
+}
+
+// mt940Subfield matches "?NN" subfields used by the German (DFÜ) :86: layout
+var mt940Subfield = regexp.MustCompile(`\?(\d{2})`)
+
+// mt940SlashCode matches "/CODE/" keywords used by the SWIFT-recommended :86: layout
+var mt940SlashCode = regexp.MustCompile(`/(NAME|REMI|ORDP|BENM|EREF|IREF|CNTP|PURP|MARF|ADDR|CSID|BUSP|RTRN)/`)
+
+// applyMT940Information fills counterparty and remittance info from a :86: field.
+// Structured layouts are recognized; anything else is kept as remittance text.
+func applyMT940Information(t *models.Transaction, info string) {
+	info = strings.ReplaceAll(info, "\n", "")
+
+	switch {
+	case mt940Subfield.MatchString(info):
+		fields := splitByMarkers(info, mt940Subfield)
+		var remittance []string
+		for code := 20; code <= 29; code++ {
+			remittance = append(remittance, fields[fmt.Sprintf("%02d", code)])
+		}
+		for code := 60; code <= 63; code++ {
+			remittance = append(remittance, fields[fmt.Sprintf("%02d", code)])
+		}
+		t.RemittanceInfo = strings.Join(strings.Fields(strings.Join(remittance, " ")), " ")
+		t.Counterparty = strings.TrimSpace(fields["32"] + fields["33"])
+	case mt940SlashCode.MatchString(info):
+		fields := splitByMarkers(info, mt940SlashCode)
+		t.Counterparty = firstNonEmpty(fields["NAME"], fields["CNTP"], fields["BENM"], fields["ORDP"])
+		// CNTP is "account/bic/name/city"; keep only the name part
+		if parts := strings.Split(t.Counterparty, "/"); len(parts) >= 3 && fields["NAME"] == "" {
+			t.Counterparty = strings.TrimSpace(parts[2])
+		}
+		t.RemittanceInfo = strings.TrimSpace(fields["REMI"])
+	default:
+		t.RemittanceInfo = strings.Join(strings.Fields(info), " ")
+	}
+
+	if d := describe(t.Counterparty, t.RemittanceInfo, ""); d != "" {
+		t.Description = d
+	}
+}
+
+// splitByMarkers splits s at every match of marker, keyed by the marker's first group
+func splitByMarkers(s string, marker *regexp.Regexp) map[string]string {
+	fields := make(map[string]string)
+	locs := marker.FindAllStringSubmatchIndex(s, -1)
+	for i, loc := range locs {
+		end := len(s)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		key := s[loc[2]:loc[3]]
+		fields[key] += s[loc[1]:end]
+	}
+	return fields
+}
+
+// parseMT940Balance returns the signed amount and date of a balance field
+func parseMT940Balance(value string) (models.Money, time.Time, error) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return models.Money{}, time.Time{}, fmt.Errorf("invalid balance field %q", value)
+	}
+	date, err := time.Parse("060102", m[2])
+	if err != nil {
+		return models.Money{}, time.Time{}, fmt.Errorf("invalid balance date %q", value)
+	}
+	v, err := models.ParseMoney(strings.Replace(m[4], ",", ".", 1), m[3])
+	if err != nil {
+		return models.Money{}, time.Time{}, fmt.Errorf("invalid balance amount %q", value)
+	}
+	if m[1] == "D" {
+		v = v.Neg()
+	}
+	return v, date, nil
+}
@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/reconcile"
+)
+
+func TestMT940Import(t *testing.T) {
+	transactions, err := (&MT940Importer{}).Import("testdata/statement.sta", "statement.sta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date        string
+		description string
+		amount      models.Money
+		kind        models.TransactionType
+		externalID  string
+	}{
+		{"2024-12-30", "STADTWERKE KOELN - STROM DEZEMBER KUNDE 4711", models.NewMoney(-4510, "EUR"), models.Debit, "REF-1"},
+		{"2024-12-31", "NONREF", models.NewMoney(-150, "EUR"), models.Debit, ""},
+		{"2025-01-02", "ACME GMBH - GEHALT JANUAR", models.NewMoney(250000, "EUR"), models.Credit, "REF-2"},
+		// A reversed credit takes the money back out
+		{"2025-01-02", "RUECKBUCHUNG GUTSCHRIFT", models.NewMoney(-10000, "EUR"), models.Debit, "REF-3"},
+	}
+	if len(transactions) != len(tests) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(tests))
+	}
+	for i, tt := range tests {
+		tx := transactions[i]
+		if got := tx.Date.Format("2006-01-02"); got != tt.date || tx.Description != tt.description ||
+			tx.Amount != tt.amount || tx.Type != tt.kind || tx.ExternalID != tt.externalID {
+			t.Errorf("transaction %d = %s %q %s %s %q, want %s %q %s %s %q", i,
+				got, tx.Description, tx.Amount, tx.Type, tx.ExternalID, tt.date, tt.description, tt.amount, tt.kind, tt.externalID)
+		}
+	}
+
+	stmt := transactions[0].Statement
+	if stmt.Account.Number != "37040044/0532013000" || stmt.Account.Currency != "EUR" {
+		t.Errorf("unexpected account %+v", stmt.Account)
+	}
+	if r := reconcile.Check(stmt, transactions, reconcile.DefaultTolerance); !r.CheckedBalance || !r.Reconciled() {
+		t.Errorf("statement does not reconcile: %s", r.Problem())
+	}
+}
+
+func TestMT940RejectsOtherFiles(t *testing.T) {
+	if _, err := parseMT940("Date,Description,Amount\n", "export.sta"); err == nil {
+		t.Error("parseMT940 accepted a file without MT940 fields")
+	}
+}
@@ -0,0 +1,293 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// OFXImporter reads OFX 1.x (SGML) and OFX 2.x (XML) files, including Quicken's QFX variant.
+//
+// Both versions share the same element names; 1.x simply omits the closing tags
+// of leaf elements. The importer therefore scans tags sequentially instead of
+// using an XML decoder, treating any tag followed by text as a leaf value.
+type OFXImporter struct{}
+
+// Name returns "ofx"
+func (i *OFXImporter) Name() string { return "ofx" }
+
+// Accepts reports whether filename is an .ofx or .qfx file
+func (i *OFXImporter) Accepts(filename string) bool {
+	return hasExtension(filename, ".ofx", ".qfx")
+}
+
+// ofxTag matches an opening or closing tag and the text that follows it
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9._]+)>([^<]*)`)
+
+// ofxTransaction holds the STMTTRN fields used to build a models.Transaction
+type ofxTransaction struct {
+	trnType  string
+	posted   string
+	amount   string
+	fitID    string
+	name     string
+	memo     string
+	checkNum string
+}
+
+// Import reads every STMTTRN record of every statement in the file
+func (i *OFXImporter) Import(path, source string) ([]*models.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX file %s: %v", path, err)
+	}
+	return parseOFX(string(data), source)
+}
+
+// parseOFX converts the OFX document body into transactions.
+// The running balance of each statement is reconstructed backwards from its
+// LEDGERBAL, which the bank reports as of the end of the statement. Credit card
+// balances are turned into amounts owed, as models.Statement expects.
+func parseOFX(content, source string) ([]*models.Transaction, error) {
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("not an OFX document: missing <OFX> element")
+	}
+	content = content[start:]
+
+	var (
+		result       []*models.Transaction
+		statement    []*models.Transaction
+		current      *ofxTransaction
+		inLedgerBal  bool
+		ledgerAmount string
+		currency     = models.DefaultCurrency
+		institution  string
+		header       ofxStatementHeader
+	)
+
+	flushStatement := func() error {
+		stmt := header.toStatement(source, institution, currency)
+		if ledgerAmount != "" && len(statement) > 0 {
+			balance, err := parseOFXAmount(ledgerAmount, currency)
+			if err != nil {
+				return fmt.Errorf("invalid LEDGERBAL amount: %v", err)
+			}
+			applyRunningBalance(statement, balance)
+			stmt.ClosingBalance = balance
+			stmt.OpeningBalance = balance
+			for _, t := range statement {
+				stmt.OpeningBalance = stmt.OpeningBalance.Sub(t.Amount)
+			}
+			// A card's LEDGERBAL is negative while money is owed; statements keep the amount owed
+			if stmt.Account.Kind == models.AccountCreditCard {
+				stmt.OpeningBalance, stmt.ClosingBalance = stmt.OpeningBalance.Neg(), stmt.ClosingBalance.Neg()
+				for _, t := range statement {
+					t.Balance = t.Balance.Neg()
+				}
+			}
+		}
+		stmt.Link(statement)
+		result = append(result, statement...)
+		statement = nil
+		ledgerAmount = ""
+		header = ofxStatementHeader{}
+		return nil
+	}
+
+	for _, m := range ofxTag.FindAllStringSubmatch(content, -1) {
+		closing := m[1] == "/"
+		tag := strings.ToUpper(m[2])
+		value := strings.TrimSpace(html.UnescapeString(m[3]))
+
+		switch {
+		case tag == "CURDEF" && value != "":
+			// Default currency of the statement that follows
+			currency = strings.ToUpper(value)
+		case tag == "STMTTRN" && !closing:
+			current = &ofxTransaction{}
+		case tag == "STMTTRN" && closing:
+			if current != nil {
+				t, err := current.toTransaction(source, currency)
+				if err != nil {
+					return nil, err
+				}
+				statement = append(statement, t)
+				current = nil
+			}
+		case tag == "LEDGERBAL":
+			inLedgerBal = !closing
+		case tag == "CCSTMTRS" && !closing:
+			header.creditCard = true
+		case (tag == "STMTRS" || tag == "CCSTMTRS") && closing:
+			if err := flushStatement(); err != nil {
+				return nil, err
+			}
+		case closing || value == "":
+			// Aggregate boundaries and explicit closing tags of leaf elements carry no data
+		case current != nil:
+			current.set(tag, value)
+		case inLedgerBal && tag == "BALAMT":
+			ledgerAmount = value
+		case tag == "ORG":
+			institution = value
+		default:
+			header.set(tag, value)
+		}
+	}
+
+	// Tolerate truncated files that never close their statement aggregate
+	if err := flushStatement(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ofxStatementHeader holds the account and period fields of a statement aggregate
+type ofxStatementHeader struct {
+	creditCard bool
+	acctID     string
+	acctType   string
+	start      string
+	end        string
+}
+
+func (h *ofxStatementHeader) set(tag, value string) {
+	switch tag {
+	case "ACCTID":
+		h.acctID = value
+	case "ACCTTYPE":
+		h.acctType = value
+	case "DTSTART":
+		h.start = value
+	case "DTEND":
+		h.end = value
+	}
+}
+
+func (h *ofxStatementHeader) toStatement(source, institution, currency string) *models.Statement {
+	kind := models.AccountChecking
+	switch {
+	case h.creditCard || h.acctType == "CREDITLINE":
+		kind = models.AccountCreditCard
+	case h.acctType == "SAVINGS" || h.acctType == "MONEYMRKT":
+		kind = models.AccountSavings
+	}
+
+	stmt := &models.Statement{
+		Source: source,
+		Account: &models.Account{
+			Institution: institution,
+			Kind:        kind,
+			Number:      h.acctID,
+			Currency:    currency,
+		},
+		OpeningBalance: models.Money{Currency: currency},
+		ClosingBalance: models.Money{Currency: currency},
+		MinimumPayment: models.Money{Currency: currency},
+	}
+	stmt.PeriodStart, _ = parseOFXDate(h.start)
+	stmt.PeriodEnd, _ = parseOFXDate(h.end)
+	return stmt
+}
+
+func (t *ofxTransaction) set(tag, value string) {
+	switch tag {
+	case "TRNTYPE":
+		t.trnType = value
+	case "DTPOSTED":
+		t.posted = value
+	case "TRNAMT":
+		t.amount = value
+	case "FITID":
+		t.fitID = value
+	case "NAME":
+		t.name = value
+	case "MEMO":
+		t.memo = value
+	case "CHECKNUM":
+		t.checkNum = value
+	}
+}
+
+func (t *ofxTransaction) toTransaction(source, currency string) (*models.Transaction, error) {
+	date, err := parseOFXDate(t.posted)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %v", t.fitID, err)
+	}
+	amount, err := parseOFXAmount(t.amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %v", t.fitID, err)
+	}
+
+	description := t.name
+	switch {
+	case description == "":
+		description = t.memo
+	case t.memo != "" && !strings.Contains(description, t.memo):
+		description += " - " + t.memo
+	}
+	if description == "" && t.checkNum != "" {
+		description = "CHECK " + t.checkNum
+	}
+
+	transactionType := models.Debit
+	if amount.IsPositive() {
+		transactionType = models.Credit
+	}
+
+	return &models.Transaction{
+		Date:        date,
+		Description: description,
+		Amount:      amount,
+		Type:        transactionType,
+		RawText:     strings.TrimSpace(fmt.Sprintf("%s %s %s %s", t.trnType, t.posted, t.amount, description)),
+		Source:      source,
+		ExternalID:  t.fitID,
+	}, nil
+}
+
+// parseOFXDate parses the date part of an OFX datetime such as "20250715120000.000[-5:EST]"
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date %q", s)
+	}
+	date, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid OFX date %q", s)
+	}
+	return date, nil
+}
+
+// parseOFXAmount parses a signed OFX amount; the spec allows either "." or "," as decimal separator
+func parseOFXAmount(s, currency string) (models.Money, error) {
+	v, err := models.ParseMoney(strings.Replace(strings.TrimSpace(s), ",", ".", 1), currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid OFX amount %q", s)
+	}
+	return v, nil
+}
+
+// applyRunningBalance fills Balance on each transaction, given the balance after the last one.
+// The balance before a transaction in another currency than closing is unknown, so that
+// transaction and the ones before it are left without a balance.
+func applyRunningBalance(transactions []*models.Transaction, closing models.Money) {
+	ordered := make([]*models.Transaction, len(transactions))
+	copy(ordered, transactions)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Date.Before(ordered[j].Date) })
+
+	balance := closing
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if c := ordered[i].Amount.Currency; c != "" && closing.Currency != "" && c != closing.Currency {
+			return
+		}
+		ordered[i].Balance = balance
+		balance = balance.Sub(ordered[i].Amount)
+	}
+}
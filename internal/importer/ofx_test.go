@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/reconcile"
+)
+
+const checkingOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><FI><ORG>Bancolombia</FI></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>COP
+<BANKACCTFROM><ACCTID>123456789<ACCTTYPE>SAVINGS</BANKACCTFROM>
+<BANKTRANLIST><DTSTART>20250601<DTEND>20250630
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20250605<TRNAMT>3000000.00<FITID>1<NAME>NOMINA ACME</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250610120000.000[-5:EST]<TRNAMT>-125000,50<FITID>2<NAME>EXITO<MEMO>COMPRA</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>3875000.00<DTASOF>20250630</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+const cardOFX = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+<CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CURDEF>COP</CURDEF>
+<CCACCTFROM><ACCTID>4111111111117002</ACCTID></CCACCTFROM>
+<BANKTRANLIST><DTSTART>20250616</DTSTART><DTEND>20250715</DTEND>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250620</DTPOSTED><TRNAMT>-50000.00</TRNAMT><FITID>a</FITID><NAME>RAPPI</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20250625</DTPOSTED><TRNAMT>200000.00</TRNAMT><FITID>b</FITID><NAME>PAGO PSE</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250701</DTPOSTED><TRNAMT>-80000.00</TRNAMT><FITID>c</FITID><NAME>CINE COLOMBIA</NAME></STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>-330000.00</BALAMT><DTASOF>20250715</DTASOF></LEDGERBAL>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>`
+
+func TestParseOFXChecking(t *testing.T) {
+	transactions, err := parseOFX(checkingOFX, "savings.ofx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+
+	tx := transactions[1]
+	if tx.Description != "EXITO - COMPRA" || tx.Amount != models.NewMoney(-12500050, "COP") || tx.Type != models.Debit {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if got := tx.Date.Format("2006-01-02"); got != "2025-06-10" {
+		t.Errorf("date = %s, want 2025-06-10", got)
+	}
+	if tx.ExternalID != "2" || tx.Balance != models.NewMoney(387500000, "COP") {
+		t.Errorf("external ID %q, balance %s", tx.ExternalID, tx.Balance)
+	}
+
+	stmt := tx.Statement
+	if stmt.Account.Kind != models.AccountSavings || stmt.Account.Institution != "Bancolombia" {
+		t.Errorf("unexpected account %+v", stmt.Account)
+	}
+	if stmt.OpeningBalance != models.NewMoney(100000050, "COP") {
+		t.Errorf("opening balance = %s, want 1000000.50 COP", stmt.OpeningBalance)
+	}
+	if r := reconcile.Check(stmt, transactions, reconcile.DefaultTolerance); !r.Reconciled() {
+		t.Errorf("statement does not reconcile: %s", r.Problem())
+	}
+}
+
+func TestParseOFXCreditCardReconciles(t *testing.T) {
+	transactions, err := parseOFX(cardOFX, "card.qfx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(transactions))
+	}
+
+	stmt := transactions[0].Statement
+	if stmt.Account.Kind != models.AccountCreditCard {
+		t.Fatalf("account kind = %s, want %s", stmt.Account.Kind, models.AccountCreditCard)
+	}
+	// Balances of cards are amounts owed: 330000 owed at the end, 400000 at the start
+	if stmt.ClosingBalance != models.NewMoney(33000000, "COP") || stmt.OpeningBalance != models.NewMoney(40000000, "COP") {
+		t.Errorf("balances %s to %s, want 400000.00 COP to 330000.00 COP", stmt.OpeningBalance, stmt.ClosingBalance)
+	}
+	if got := transactions[0].Balance; got != models.NewMoney(45000000, "COP") {
+		t.Errorf("balance after the first charge = %s, want 450000.00 COP", got)
+	}
+
+	r := reconcile.Check(stmt, transactions, reconcile.DefaultTolerance)
+	if !r.CheckedBalance || !r.CheckedRunning {
+		t.Errorf("checks not made: balance %v, running %v", r.CheckedBalance, r.CheckedRunning)
+	}
+	if !r.Reconciled() {
+		t.Errorf("statement does not reconcile: %s", r.Problem())
+	}
+}
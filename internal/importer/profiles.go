@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile describes how the columns of one bank's spreadsheet export map onto a transaction.
+// The profile is selected automatically when every column listed in Headers is
+// present in the file's header row.
+type Profile struct {
+	// Name identifies the profile in logs, e.g. "bancolombia-ahorros"
+	Name string `yaml:"name"`
+
+	// Headers is the header fingerprint: column names that must all be present
+	Headers []string `yaml:"headers"`
+
+	// DateColumn and DateFormat locate and parse the transaction date (Go layout, e.g. "02/01/2006")
+	DateColumn string `yaml:"date_column"`
+	DateFormat string `yaml:"date_format"`
+
+	// DescriptionColumn holds the merchant or transaction description
+	DescriptionColumn string `yaml:"description_column"`
+
+	// AmountColumn holds a single signed amount. Alternatively, DebitColumn and
+	// CreditColumn hold unsigned money-out and money-in amounts.
+	AmountColumn string `yaml:"amount_column"`
+	DebitColumn  string `yaml:"debit_column"`
+	CreditColumn string `yaml:"credit_column"`
+
+	// SignConvention applies to AmountColumn: "debit-negative" (default) when
+	// money out is negative, "debit-positive" when money out is positive.
+	SignConvention string `yaml:"sign_convention"`
+
+	// DecimalSeparator is "." (default) or ","; the other character is treated as a thousands separator
+	DecimalSeparator string `yaml:"decimal_separator"`
+
+	// BalanceColumn optionally holds the running balance
+	BalanceColumn string `yaml:"balance_column"`
+
+	// Currency is the ISO-4217 code of the amounts; the default currency is assumed when empty
+	Currency string `yaml:"currency"`
+
+	// Delimiter optionally forces the CSV field delimiter; it is detected when empty
+	Delimiter string `yaml:"delimiter"`
+}
+
+// Sign conventions for Profile.SignConvention
+const (
+	SignDebitNegative = "debit-negative"
+	SignDebitPositive = "debit-positive"
+)
+
+// profileFile is the on-disk layout of the profiles config file
+type profileFile struct {
+	Profiles []Profile `yaml:"profiles"`
+}
+
+// LoadProfiles reads column mapping profiles from a YAML file.
+// A missing file is not an error; it simply yields no profiles.
+func LoadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import profiles %s: %v", path, err)
+	}
+
+	var file profileFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse import profiles %s: %v", path, err)
+	}
+
+	for i, p := range file.Profiles {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("import profile #%d (%s): %v", i+1, p.Name, err)
+		}
+	}
+	return file.Profiles, nil
+}
+
+func (p Profile) validate() error {
+	if len(p.Headers) == 0 {
+		return fmt.Errorf("headers are required to select the profile")
+	}
+	if p.DateColumn == "" || p.DateFormat == "" {
+		return fmt.Errorf("date_column and date_format are required")
+	}
+	if p.DescriptionColumn == "" {
+		return fmt.Errorf("description_column is required")
+	}
+	if p.AmountColumn == "" && p.DebitColumn == "" && p.CreditColumn == "" {
+		return fmt.Errorf("amount_column or debit_column/credit_column is required")
+	}
+	switch p.SignConvention {
+	case "", SignDebitNegative, SignDebitPositive:
+	default:
+		return fmt.Errorf("unknown sign_convention %q", p.SignConvention)
+	}
+	switch p.DecimalSeparator {
+	case "", ".", ",":
+	default:
+		return fmt.Errorf("decimal_separator must be \".\" or \",\"")
+	}
+	if p.Currency != "" && len(p.Currency) != 3 {
+		return fmt.Errorf("currency must be a three-letter ISO-4217 code")
+	}
+	return nil
+}
+
+// currency returns the profile's currency or the default one
+func (p Profile) currency() string {
+	if p.Currency != "" {
+		return p.Currency
+	}
+	return models.DefaultCurrency
+}
+
+// matches reports whether every fingerprint header appears in the header row
+func (p Profile) matches(header map[string]int) bool {
+	for _, h := range p.Headers {
+		if _, ok := header[normalizeHeader(h)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeHeader makes header comparison insensitive to case, surrounding
+// whitespace and a UTF-8 byte order mark
+func normalizeHeader(h string) string {
+	h = strings.TrimPrefix(h, "\ufeff")
+	return strings.ToLower(strings.Join(strings.Fields(h), " "))
+}
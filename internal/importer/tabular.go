@@ -0,0 +1,344 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// headerSearchRows is how many leading rows are searched for the header row,
+// since spreadsheet exports often start with a title block
+const headerSearchRows = 20
+
+// errInvalidDate marks rows whose date cell does not parse; such rows (balance
+// lines, totals, notes) are skipped instead of failing the import
+var errInvalidDate = errors.New("invalid date")
+
+// TabularImporter reads CSV and XLSX spreadsheet exports using column mapping profiles
+type TabularImporter struct {
+	profiles []Profile
+}
+
+// NewTabularImporter creates an importer that maps columns with the given profiles
+func NewTabularImporter(profiles []Profile) *TabularImporter {
+	return &TabularImporter{profiles: profiles}
+}
+
+// Name returns "spreadsheet"
+func (i *TabularImporter) Name() string { return "spreadsheet" }
+
+// Accepts reports whether filename is a .csv or .xlsx file
+func (i *TabularImporter) Accepts(filename string) bool {
+	return hasExtension(filename, ".csv", ".xlsx")
+}
+
+// Import reads the rows of the file and maps them with the profile matching its header row
+func (i *TabularImporter) Import(path, source string) ([]*models.Transaction, error) {
+	var (
+		rows      [][]string
+		headerRow int
+		header    map[string]int
+		profile   *Profile
+		err       error
+	)
+	numericCells := false
+	if hasExtension(path, ".xlsx") {
+		rows, err = readXLSX(path)
+		numericCells = true
+		if err == nil {
+			headerRow, header, profile = selectProfile(rows, i.candidates(nil))
+		}
+	} else {
+		rows, headerRow, header, profile, err = i.readCSV(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("no import profile matches the headers of %s; add one to the import profiles file", source)
+	}
+	fmt.Printf("Using import profile %q for %s\n", profile.Name, source)
+
+	var result []*models.Transaction
+	skipped, firstSkipped := 0, ""
+	for n, row := range rows[headerRow+1:] {
+		t, err := profile.mapRow(row, header, numericCells)
+		if errors.Is(err, errInvalidDate) {
+			if skipped == 0 {
+				firstSkipped = fmt.Sprintf("row %d: %v", headerRow+n+2, err)
+			}
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: %v", source, headerRow+n+2, err)
+		}
+		if t == nil {
+			continue
+		}
+		t.Source = source
+		result = append(result, t)
+	}
+	if skipped > 0 {
+		fmt.Printf("⚠️  Warning: %s: skipped %d rows without a valid date (first at %s)\n", source, skipped, firstSkipped)
+	}
+	return result, nil
+}
+
+// candidates returns, in order, the profiles keep accepts; nil keep accepts all
+func (i *TabularImporter) candidates(keep func(*Profile) bool) []*Profile {
+	var profiles []*Profile
+	for p := range i.profiles {
+		if keep == nil || keep(&i.profiles[p]) {
+			profiles = append(profiles, &i.profiles[p])
+		}
+	}
+	return profiles
+}
+
+// selectProfile finds the header row and the first profile whose fingerprint it matches
+func selectProfile(rows [][]string, profiles []*Profile) (int, map[string]int, *Profile) {
+	for r := 0; r < len(rows) && r < headerSearchRows; r++ {
+		header := make(map[string]int, len(rows[r]))
+		for c, name := range rows[r] {
+			if key := normalizeHeader(name); key != "" {
+				if _, dup := header[key]; !dup {
+					header[key] = c
+				}
+			}
+		}
+		for _, p := range profiles {
+			if p.matches(header) {
+				return r, header, p
+			}
+		}
+	}
+	return -1, nil, nil
+}
+
+// mapRow converts one data row into a transaction; blank rows yield nil
+func (p *Profile) mapRow(row []string, header map[string]int, numericCells bool) (*models.Transaction, error) {
+	cell := func(column string) string {
+		if column == "" {
+			return ""
+		}
+		idx, ok := header[normalizeHeader(column)]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	dateText := cell(p.DateColumn)
+	if dateText == "" {
+		// Summary and padding rows have no date
+		return nil, nil
+	}
+	date, err := parseSpreadsheetDate(dateText, p.DateFormat, numericCells)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount models.Money
+	if p.AmountColumn != "" {
+		amount, err = p.parseAmount(cell(p.AmountColumn), numericCells)
+		if err != nil {
+			return nil, err
+		}
+		if p.SignConvention == SignDebitPositive {
+			amount = amount.Neg()
+		}
+	} else {
+		debit, err := p.parseAmount(cell(p.DebitColumn), numericCells)
+		if err != nil {
+			return nil, err
+		}
+		credit, err := p.parseAmount(cell(p.CreditColumn), numericCells)
+		if err != nil {
+			return nil, err
+		}
+		amount = credit.Abs().Sub(debit.Abs())
+	}
+
+	balance := models.Money{Currency: p.currency()}
+	if b := cell(p.BalanceColumn); b != "" {
+		if balance, err = p.parseAmount(b, numericCells); err != nil {
+			return nil, err
+		}
+	}
+
+	transactionType := models.Debit
+	if amount.IsPositive() {
+		transactionType = models.Credit
+	}
+
+	return &models.Transaction{
+		Date:        date,
+		Description: strings.Join(strings.Fields(cell(p.DescriptionColumn)), " "),
+		Amount:      amount,
+		Type:        transactionType,
+		Balance:     balance,
+		RawText:     strings.Join(row, " | "),
+	}, nil
+}
+
+// plainNumber matches the canonical numbers XLSX stores for numeric cells
+var plainNumber = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+// parseAmount parses an amount using the profile's decimal separator.
+// Empty cells count as zero. Numeric XLSX cells are stored in canonical form
+// regardless of how they are displayed, so they are parsed directly.
+func (p *Profile) parseAmount(s string, numericCells bool) (models.Money, error) {
+	raw := s
+	currency := p.currency()
+	if s == "" {
+		return models.Money{Currency: currency}, nil
+	}
+	if numericCells && plainNumber.MatchString(s) {
+		if !strings.ContainsAny(s, "eE") {
+			return models.ParseMoney(s, currency)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return models.Money{}, fmt.Errorf("invalid amount %q", raw)
+		}
+		return models.MoneyFromFloat(v, currency), nil
+	}
+
+	s = strings.NewReplacer("$", "", " ", "", "\u00a0", "").Replace(s)
+	s = strings.TrimFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' })
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = true
+		s = s[:len(s)-1]
+	}
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	}
+
+	if p.DecimalSeparator == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	v, err := models.ParseMoney(s, currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		v = v.Neg()
+	}
+	return v, nil
+}
+
+// parseSpreadsheetDate parses a date cell; XLSX date cells arrive as serial day numbers
+func parseSpreadsheetDate(s, layout string, numericCells bool) (time.Time, error) {
+	if numericCells && plainNumber.MatchString(s) {
+		if serial, err := strconv.ParseFloat(s, 64); err == nil {
+			return excelize.ExcelDateToTime(serial, false)
+		}
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q (expected layout %s)", errInvalidDate, s, layout)
+	}
+	return t, nil
+}
+
+// readCSV reads a CSV file and selects its profile. A profile that sets a delimiter
+// is tried with that delimiter only; otherwise the delimiter is detected and the
+// profiles without one are matched.
+func (i *TabularImporter) readCSV(path string) ([][]string, int, map[string]int, *Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("failed to read CSV file %s: %v", path, err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	for _, p := range i.candidates(func(p *Profile) bool { return p.Delimiter != "" }) {
+		rows, err := parseCSV(data, []rune(p.Delimiter)[0])
+		if err != nil {
+			continue
+		}
+		if headerRow, header, profile := selectProfile(rows, []*Profile{p}); profile != nil {
+			return rows, headerRow, header, profile, nil
+		}
+	}
+
+	// Try the common delimiters, keeping the parse with the most columns
+	var best [][]string
+	bestWidth := 0
+	for _, delim := range []rune{',', ';', '\t', '|'} {
+		rows, err := parseCSV(data, delim)
+		if err != nil {
+			continue
+		}
+		if w := maxWidth(rows); w > bestWidth {
+			best, bestWidth = rows, w
+		}
+	}
+	if best == nil {
+		return nil, 0, nil, nil, fmt.Errorf("failed to parse CSV file %s", path)
+	}
+	headerRow, header, profile := selectProfile(best, i.candidates(func(p *Profile) bool { return p.Delimiter == "" }))
+	return best, headerRow, header, profile, nil
+}
+
+// parseCSV splits data into rows with the given delimiter, allowing rows of different widths
+func parseCSV(data []byte, delim rune) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+// readXLSX returns the raw cell values of the first worksheet
+func readXLSX(path string) ([][]string, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX file %s has no worksheets", path)
+	}
+	rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read worksheet %s of %s: %v", sheets[0], path, err)
+	}
+	return rows, nil
+}
+
+func maxWidth(rows [][]string) int {
+	w := 0
+	for i, row := range rows {
+		if i >= headerSearchRows {
+			break
+		}
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
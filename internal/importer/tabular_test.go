@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTabularProfileDelimiterIsNotSniffed(t *testing.T) {
+	// Commas in descriptions and amounts give more columns than the real delimiter
+	path := writeCSV(t, "Fecha;Descripción;Valor;Saldo\n"+
+		"03/06/2025;COMPRA EXITO, CALLE 80, BOGOTA;-125.000,50;874.999,50\n"+
+		"05/06/2025;NOMINA ACME, S.A.S.;3.000.000,00;3.874.999,50\n")
+	importer := NewTabularImporter([]Profile{{
+		Name:              "savings-semicolon",
+		Headers:           []string{"Fecha", "Descripción", "Valor"},
+		DateColumn:        "Fecha",
+		DateFormat:        "02/01/2006",
+		DescriptionColumn: "Descripción",
+		AmountColumn:      "Valor",
+		DecimalSeparator:  ",",
+		BalanceColumn:     "Saldo",
+		Delimiter:         ";",
+	}})
+
+	transactions, err := importer.Import(path, "export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	tx := transactions[0]
+	if tx.Description != "COMPRA EXITO, CALLE 80, BOGOTA" || tx.Amount != models.NewMoney(-12500050, "COP") ||
+		tx.Balance != models.NewMoney(87499950, "COP") || tx.Type != models.Debit {
+		t.Errorf("unexpected transaction %q %s balance %s %s", tx.Description, tx.Amount, tx.Balance, tx.Type)
+	}
+	if got := transactions[1].Amount; got != models.NewMoney(300000000, "COP") {
+		t.Errorf("second amount = %s, want 3000000.00 COP", got)
+	}
+}
+
+func TestTabularSniffsDelimiter(t *testing.T) {
+	path := writeCSV(t, "Account statement\n\n"+
+		"Date\tDescription\tDebit\tCredit\n"+
+		"2025-06-03\tGROCERY STORE\t45.10\t\n"+
+		"2025-06-05\tPAYROLL\t\t2,500.00\n"+
+		"\tTOTAL\t45.10\t2,500.00\n")
+	importer := NewTabularImporter([]Profile{{
+		Name:              "debit-credit",
+		Headers:           []string{"Date", "Description", "Debit", "Credit"},
+		DateColumn:        "Date",
+		DateFormat:        "2006-01-02",
+		DescriptionColumn: "Description",
+		DebitColumn:       "Debit",
+		CreditColumn:      "Credit",
+		Currency:          "USD",
+	}})
+
+	transactions, err := importer.Import(path, "export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Money{models.NewMoney(-4510, "USD"), models.NewMoney(250000, "USD")}
+	if len(transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(want))
+	}
+	for i, w := range want {
+		if transactions[i].Amount != w {
+			t.Errorf("transaction %d amount = %s, want %s", i, transactions[i].Amount, w)
+		}
+	}
+}
+
+func TestTabularSkipsRowsWithoutValidDate(t *testing.T) {
+	// Balance and total lines put text in the date column
+	path := writeCSV(t, "Fecha;Descripción;Valor\n"+
+		"Saldo anterior;;1.000.000,00\n"+
+		"03/06/2025;COMPRA EXITO;-125.000,50\n"+
+		"05/06/2025;NOMINA ACME;3.000.000,00\n"+
+		"Total;;2.874.999,50\n")
+	importer := NewTabularImporter([]Profile{{
+		Name:              "savings-semicolon",
+		Headers:           []string{"Fecha", "Descripción", "Valor"},
+		DateColumn:        "Fecha",
+		DateFormat:        "02/01/2006",
+		DescriptionColumn: "Descripción",
+		AmountColumn:      "Valor",
+		DecimalSeparator:  ",",
+	}})
+
+	transactions, err := importer.Import(path, "export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	if transactions[0].Description != "COMPRA EXITO" || transactions[1].Description != "NOMINA ACME" {
+		t.Errorf("got %q and %q", transactions[0].Description, transactions[1].Description)
+	}
+}
+
+func TestTabularFailsOnInvalidAmount(t *testing.T) {
+	path := writeCSV(t, "Date,Description,Amount\n2025-06-03,GROCERY STORE,lots\n")
+	importer := NewTabularImporter([]Profile{{
+		Name:              "simple",
+		Headers:           []string{"Date", "Description", "Amount"},
+		DateColumn:        "Date",
+		DateFormat:        "2006-01-02",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+	}})
+	if _, err := importer.Import(path, "export.csv"); err == nil {
+		t.Error("Import() accepted a row with an invalid amount")
+	}
+}
@@ -0,0 +1,262 @@
+// Package journal keeps an append-only log of the steps a run has completed, so
+// that a run that fails or is interrupted part-way can be resumed instead of
+// extracting and categorizing everything again.
+//
+// The journal is a JSON Lines file in the output folder with one record per step:
+// - start: a new run began
+// - resume: a run was resumed with -resume
+// - extract: one statement file was extracted, with its transactions (or the error)
+// - categorize: one categorization batch finished, with its categories (or the error)
+// - complete: the reports were written and the run finished
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// FileName is the name of the journal in the output folder
+const FileName = "run_journal.jsonl"
+
+// Steps recorded in the journal
+const (
+	StepStart      = "start"
+	StepResume     = "resume"
+	StepExtract    = "extract"
+	StepCategorize = "categorize"
+	StepComplete   = "complete"
+)
+
+// record is one line of the journal
+type record struct {
+	Step string    `json:"step"`
+	Time time.Time `json:"time"`
+
+	// Extraction of one statement file
+	File         string              `json:"file,omitempty"`
+	ContentHash  string              `json:"content_hash,omitempty"`
+	Statements   []statementRecord   `json:"statements,omitempty"`
+	Transactions []transactionRecord `json:"transactions,omitempty"`
+
+	// Categorization of one batch
+	Batch      int              `json:"batch,omitempty"`
+	Categories []categoryRecord `json:"categories,omitempty"`
+
+	// Error is set when the step failed; failed steps are retried on resume
+	Error string `json:"error,omitempty"`
+}
+
+// Journal records the completed steps of the current run
+type Journal struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+
+	// Results of the run being resumed: extracted files by name and categories by transaction key
+	extracted  map[string]record
+	categories map[string]categoryRecord
+
+	// keys identifies the transactions of this run for categorization records
+	keys map[*models.Transaction]string
+}
+
+// Open starts the journal in folder. Without resume, any previous journal is replaced
+// and a new run is recorded. With resume, the steps of the previous run are loaded
+// and new steps are appended to them; if the previous run completed (or there is
+// none), a new run is started instead and resumed reports false.
+func Open(folder string, resume bool) (j *Journal, resumed bool, err error) {
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create journal directory: %v", err)
+	}
+	j = &Journal{
+		path:       filepath.Join(folder, FileName),
+		extracted:  make(map[string]record),
+		categories: make(map[string]categoryRecord),
+		keys:       make(map[*models.Transaction]string),
+	}
+
+	if resume {
+		resumed, err = j.load()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	step := StepStart
+	if resumed {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		step = StepResume
+	}
+	j.file, err = os.OpenFile(j.path, flags, 0644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open run journal %s: %v", j.path, err)
+	}
+	if err := j.append(record{Step: step}); err != nil {
+		j.file.Close()
+		return nil, false, err
+	}
+	return j, resumed, nil
+}
+
+// load reads the previous run and reports whether it is unfinished
+func (j *Journal) load() (bool, error) {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open run journal %s: %v", j.path, err)
+	}
+	defer f.Close()
+
+	unfinished := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// A line cut short by a crash ends the journal; everything before it still counts
+			fmt.Printf("⚠️  Warning: Ignoring the unreadable end of the run journal (line %d): %v\n", line, err)
+			break
+		}
+		switch r.Step {
+		case StepStart, StepResume:
+			unfinished = true
+		case StepComplete:
+			unfinished = false
+		case StepExtract:
+			if r.Error == "" {
+				j.extracted[r.File] = r
+			} else {
+				delete(j.extracted, r.File)
+			}
+		case StepCategorize:
+			for _, c := range r.Categories {
+				j.categories[c.Key] = c
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read run journal %s: %v", j.path, err)
+	}
+
+	if !unfinished {
+		j.extracted = make(map[string]record)
+		j.categories = make(map[string]categoryRecord)
+	}
+	return unfinished, nil
+}
+
+// append writes r to the journal and flushes it to disk, so it survives a crash
+func (j *Journal) append(r record) error {
+	r.Time = time.Now().UTC()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %v", err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write run journal: %v", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync run journal: %v", err)
+	}
+	return nil
+}
+
+// Path returns the location of the journal file
+func (j *Journal) Path() string { return j.path }
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// Extracted returns the transactions the resumed run extracted from file, if it
+// extracted the file successfully and its content hash is unchanged
+func (j *Journal) Extracted(file, contentHash string) ([]*models.Transaction, bool) {
+	r, ok := j.extracted[file]
+	if !ok || r.ContentHash != contentHash {
+		return nil, false
+	}
+	return decodeTransactions(r), true
+}
+
+// RecordExtraction records the result of extracting one statement file
+func (j *Journal) RecordExtraction(file, contentHash string, transactions []*models.Transaction, extractErr error) error {
+	r := record{Step: StepExtract, File: file, ContentHash: contentHash}
+	if extractErr != nil {
+		r.Error = extractErr.Error()
+	} else {
+		r.Statements, r.Transactions = encodeTransactions(transactions)
+	}
+	return j.append(r)
+}
+
+// RestoreCategories identifies the transactions of this run for later RecordBatch
+// calls and gives the uncategorized ones the categories the resumed run recorded
+// for them. It returns how many categorizations were restored.
+func (j *Journal) RestoreCategories(transactions []*models.Transaction) int {
+	keys := transactionKeys(transactions)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	restored := 0
+	for i, t := range transactions {
+		j.keys[t] = keys[i]
+		c, ok := j.categories[keys[i]]
+		if !ok || t.Category != "" {
+			continue
+		}
+		t.Category, t.Subcategory, t.CategoryID, t.Confidence = c.Category, c.Subcategory, c.CategoryID, c.Confidence
+		t.Tags, t.CategorizedBy = c.Tags, c.By
+		restored++
+	}
+	return restored
+}
+
+// RecordBatch records the result of one categorization batch. Transactions must
+// have been passed to RestoreCategories first so they can be identified on resume.
+func (j *Journal) RecordBatch(batch int, transactions []*models.Transaction, batchErr error) error {
+	r := record{Step: StepCategorize, Batch: batch}
+	if batchErr != nil {
+		r.Error = batchErr.Error()
+	}
+
+	j.mu.Lock()
+	for _, t := range transactions {
+		key, ok := j.keys[t]
+		if !ok || t.Category == "" {
+			continue
+		}
+		r.Categories = append(r.Categories, categoryRecord{
+			Key:         key,
+			Category:    t.Category,
+			Subcategory: t.Subcategory,
+			CategoryID:  t.CategoryID,
+			Confidence:  t.Confidence,
+			Tags:        t.Tags,
+			By:          t.CategorizedBy,
+		})
+	}
+	j.mu.Unlock()
+
+	return j.append(r)
+}
+
+// Complete records that the run finished; a later -resume starts a new run
+func (j *Journal) Complete() error {
+	return j.append(record{Step: StepComplete})
+}
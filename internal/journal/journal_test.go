@@ -0,0 +1,172 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func statementTransactions() []*models.Transaction {
+	stmt := &models.Statement{
+		Source:         "card.pdf",
+		Account:        &models.Account{Kind: models.AccountCreditCard, Number: "7002", Currency: "COP"},
+		PeriodEnd:      time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+		ClosingBalance: models.NewMoney(15000000, "COP"),
+	}
+	transactions := []*models.Transaction{
+		{Date: time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC), Description: "EXITO COLINA", Amount: models.NewMoney(-12500050, "COP"), Type: models.Debit, Source: "card.pdf"},
+		{Date: time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), Description: "UBER TRIP", Amount: models.NewMoney(-1840000, "COP"), Type: models.Debit, Source: "card.pdf"},
+		// An identical second row must keep its own categorization
+		{Date: time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), Description: "UBER TRIP", Amount: models.NewMoney(-1840000, "COP"), Type: models.Debit, Source: "card.pdf"},
+	}
+	stmt.Link(transactions)
+	return transactions
+}
+
+func TestResumeRestoresExtractionsAndCategories(t *testing.T) {
+	dir := t.TempDir()
+	j, resumed, err := Open(dir, false)
+	if err != nil || resumed {
+		t.Fatalf("Open = %v, %v", resumed, err)
+	}
+	transactions := statementTransactions()
+	if err := j.RecordExtraction("card.pdf", "hash-1", transactions, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.RecordExtraction("savings.pdf", "hash-2", nil, errors.New("model timeout")); err != nil {
+		t.Fatal(err)
+	}
+	j.RestoreCategories(transactions)
+	transactions[0].Category, transactions[0].CategoryID, transactions[0].Confidence = "Food & Dining", "food.groceries", 0.9
+	transactions[2].Category, transactions[2].CategorizedBy = "Transportation", "rule:uber"
+	if err := j.RecordBatch(1, transactions, nil); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// The run stopped before Complete: resuming restores what it finished
+	j, resumed, err = Open(dir, true)
+	if err != nil || !resumed {
+		t.Fatalf("Open with resume = %v, %v", resumed, err)
+	}
+	defer j.Close()
+
+	restored, ok := j.Extracted("card.pdf", "hash-1")
+	if !ok || len(restored) != 3 {
+		t.Fatalf("Extracted card.pdf = %d transactions, %v", len(restored), ok)
+	}
+	if restored[0].Amount != transactions[0].Amount || restored[0].Statement == nil || restored[0].Statement != restored[2].Statement {
+		t.Errorf("restored %+v", restored[0])
+	}
+	if restored[0].Statement.Account.Number != "7002" || restored[0].Statement.ClosingBalance != models.NewMoney(15000000, "COP") {
+		t.Errorf("restored statement %+v", restored[0].Statement)
+	}
+	if _, ok := j.Extracted("savings.pdf", "hash-2"); ok {
+		t.Error("failed extraction was restored")
+	}
+	// A statement that changed since is extracted again
+	if _, ok := j.Extracted("card.pdf", "hash-3"); ok {
+		t.Error("extraction restored for another content hash")
+	}
+
+	if n := j.RestoreCategories(restored); n != 2 {
+		t.Errorf("restored %d categorizations, want 2", n)
+	}
+	if restored[0].CategoryID != "food.groceries" || restored[1].Category != "" || restored[2].CategorizedBy != "rule:uber" {
+		t.Errorf("restored categories %q %q %q", restored[0].CategoryID, restored[1].Category, restored[2].CategorizedBy)
+	}
+}
+
+func TestResumeIgnoresTruncatedLastLine(t *testing.T) {
+	dir := t.TempDir()
+	j, _, err := Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.RecordExtraction("card.pdf", "hash-1", statementTransactions(), nil); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// A crash while writing leaves half a record behind
+	f, err := os.OpenFile(j.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"step":"extract","file":"savings.pdf","content_hash":"ha`)
+	f.Close()
+
+	j, resumed, err := Open(dir, true)
+	if err != nil || !resumed {
+		t.Fatalf("Open with resume = %v, %v", resumed, err)
+	}
+	defer j.Close()
+	if _, ok := j.Extracted("card.pdf", "hash-1"); !ok {
+		t.Error("record before the truncated line was lost")
+	}
+}
+
+func TestResumeAfterCompleteStartsNewRun(t *testing.T) {
+	dir := t.TempDir()
+	j, _, err := Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.RecordExtraction("card.pdf", "hash-1", statementTransactions(), nil)
+	if err := j.Complete(); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	j, resumed, err := Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed {
+		t.Error("resumed a completed run")
+	}
+	if _, ok := j.Extracted("card.pdf", "hash-1"); ok {
+		t.Error("completed run's extraction was restored")
+	}
+	j.Close()
+
+	// The new run replaced the old journal, so a second resume finds nothing either
+	j, resumed, err = Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if !resumed {
+		t.Error("the new run, which did not complete, was not resumed")
+	}
+	if _, ok := j.Extracted("card.pdf", "hash-1"); ok {
+		t.Error("completed run's extraction was restored")
+	}
+}
+
+func TestOpenWithoutResumeReplacesJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, _, err := Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.RecordExtraction("card.pdf", "hash-1", statementTransactions(), nil)
+	j.Close()
+
+	j, _, err = Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	j, _, err = Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if _, ok := j.Extracted("card.pdf", "hash-1"); ok {
+		t.Error("extraction of a replaced journal was restored")
+	}
+}
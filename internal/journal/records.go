@@ -0,0 +1,226 @@
+package journal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// statementRecord is the journal form of a statement header
+type statementRecord struct {
+	Institution    string       `json:"institution,omitempty"`
+	AccountKind    string       `json:"account_kind,omitempty"`
+	AccountNumber  string       `json:"account_number,omitempty"`
+	AccountName    string       `json:"account_name,omitempty"`
+	Currency       string       `json:"currency,omitempty"`
+	HasAccount     bool         `json:"has_account"`
+	PeriodStart    string       `json:"period_start,omitempty"`
+	PeriodEnd      string       `json:"period_end,omitempty"`
+	DueDate        string       `json:"due_date,omitempty"`
+	OpeningBalance models.Money `json:"opening_balance"`
+	ClosingBalance models.Money `json:"closing_balance"`
+	MinimumPayment models.Money `json:"minimum_payment"`
+	TotalDebits    models.Money `json:"total_debits"`
+	TotalCredits   models.Money `json:"total_credits"`
+}
+
+// transactionRecord is the journal form of a transaction; Statement indexes the
+// statements of the same record and is -1 when the transaction has none
+type transactionRecord struct {
+	Date           string        `json:"date"`
+	ValueDate      string        `json:"value_date,omitempty"`
+	Description    string        `json:"description"`
+	Amount         models.Money  `json:"amount"`
+	OriginalAmount *models.Money `json:"original_amount,omitempty"`
+	Balance        *models.Money `json:"balance,omitempty"`
+	Type           string        `json:"type"`
+	Category       string        `json:"category,omitempty"`
+	Subcategory    string        `json:"subcategory,omitempty"`
+	CategoryID     string        `json:"category_id,omitempty"`
+	Confidence     float64       `json:"confidence,omitempty"`
+	Tags           []string      `json:"tags,omitempty"`
+	CategorizedBy  string        `json:"categorized_by,omitempty"`
+	RawText        string        `json:"raw_text,omitempty"`
+	Source         string        `json:"source"`
+	ExternalID     string        `json:"external_id,omitempty"`
+	Counterparty   string        `json:"counterparty,omitempty"`
+	RemittanceInfo string        `json:"remittance_info,omitempty"`
+	Statement      int           `json:"statement"`
+}
+
+// categoryRecord is the categorization of one transaction, identified by its key
+type categoryRecord struct {
+	Key         string   `json:"key"`
+	Category    string   `json:"category"`
+	Subcategory string   `json:"subcategory,omitempty"`
+	CategoryID  string   `json:"category_id,omitempty"`
+	Confidence  float64  `json:"confidence"`
+	Tags        []string `json:"tags,omitempty"`
+	By          string   `json:"categorized_by,omitempty"`
+}
+
+// encodeTransactions converts transactions to records, listing each statement header once
+func encodeTransactions(transactions []*models.Transaction) ([]statementRecord, []transactionRecord) {
+	var statements []statementRecord
+	index := make(map[*models.Statement]int)
+	records := make([]transactionRecord, len(transactions))
+
+	for i, t := range transactions {
+		stmt := -1
+		if t.Statement != nil {
+			n, ok := index[t.Statement]
+			if !ok {
+				n = len(statements)
+				index[t.Statement] = n
+				statements = append(statements, encodeStatement(t.Statement))
+			}
+			stmt = n
+		}
+		records[i] = transactionRecord{
+			Date:           formatDate(t.Date),
+			ValueDate:      formatDate(t.ValueDate),
+			Description:    t.Description,
+			Amount:         t.Amount,
+			OriginalAmount: optionalMoney(t.OriginalAmount),
+			Balance:        optionalMoney(t.Balance),
+			Type:           t.Type.String(),
+			Category:       t.Category,
+			Subcategory:    t.Subcategory,
+			CategoryID:     t.CategoryID,
+			Confidence:     t.Confidence,
+			Tags:           t.Tags,
+			CategorizedBy:  t.CategorizedBy,
+			RawText:        t.RawText,
+			Source:         t.Source,
+			ExternalID:     t.ExternalID,
+			Counterparty:   t.Counterparty,
+			RemittanceInfo: t.RemittanceInfo,
+			Statement:      stmt,
+		}
+	}
+	return statements, records
+}
+
+// decodeTransactions rebuilds the transactions of an extract record; transactions
+// of the same statement share one *models.Statement
+func decodeTransactions(r record) []*models.Transaction {
+	statements := make([]*models.Statement, len(r.Statements))
+	for i, s := range r.Statements {
+		statements[i] = decodeStatement(s, r.File)
+	}
+
+	transactions := make([]*models.Transaction, len(r.Transactions))
+	for i, tr := range r.Transactions {
+		t := &models.Transaction{
+			Date:           parseDate(tr.Date),
+			ValueDate:      parseDate(tr.ValueDate),
+			Description:    tr.Description,
+			Amount:         tr.Amount,
+			Type:           models.ParseTransactionType(tr.Type),
+			Category:       tr.Category,
+			Subcategory:    tr.Subcategory,
+			CategoryID:     tr.CategoryID,
+			Confidence:     tr.Confidence,
+			Tags:           tr.Tags,
+			CategorizedBy:  tr.CategorizedBy,
+			RawText:        tr.RawText,
+			Source:         tr.Source,
+			ExternalID:     tr.ExternalID,
+			Counterparty:   tr.Counterparty,
+			RemittanceInfo: tr.RemittanceInfo,
+		}
+		if tr.OriginalAmount != nil {
+			t.OriginalAmount = *tr.OriginalAmount
+		}
+		if tr.Balance != nil {
+			t.Balance = *tr.Balance
+		}
+		if tr.Statement >= 0 && tr.Statement < len(statements) {
+			t.Statement = statements[tr.Statement]
+		}
+		transactions[i] = t
+	}
+	return transactions
+}
+
+func encodeStatement(s *models.Statement) statementRecord {
+	r := statementRecord{
+		PeriodStart:    formatDate(s.PeriodStart),
+		PeriodEnd:      formatDate(s.PeriodEnd),
+		DueDate:        formatDate(s.DueDate),
+		OpeningBalance: s.OpeningBalance,
+		ClosingBalance: s.ClosingBalance,
+		MinimumPayment: s.MinimumPayment,
+		TotalDebits:    s.TotalDebits,
+		TotalCredits:   s.TotalCredits,
+	}
+	if a := s.Account; a != nil {
+		r.HasAccount = true
+		r.Institution, r.AccountKind, r.AccountNumber, r.AccountName, r.Currency =
+			a.Institution, a.Kind, a.Number, a.Name, a.Currency
+	}
+	return r
+}
+
+func decodeStatement(r statementRecord, source string) *models.Statement {
+	s := &models.Statement{
+		Source:         source,
+		PeriodStart:    parseDate(r.PeriodStart),
+		PeriodEnd:      parseDate(r.PeriodEnd),
+		DueDate:        parseDate(r.DueDate),
+		OpeningBalance: r.OpeningBalance,
+		ClosingBalance: r.ClosingBalance,
+		MinimumPayment: r.MinimumPayment,
+		TotalDebits:    r.TotalDebits,
+		TotalCredits:   r.TotalCredits,
+	}
+	if r.HasAccount {
+		s.Account = &models.Account{
+			Institution: r.Institution,
+			Kind:        r.AccountKind,
+			Number:      r.AccountNumber,
+			Name:        r.AccountName,
+			Currency:    r.Currency,
+		}
+	}
+	return s
+}
+
+// transactionKeys identifies transactions across runs by source, date, description
+// and amount (or the bank's id), numbering identical rows of the same source
+func transactionKeys(transactions []*models.Transaction) []string {
+	keys := make([]string, len(transactions))
+	occurrences := make(map[string]int)
+	for i, t := range transactions {
+		base := fmt.Sprintf("%s|%s|%s|%s", t.Source, formatDate(t.Date), t.Description, t.Amount.Decimal())
+		if t.ExternalID != "" {
+			base = t.Source + "|id|" + t.ExternalID
+		}
+		keys[i] = fmt.Sprintf("%s|%d", base, occurrences[base])
+		occurrences[base]++
+	}
+	return keys
+}
+
+// optionalMoney returns nil for an unset amount so it is left out of the record
+func optionalMoney(m models.Money) *models.Money {
+	if m.Minor == 0 && m.Currency == "" {
+		return nil
+	}
+	return &m
+}
+
+func formatDate(d time.Time) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+func parseDate(s string) time.Time {
+	d, _ := time.Parse(dateLayout, s)
+	return d
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAnthropicBaseURL is the public Anthropic API endpoint
+const DefaultAnthropicBaseURL = "https://api.anthropic.com"
+
+// anthropicVersion is the Messages API version sent with every request
+const anthropicVersion = "2023-06-01"
+
+// Anthropic calls the Anthropic Messages API
+type Anthropic struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	limiter *RateLimiter
+}
+
+// NewAnthropic creates an Anthropic provider; an empty baseURL uses DefaultAnthropicBaseURL.
+// A custom base URL points the provider at a proxy, gateway or the replay server.
+func NewAnthropic(baseURL, apiKey string, timeout time.Duration) *Anthropic {
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+	return &Anthropic{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  newHTTPClient(timeout),
+	}
+}
+
+// anthropicRequest is the Messages API request body
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Messages    []Message            `json:"messages"`
+	Temperature float64              `json:"temperature"`
+	Tools       []Tool               `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// anthropicToolChoice forces the model to call one tool
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// anthropicResponse is the Messages API response body
+type anthropicResponse struct {
+	Type       string             `json:"type"`
+	Role       string             `json:"role"`
+	Model      string             `json:"model"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      Usage              `json:"usage"`
+}
+
+// anthropicContent is one content block of a Messages API response
+type anthropicContent struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// SetRateLimiter makes every request wait for l (nil disables rate limiting)
+func (p *Anthropic) SetRateLimiter(l *RateLimiter) { p.limiter = l }
+
+// Name returns "anthropic"
+func (p *Anthropic) Name() string { return ProviderAnthropic }
+
+// Complete sends the request to the Messages API
+func (p *Anthropic) Complete(ctx context.Context, req Request) (*Response, error) {
+	body := anthropicRequest{
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		Tools:       req.Tools,
+	}
+	if req.ToolChoice != "" {
+		body.ToolChoice = &anthropicToolChoice{Type: "tool", Name: req.ToolChoice}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	tokens := EstimateTokens(req)
+	respBody, err := postJSON(ctx, p.client, p.limiter, "Claude", p.baseURL+"/v1/messages", data, tokens, map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	p.limiter.Settle(tokens, resp.Usage)
+	if len(resp.Content) == 0 {
+		return nil, fmt.Errorf("no content in API response")
+	}
+
+	var text strings.Builder
+	var calls []ToolCall
+	for _, c := range resp.Content {
+		switch c.Type {
+		case "text":
+			text.WriteString(c.Text)
+		case "tool_use":
+			calls = append(calls, ToolCall{ID: c.ID, Name: c.Name, Input: c.Input})
+		}
+	}
+	return &Response{
+		Text:       text.String(),
+		StopReason: resp.StopReason,
+		ToolCalls:  calls,
+		Usage:      resp.Usage,
+	}, nil
+}
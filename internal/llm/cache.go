@@ -0,0 +1,232 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache stores model responses on disk, keyed by a hash of the model, temperature
+// and prompt, so identical requests are only paid for once.
+//
+// Limits:
+// - Entries older than the TTL are treated as misses and removed
+// - When the cache grows beyond its size limit the least recently used entries are evicted
+type Cache struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	stats CacheStats
+}
+
+// CacheStats counts what the cache did during a run
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Writes    int
+	Expired   int
+	Evictions int
+
+	// SavedTokens counts the input and output tokens of the responses served from the cache
+	SavedTokens Usage
+
+	// Entries and Bytes describe the cache contents
+	Entries int
+	Bytes   int64
+}
+
+// cacheEntry is the file format of a cached response
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	Response  Response  `json:"response"`
+}
+
+// OpenCache opens (creating if needed) the cache in dir.
+// A zero ttl keeps entries forever and a zero maxBytes disables the size limit.
+func OpenCache(dir string, ttl time.Duration, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %v", dir, err)
+	}
+	c := &Cache{dir: dir, ttl: ttl, maxBytes: maxBytes}
+
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		c.size += f.size
+		c.stats.Entries++
+	}
+	return c, nil
+}
+
+// CacheKey returns the cache key of a request: a hash of its model, temperature and prompt
+func CacheKey(req Request) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%g\x00%s", req.Model, req.Temperature, req.Key())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached response to req, if there is a fresh one
+func (c *Cache) Get(req Request) (*Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(CacheKey(req))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// Unreadable entries are dropped and fetched again
+		c.remove(path, int64(len(data)))
+		c.stats.Misses++
+		return nil, false
+	}
+	if c.ttl > 0 && time.Since(entry.CreatedAt) > c.ttl {
+		c.remove(path, int64(len(data)))
+		c.stats.Expired++
+		c.stats.Misses++
+		return nil, false
+	}
+
+	// Touch the entry so size-based eviction removes the least recently used ones first
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	c.stats.Hits++
+	c.stats.SavedTokens.InputTokens += entry.Response.Usage.InputTokens
+	c.stats.SavedTokens.OutputTokens += entry.Response.Usage.OutputTokens
+	return &entry.Response, true
+}
+
+// Put stores the response to req and evicts old entries if the cache is over its size limit
+func (c *Cache) Put(req Request, resp *Response) error {
+	key := CacheKey(req)
+	data, err := json.Marshal(cacheEntry{Key: key, Model: req.Model, CreatedAt: time.Now(), Response: *resp})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		c.size -= info.Size()
+		c.stats.Entries--
+	}
+
+	// Write to a temporary file first so an interrupted run never leaves a partial entry
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	c.size += int64(len(data))
+	c.stats.Entries++
+	c.stats.Writes++
+
+	return c.evict()
+}
+
+// Delete removes the cached response to req, e.g. when the caller rejected it
+func (c *Cache) Delete(req Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(CacheKey(req))
+	if info, err := os.Stat(path); err == nil {
+		c.remove(path, info.Size())
+	}
+}
+
+// Stats returns the counters of this run and the current size of the cache
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Bytes = c.size
+	return stats
+}
+
+// cacheFile is an entry found on disk
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// files lists the cache entries on disk
+func (c *Cache) files() ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cache directory %s: %v", c.dir, err)
+	}
+	return files, nil
+}
+
+// evict removes the least recently used entries until the cache fits its size limit
+func (c *Cache) evict() error {
+	if c.maxBytes <= 0 || c.size <= c.maxBytes {
+		return nil
+	}
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if c.size <= c.maxBytes {
+			break
+		}
+		c.remove(f.path, f.size)
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+// remove deletes an entry and updates the size counters
+func (c *Cache) remove(path string, size int64) {
+	if err := os.Remove(path); err == nil {
+		c.size -= size
+		c.stats.Entries--
+	}
+}
+
+// path returns the file of a key, fanned out over subdirectories by its first two characters
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
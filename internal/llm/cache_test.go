@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func cacheRequest(prompt string) Request {
+	return Request{Model: "model-a", MaxTokens: 100, Temperature: 0.1, Messages: []Message{{Role: "user", Content: prompt}}}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := cacheRequest("extract")
+	otherModel, otherTemperature, otherPrompt := base, base, cacheRequest("categorize")
+	otherModel.Model = "model-b"
+	otherTemperature.Temperature = 0.2
+	otherMaxTokens := base
+	otherMaxTokens.MaxTokens = 200
+
+	for name, req := range map[string]Request{"model": otherModel, "temperature": otherTemperature, "prompt": otherPrompt} {
+		if CacheKey(req) == CacheKey(base) {
+			t.Errorf("requests with another %s share the cache key", name)
+		}
+	}
+	if CacheKey(otherMaxTokens) != CacheKey(base) {
+		t.Error("MaxTokens changed the cache key")
+	}
+}
+
+func TestCacheGetPut(t *testing.T) {
+	c, err := OpenCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := cacheRequest("extract")
+	if _, ok := c.Get(req); ok {
+		t.Fatal("empty cache returned a response")
+	}
+	if err := c.Put(req, &Response{Text: "cached", Usage: Usage{InputTokens: 10, OutputTokens: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, ok := c.Get(req)
+	if !ok || resp.Text != "cached" {
+		t.Fatalf("Get = %+v, %v", resp, ok)
+	}
+
+	c.Delete(req)
+	if _, ok := c.Get(req); ok {
+		t.Error("deleted entry was returned")
+	}
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Writes != 1 || stats.Entries != 0 || stats.SavedTokens.OutputTokens != 5 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	c, err := OpenCache(dir, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := cacheRequest("extract")
+	if err := c.Put(req, &Response{Text: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(req); !ok {
+		t.Fatal("fresh entry was not returned")
+	}
+
+	// The same directory opened with a shorter TTL finds the entry expired
+	c, err = OpenCache(dir, time.Nanosecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get(req); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if _, err := os.Stat(c.path(CacheKey(req))); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed: %v", err)
+	}
+	if stats := c.Stats(); stats.Expired != 1 || stats.Entries != 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	first, second, third := cacheRequest("first"), cacheRequest("second"), cacheRequest("third")
+
+	// Size the cache to hold two entries
+	c, err := OpenCache(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put(first, &Response{Text: "1"})
+	c.Put(second, &Response{Text: "2"})
+	entry := c.Stats().Bytes / 2
+	c, err = OpenCache(dir, 0, 2*entry+entry/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first is older, but was used more recently than second
+	hourAgo, twoHoursAgo := time.Now().Add(-time.Hour), time.Now().Add(-2*time.Hour)
+	os.Chtimes(c.path(CacheKey(first)), twoHoursAgo, twoHoursAgo)
+	os.Chtimes(c.path(CacheKey(second)), hourAgo, hourAgo)
+	if _, ok := c.Get(first); !ok {
+		t.Fatal("first entry missing")
+	}
+
+	if err := c.Put(third, &Response{Text: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	for req, want := range map[*Request]bool{&first: true, &second: false, &third: true} {
+		if _, ok := c.Get(*req); ok != want {
+			t.Errorf("entry %s cached = %v, want %v", req.Messages[0].Content, ok, want)
+		}
+	}
+	if stats := c.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("stats %+v", stats)
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxAttempts is how many times a request is sent before giving up on transient failures
+const maxAttempts = 3
+
+// postJSON sends body to url and returns the response body of a 200 reply.
+// Every attempt waits for the rate limiter, reserving the estimated tokens.
+// Timeouts, 429 and 5xx replies are retried with a linear backoff, or after the
+// delay of a retry-after header, which pauses every request sharing the limiter.
+func postJSON(ctx context.Context, client *http.Client, limiter *RateLimiter, name, url string, body []byte, tokens int, headers map[string]string) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := limiter.Wait(ctx, tokens); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && attempt < maxAttempts {
+				backoff := time.Duration(attempt*2) * time.Second
+				fmt.Printf("Transient timeout from %s API (attempt %d). Retrying in %s...\n", name, attempt, backoff)
+				if err := sleep(ctx, backoff); err != nil {
+					return nil, err
+				}
+				lastErr = err
+				continue
+			}
+			return nil, fmt.Errorf("failed to make API request: %v", err)
+		}
+
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read response body: %v", readErr)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return respBody, nil
+		}
+
+		// Retry on 429/5xx
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) && attempt < maxAttempts {
+			backoff := time.Duration(attempt*2) * time.Second
+			if d, ok := retryAfter(resp.Header); ok {
+				backoff = d
+			}
+			fmt.Printf("%s API returned status %d (attempt %d). Retrying in %s...\n", name, resp.StatusCode, attempt, backoff)
+			if limiter != nil {
+				limiter.Pause(backoff)
+			} else if err := sleep(ctx, backoff); err != nil {
+				return nil, err
+			}
+			lastErr = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+			continue
+		}
+
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("unknown error during %s API call", name)
+	}
+	return nil, lastErr
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newHTTPClient returns a client with the given timeout, or 120s when unset
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Provider sends a prompt to a language model and returns its reply.
+// Implementations handle transport, authentication and retries of transient failures.
+type Provider interface {
+	// Name identifies the provider in messages, e.g. "anthropic"
+	Name() string
+
+	// Complete sends the request and returns the model's reply.
+	// Cancelling ctx aborts the request, including any rate limit wait or retry backoff.
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// Message is one turn of the conversation sent to the model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a provider-independent completion request
+type Request struct {
+	Model       string    `json:"model"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature"`
+	Messages    []Message `json:"messages"`
+
+	// Tools the model may call; ToolChoice forces a call to the named tool
+	Tools      []Tool `json:"tools,omitempty"`
+	ToolChoice string `json:"tool_choice,omitempty"`
+}
+
+// Response is a provider-independent completion reply
+type Response struct {
+	// Text is the concatenated text output of the model
+	Text string `json:"text"`
+
+	// StopReason is why the model stopped, as reported by the provider (e.g. "end_turn", "stop")
+	StopReason string `json:"stop_reason,omitempty"`
+
+	// ToolCalls holds the tool invocations of the reply
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	Usage Usage `json:"usage"`
+}
+
+// Usage counts the tokens billed for one request
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Key identifies the prompt of a request: a hash of its messages and tools.
+// Recordings are matched by Key, so they replay regardless of the configured model.
+func (r Request) Key() string {
+	h := sha256.New()
+	for _, m := range r.Messages {
+		fmt.Fprintf(h, "%s\x00%s\x00", m.Role, m.Content)
+	}
+	for _, t := range r.Tools {
+		schema, _ := json.Marshal(t.InputSchema)
+		fmt.Fprintf(h, "tool\x00%s\x00%s\x00", t.Name, schema)
+	}
+	if r.ToolChoice != "" {
+		fmt.Fprintf(h, "tool_choice\x00%s\x00", r.ToolChoice)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Provider names accepted by New
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai"
+	ProviderReplay    = "replay"
+)
+
+// Config selects and configures a provider
+type Config struct {
+	// Provider is one of ProviderAnthropic (default), ProviderOpenAI or ProviderReplay
+	Provider string
+
+	// BaseURL overrides the provider's default endpoint
+	BaseURL string
+
+	// APIKey authenticates against the provider; optional for local OpenAI-compatible servers
+	APIKey string
+
+	// Timeout bounds each HTTP request
+	Timeout time.Duration
+
+	// ReplayDir holds the recordings served by the replay provider
+	ReplayDir string
+
+	// RecordDir, when set, saves every response to this directory for later replay
+	RecordDir string
+
+	// RequestsPerMinute and TokensPerMinute rate-limit the anthropic and openai providers (0 = unlimited)
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// New creates the provider described by cfg.
+// "anthropic" calls the Messages API, "openai" any OpenAI-compatible chat completions
+// server (llama.cpp, Ollama, vLLM...), and "replay" an in-process server that answers
+// with recorded responses.
+func New(cfg Config) (Provider, error) {
+	var (
+		p   Provider
+		err error
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "", ProviderAnthropic:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("an API key is required for the %s provider", ProviderAnthropic)
+		}
+		anthropic := NewAnthropic(cfg.BaseURL, cfg.APIKey, cfg.Timeout)
+		anthropic.SetRateLimiter(NewRateLimiter(cfg.RequestsPerMinute, cfg.TokensPerMinute))
+		p = anthropic
+	case ProviderOpenAI:
+		openai := NewOpenAI(cfg.BaseURL, cfg.APIKey, cfg.Timeout)
+		openai.SetRateLimiter(NewRateLimiter(cfg.RequestsPerMinute, cfg.TokensPerMinute))
+		p = openai
+	case ProviderReplay:
+		p, err = NewReplay(cfg.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (expected anthropic, openai or replay)", cfg.Provider)
+	}
+
+	if cfg.RecordDir != "" {
+		p = NewRecorder(p, cfg.RecordDir)
+	}
+	return p, nil
+}
@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIBaseURL is the OpenAI-compatible endpoint of a local Ollama server.
+// llama.cpp's server listens on http://localhost:8080/v1 by default.
+const DefaultOpenAIBaseURL = "http://localhost:11434/v1"
+
+// OpenAI calls an OpenAI-compatible chat completions API, as served by
+// llama.cpp, Ollama, vLLM and hosted OpenAI-compatible gateways
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	limiter *RateLimiter
+}
+
+// NewOpenAI creates an OpenAI-compatible provider; an empty baseURL uses DefaultOpenAIBaseURL.
+// The API key is optional since local servers usually do not check it.
+func NewOpenAI(baseURL, apiKey string, timeout time.Duration) *OpenAI {
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  newHTTPClient(timeout),
+	}
+}
+
+// openAIRequest is the chat completions request body
+type openAIRequest struct {
+	Model       string            `json:"model"`
+	Messages    []Message         `json:"messages"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+	Temperature float64           `json:"temperature"`
+	Stream      bool              `json:"stream"`
+	Tools       []openAITool      `json:"tools,omitempty"`
+	ToolChoice  *openAIToolChoice `json:"tool_choice,omitempty"`
+}
+
+// openAITool declares a function the model may call
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+// openAIFunction describes a callable function and its parameters schema
+type openAIFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters"`
+}
+
+// openAIToolChoice forces the model to call one function
+type openAIToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// openAIToolCall is a function call in a chat completions reply.
+// Arguments hold the JSON input encoded as a string.
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIResponse is the chat completions response body
+type openAIResponse struct {
+	Object  string         `json:"object"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   openAIUsage    `json:"usage"`
+}
+
+// openAIChoice is one generated reply of a chat completions response
+type openAIChoice struct {
+	Index        int           `json:"index"`
+	Message      openAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+// openAIMessage is the assistant message of a chat completions reply
+type openAIMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls,omitempty"`
+}
+
+// openAIUsage counts the tokens of a chat completions request
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// SetRateLimiter makes every request wait for l (nil disables rate limiting)
+func (p *OpenAI) SetRateLimiter(l *RateLimiter) { p.limiter = l }
+
+// Name returns "openai"
+func (p *OpenAI) Name() string { return ProviderOpenAI }
+
+// Complete sends the request to the chat completions endpoint
+func (p *OpenAI) Complete(ctx context.Context, req Request) (*Response, error) {
+	body := openAIRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	for _, t := range req.Tools {
+		body.Tools = append(body.Tools, openAITool{
+			Type:     "function",
+			Function: openAIFunction{Name: t.Name, Description: t.Description, Parameters: t.InputSchema},
+		})
+	}
+	if req.ToolChoice != "" {
+		body.ToolChoice = &openAIToolChoice{Type: "function"}
+		body.ToolChoice.Function.Name = req.ToolChoice
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	tokens := EstimateTokens(req)
+	respBody, err := postJSON(ctx, p.client, p.limiter, "OpenAI-compatible", p.baseURL+"/chat/completions", data, tokens, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp openAIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	p.limiter.Settle(tokens, Usage{InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens})
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in API response")
+	}
+	choice := resp.Choices[0]
+	result := &Response{
+		Text:       choice.Message.Content,
+		StopReason: choice.FinishReason,
+		Usage:      Usage{InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens},
+	}
+	for _, call := range choice.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by every request to a provider.
+// It enforces a requests-per-minute and a tokens-per-minute limit, and pauses
+// all requests when the API answers with a retry-after header.
+//
+// Tokens:
+// - A request reserves its estimated prompt tokens before it is sent
+// - Once the reply arrives the reservation is settled with the actual input and output tokens
+// - The token balance may go negative, which delays later requests until it refills
+type RateLimiter struct {
+	rpm float64
+	tpm float64
+
+	mu          sync.Mutex
+	requests    float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter creates a limiter; a zero limit is not enforced.
+// It returns nil when neither limit is set, and a nil limiter never waits.
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rpm:      float64(requestsPerMinute),
+		tpm:      float64(tokensPerMinute),
+		requests: float64(requestsPerMinute),
+		tokens:   float64(tokensPerMinute),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a request estimated at the given tokens may be sent, and reserves it.
+// A request larger than the whole per-minute budget waits for a full bucket.
+// It returns early with the context's error when ctx is cancelled.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	for {
+		delay := l.reserve(float64(tokens))
+		if delay <= 0 {
+			return ctx.Err()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes the request from the buckets, or returns how long to wait before trying again
+func (l *RateLimiter) reserve(tokens float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	l.refill(now)
+
+	var delay time.Duration
+	if l.rpm > 0 && l.requests < 1 {
+		delay = perMinute(1-l.requests, l.rpm)
+	}
+	if l.tpm > 0 {
+		need := tokens
+		if need > l.tpm {
+			need = l.tpm
+		}
+		if l.tokens < need {
+			if d := perMinute(need-l.tokens, l.tpm); d > delay {
+				delay = d
+			}
+		}
+	}
+	if delay > 0 {
+		return delay
+	}
+
+	if l.rpm > 0 {
+		l.requests--
+	}
+	if l.tpm > 0 {
+		l.tokens -= tokens
+	}
+	return 0
+}
+
+// Settle corrects a reservation with the tokens the request actually used
+func (l *RateLimiter) Settle(reserved int, u Usage) {
+	if l == nil || l.tpm <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens -= float64(u.InputTokens + u.OutputTokens - reserved)
+}
+
+// Pause holds every request for d, e.g. after a 429 with a retry-after header
+func (l *RateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.mu.Unlock()
+}
+
+// refill adds the requests and tokens accrued since the last refill, up to one minute's worth
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if l.rpm > 0 {
+		l.requests = min(l.rpm, l.requests+elapsed*l.rpm)
+	}
+	if l.tpm > 0 {
+		l.tokens = min(l.tpm, l.tokens+elapsed*l.tpm)
+	}
+}
+
+// perMinute returns how long a bucket refilling at rate per minute takes to gain amount
+func perMinute(amount, rate float64) time.Duration {
+	return time.Duration(amount / rate * float64(time.Minute))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// EstimateTokens is a conservative estimate of the prompt tokens of a request
+// (about three characters per token, which over-counts typical statement text)
+func EstimateTokens(req Request) int {
+	n := 0
+	for _, m := range req.Messages {
+		n += len(m.Content)
+	}
+	for _, tool := range req.Tools {
+		schema, _ := json.Marshal(tool.InputSchema)
+		n += len(tool.Name) + len(tool.Description) + len(schema)
+	}
+	return n/3 + 1
+}
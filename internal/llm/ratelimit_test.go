@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// near reports whether d is within a second of want
+func near(d, want time.Duration) bool {
+	return d > want-time.Second && d <= want+time.Second
+}
+
+// elapse moves the limiter's clock back, as if d had passed since its last refill
+func elapse(l *RateLimiter, d time.Duration) {
+	l.mu.Lock()
+	l.last = l.last.Add(-d)
+	l.mu.Unlock()
+}
+
+func TestRateLimiterRequestsPerMinute(t *testing.T) {
+	l := NewRateLimiter(2, 0)
+	for i := 0; i < 2; i++ {
+		if d := l.reserve(1000); d != 0 {
+			t.Fatalf("request %d waited %v", i, d)
+		}
+	}
+	if d := l.reserve(1000); !near(d, 30*time.Second) {
+		t.Errorf("third request waits %v, want 30s", d)
+	}
+	elapse(l, 30*time.Second)
+	if d := l.reserve(1000); d != 0 {
+		t.Errorf("after 30s the request waits %v, want 0", d)
+	}
+}
+
+func TestRateLimiterTokensPerMinute(t *testing.T) {
+	l := NewRateLimiter(0, 1000)
+	if d := l.reserve(600); d != 0 {
+		t.Fatalf("first request waited %v", d)
+	}
+	// 400 tokens left; 200 more take 12s to refill
+	if d := l.reserve(600); !near(d, 12*time.Second) {
+		t.Errorf("second request waits %v, want 12s", d)
+	}
+	elapse(l, 12*time.Second)
+	if d := l.reserve(600); d != 0 {
+		t.Errorf("after 12s the request waits %v, want 0", d)
+	}
+}
+
+func TestRateLimiterOversizeRequest(t *testing.T) {
+	l := NewRateLimiter(0, 1000)
+	// A request over the per-minute budget goes out on a full bucket instead of waiting forever
+	if d := l.reserve(5000); d != 0 {
+		t.Fatalf("oversize request on a full bucket waited %v", d)
+	}
+	// and the debt delays the next one until the bucket has refilled
+	if d := l.reserve(100); !near(d, 246*time.Second) {
+		t.Errorf("next request waits %v, want 4m6s", d)
+	}
+}
+
+func TestRateLimiterSettle(t *testing.T) {
+	l := NewRateLimiter(0, 1000)
+	l.reserve(600)
+	// The request used 400 tokens instead of the 600 reserved
+	l.Settle(600, Usage{InputTokens: 300, OutputTokens: 100})
+	if d := l.reserve(600); d != 0 {
+		t.Errorf("request after settling waits %v, want 0", d)
+	}
+
+	// A request that used more than reserved delays the next one
+	l = NewRateLimiter(0, 1000)
+	l.reserve(100)
+	l.Settle(100, Usage{InputTokens: 700, OutputTokens: 300})
+	if d := l.reserve(100); !near(d, 6*time.Second) {
+		t.Errorf("request after an overrun waits %v, want 6s", d)
+	}
+}
+
+func TestRateLimiterPauseFromRetryAfter(t *testing.T) {
+	h := http.Header{}
+	h.Set("Retry-After", "20")
+	d, ok := retryAfter(h)
+	if !ok || d != 20*time.Second {
+		t.Fatalf("retryAfter = %v, %v, want 20s", d, ok)
+	}
+
+	l := NewRateLimiter(60, 0)
+	l.Pause(d)
+	if wait := l.reserve(1); !near(wait, 20*time.Second) {
+		t.Errorf("paused request waits %v, want 20s", wait)
+	}
+	// A shorter pause does not shorten the current one
+	l.Pause(time.Second)
+	if wait := l.reserve(1); !near(wait, 20*time.Second) {
+		t.Errorf("paused request waits %v after a shorter pause, want 20s", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"1.5", 1500 * time.Millisecond, true},
+		{"0", 0, true},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+		{"-3", 0, false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Retry-After", tt.value)
+		if d, ok := retryAfter(h); d != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, d, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	var unlimited *RateLimiter
+	if err := unlimited.Wait(context.Background(), 1000); err != nil {
+		t.Errorf("nil limiter: %v", err)
+	}
+	if NewRateLimiter(0, 0) != nil {
+		t.Error("limiter without limits is not nil")
+	}
+
+	l := NewRateLimiter(1, 0)
+	if err := l.Wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait on an empty bucket = %v, want the context's error", err)
+	}
+}
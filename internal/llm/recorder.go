@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Recording is a saved request and the response the model gave to it.
+// Recordings are stored as <Key>.json and served back by the replay provider.
+type Recording struct {
+	Key      string   `json:"key"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Recorder wraps a provider and saves every successful response to a directory
+type Recorder struct {
+	provider Provider
+	dir      string
+}
+
+// NewRecorder creates a recorder that saves the responses of p to dir
+func NewRecorder(p Provider, dir string) *Recorder {
+	return &Recorder{provider: p, dir: dir}
+}
+
+// Name returns the name of the wrapped provider
+func (r *Recorder) Name() string { return r.provider.Name() }
+
+// Complete forwards the request and records the response.
+// Failing to write a recording is reported but does not fail the request.
+func (r *Recorder) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := r.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveRecording(r.dir, Recording{Key: req.Key(), Request: req, Response: *resp}); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
+	return resp, nil
+}
+
+// SaveRecording writes rec to dir as <key>.json
+func SaveRecording(dir string, rec Recording) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create recordings directory %s: %v", dir, err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %v", err)
+	}
+	path := filepath.Join(dir, rec.Key+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recording %s: %v", path, err)
+	}
+	return nil
+}
+
+// LoadRecordings reads every *.json recording in dir, keyed by prompt key.
+// A recording saved as default.json answers prompts that have no recording of their own.
+func LoadRecordings(dir string) (map[string]Response, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings in %s: %v", dir, err)
+	}
+
+	recordings := make(map[string]Response, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording %s: %v", path, err)
+		}
+		var rec Recording
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %v", path, err)
+		}
+
+		key := rec.Key
+		if filepath.Base(path) == defaultRecording {
+			key = defaultRecordingKey
+		} else if key == "" {
+			key = rec.Request.Key()
+		}
+		recordings[key] = rec.Response
+	}
+	return recordings, nil
+}
@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// defaultRecording is the file name of the recording used for unknown prompts
+const defaultRecording = "default.json"
+
+// defaultRecordingKey is the key LoadRecordings stores the default recording under
+const defaultRecordingKey = "default"
+
+// ReplayServer is an in-process HTTP server that answers Anthropic Messages API and
+// OpenAI-compatible chat completions requests with recorded responses, so the whole
+// pipeline can run without network access or API keys (e.g. in CI)
+type ReplayServer struct {
+	server     *http.Server
+	url        string
+	recordings map[string]Response
+
+	mu     sync.Mutex
+	served int
+	missed []string
+}
+
+// NewReplayServer loads the recordings in dir and starts serving them on a local port
+func NewReplayServer(dir string) (*ReplayServer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("a recordings directory is required for the %s provider", ProviderReplay)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("recordings directory %s: %v", dir, err)
+	}
+	recordings, err := LoadRecordings(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Any free port on the loopback interface; the server is never reachable from outside
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the replay server: %v", err)
+	}
+
+	s := &ReplayServer{recordings: recordings, url: "http://" + listener.Addr().String()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages", s.handleMessages)
+	mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	s.server = &http.Server{Handler: mux}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// URL returns the base URL of the server, usable as the base URL of either provider
+// (append "/v1" for the OpenAI-compatible one)
+func (s *ReplayServer) URL() string { return s.url }
+
+// Close shuts the server down
+func (s *ReplayServer) Close() { s.server.Close() }
+
+// Stats returns how many requests were answered and the keys of prompts that had no recording
+func (s *ReplayServer) Stats() (served int, missed []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.served, append([]string(nil), s.missed...)
+}
+
+// lookup returns the recorded response for the prompt of req
+func (s *ReplayServer) lookup(req Request) (Response, bool) {
+	key := req.Key()
+	resp, ok := s.recordings[key]
+	if !ok {
+		resp, ok = s.recordings[defaultRecordingKey]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok {
+		s.served++
+	} else {
+		s.missed = append(s.missed, key)
+	}
+	return resp, ok
+}
+
+// handleMessages answers Anthropic Messages API requests
+func (s *ReplayServer) handleMessages(w http.ResponseWriter, r *http.Request) {
+	var body anthropicRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeReplayError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	req := Request{Model: body.Model, MaxTokens: body.MaxTokens, Temperature: body.Temperature, Messages: body.Messages, Tools: body.Tools}
+	if body.ToolChoice != nil {
+		req.ToolChoice = body.ToolChoice.Name
+	}
+	resp, ok := s.lookup(req)
+	if !ok {
+		writeReplayError(w, http.StatusNotFound, "not_found_error", "no recording for prompt "+req.Key())
+		return
+	}
+
+	var content []anthropicContent
+	if resp.Text != "" || len(resp.ToolCalls) == 0 {
+		content = append(content, anthropicContent{Type: "text", Text: resp.Text})
+	}
+	for i, call := range resp.ToolCalls {
+		content = append(content, anthropicContent{Type: "tool_use", ID: replayCallID(call, i), Name: call.Name, Input: call.Input})
+	}
+
+	stopReason := resp.StopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+		if len(resp.ToolCalls) > 0 {
+			stopReason = "tool_use"
+		}
+	}
+	writeReplayJSON(w, anthropicResponse{
+		Type:       "message",
+		Role:       "assistant",
+		Model:      body.Model,
+		Content:    content,
+		StopReason: stopReason,
+		Usage:      resp.Usage,
+	})
+}
+
+// handleChatCompletions answers OpenAI-compatible chat completions requests
+func (s *ReplayServer) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var body openAIRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeReplayError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	req := Request{Model: body.Model, MaxTokens: body.MaxTokens, Temperature: body.Temperature, Messages: body.Messages}
+	for _, t := range body.Tools {
+		req.Tools = append(req.Tools, Tool{Name: t.Function.Name, Description: t.Function.Description, InputSchema: t.Function.Parameters})
+	}
+	if body.ToolChoice != nil {
+		req.ToolChoice = body.ToolChoice.Function.Name
+	}
+	resp, ok := s.lookup(req)
+	if !ok {
+		writeReplayError(w, http.StatusNotFound, "not_found_error", "no recording for prompt "+req.Key())
+		return
+	}
+
+	message := openAIMessage{Role: "assistant", Content: resp.Text}
+	for i, call := range resp.ToolCalls {
+		tc := openAIToolCall{ID: replayCallID(call, i), Type: "function"}
+		tc.Function.Name = call.Name
+		tc.Function.Arguments = string(call.Input)
+		message.ToolCalls = append(message.ToolCalls, tc)
+	}
+
+	finishReason := resp.StopReason
+	if finishReason == "" {
+		finishReason = "stop"
+		if len(resp.ToolCalls) > 0 {
+			finishReason = "tool_calls"
+		}
+	}
+	writeReplayJSON(w, openAIResponse{
+		Object:  "chat.completion",
+		Model:   body.Model,
+		Choices: []openAIChoice{{Message: message, FinishReason: finishReason}},
+		Usage: openAIUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	})
+}
+
+// replayCallID returns the recorded id of a tool call, or a generated one
+func replayCallID(call ToolCall, i int) string {
+	if call.ID != "" {
+		return call.ID
+	}
+	return fmt.Sprintf("call_replay_%d", i)
+}
+
+// writeReplayJSON writes v as a 200 JSON reply
+func writeReplayJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeReplayError writes an API error in the shape both providers report
+func writeReplayError(w http.ResponseWriter, status int, kind, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": kind, "message": message},
+	})
+}
+
+// Replay is the provider backed by a ReplayServer. Requests travel over HTTP
+// through the Anthropic provider, so the full client path is exercised.
+type Replay struct {
+	*Anthropic
+	server *ReplayServer
+}
+
+// NewReplay starts a replay server for the recordings in dir
+func NewReplay(dir string) (*Replay, error) {
+	server, err := NewReplayServer(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Replay{
+		Anthropic: NewAnthropic(server.URL(), "replay", 0),
+		server:    server,
+	}, nil
+}
+
+// Name returns "replay"
+func (p *Replay) Name() string { return ProviderReplay }
+
+// Server returns the underlying replay server
+func (p *Replay) Server() *ReplayServer { return p.server }
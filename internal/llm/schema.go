@@ -0,0 +1,209 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema used to describe tool inputs.
+// It is sent to the provider as the tool's input schema and used to validate
+// what the model returns.
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+}
+
+// Float returns a pointer to f, for Schema.Minimum and Schema.Maximum
+func Float(f float64) *float64 { return &f }
+
+// ValidationError lists every place where a value does not match its schema
+type ValidationError struct {
+	Problems []string
+}
+
+// Error reports all problems, one per line
+func (e *ValidationError) Error() string {
+	return "output does not match the schema:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks data against the schema and returns a *ValidationError
+// describing every missing field and type mismatch
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Problems: []string{fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	var problems []string
+	s.validate(v, "$", &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validate appends the problems of v at path to problems
+func (s *Schema) validate(v interface{}, path string, problems *[]string) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			report("expected object, got %s", describe(v))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				report("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if value, ok := obj[name]; ok {
+				s.Properties[name].validate(value, path+"."+name, problems)
+			}
+		}
+
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			report("expected array, got %s", describe(v))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report("expected string, got %s", describe(v))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			report("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+				report("%q does not match %s", str, s.Pattern)
+			}
+		}
+
+	case "number", "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			report("expected %s, got %s", s.Type, describe(v))
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			report("invalid number %s", n)
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				report("expected integer, got %s", n)
+				return
+			}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			report("%s is below the minimum %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			report("%s is above the maximum %v", n, *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report("expected boolean, got %s", describe(v))
+		}
+	}
+}
+
+// Empty returns the smallest value that satisfies the schema: required fields only,
+// empty arrays, zero numbers and the first enum value. Dry runs use it as mock tool output.
+func (s *Schema) Empty() json.RawMessage {
+	data, _ := json.Marshal(s.empty())
+	return data
+}
+
+func (s *Schema) empty() interface{} {
+	switch s.Type {
+	case "object":
+		obj := map[string]interface{}{}
+		for _, name := range s.Required {
+			if p, ok := s.Properties[name]; ok {
+				obj[name] = p.empty()
+			}
+		}
+		return obj
+	case "array":
+		return []interface{}{}
+	case "string":
+		if len(s.Enum) > 0 {
+			return s.Enum[0]
+		}
+		return ""
+	case "number", "integer":
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// describe names the JSON type of v, with the value for scalars
+func describe(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		if len(x) > 40 {
+			x = x[:40] + "..."
+		}
+		return fmt.Sprintf("string %q", x)
+	case json.Number:
+		return "number " + x.String()
+	case bool:
+		return fmt.Sprintf("boolean %v", x)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
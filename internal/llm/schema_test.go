@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSchema = &Schema{
+	Type:     "object",
+	Required: []string{"items"},
+	Properties: map[string]*Schema{
+		"items": {
+			Type: "array",
+			Items: &Schema{
+				Type:     "object",
+				Required: []string{"date", "index", "type"},
+				Properties: map[string]*Schema{
+					"date":       {Type: "string", Pattern: `^\d{4}-\d{2}-\d{2}$`},
+					"index":      {Type: "integer", Minimum: Float(0)},
+					"type":       {Type: "string", Enum: []string{"debit", "credit"}},
+					"confidence": {Type: "number", Minimum: Float(0), Maximum: Float(1)},
+					"recurring":  {Type: "boolean"},
+				},
+			},
+		},
+	},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		problems []string
+	}{
+		{
+			name: "valid",
+			data: `{"items": [{"date": "2025-06-03", "index": 0, "type": "debit", "confidence": 0.9, "recurring": false}]}`,
+		},
+		{
+			name:     "missing required fields",
+			data:     `{"items": [{"date": "2025-06-03"}]}`,
+			problems: []string{`$.items[0]: missing required field "index"`, `$.items[0]: missing required field "type"`},
+		},
+		{
+			name:     "missing top-level field",
+			data:     `{}`,
+			problems: []string{`$: missing required field "items"`},
+		},
+		{
+			name: "wrong types",
+			data: `{"items": [{"date": 20250603, "index": "0", "type": null, "recurring": "yes"}]}`,
+			problems: []string{
+				`$.items[0].date: expected string, got number 20250603`,
+				`$.items[0].index: expected integer, got string "0"`,
+				`$.items[0].recurring: expected boolean, got string "yes"`,
+				`$.items[0].type: expected string, got null`,
+			},
+		},
+		{
+			name:     "array expected",
+			data:     `{"items": {"date": "2025-06-03"}}`,
+			problems: []string{`$.items: expected array, got object`},
+		},
+		{
+			name:     "enum and pattern",
+			data:     `{"items": [{"date": "03/06/2025", "index": 1, "type": "withdrawal"}]}`,
+			problems: []string{`$.items[0].date: "03/06/2025" does not match ^\d{4}-\d{2}-\d{2}$`, `$.items[0].type: "withdrawal" is not one of debit, credit`},
+		},
+		{
+			name:     "minimum and maximum",
+			data:     `{"items": [{"date": "2025-06-03", "index": -1, "type": "debit", "confidence": 1.5}]}`,
+			problems: []string{`$.items[0].confidence: 1.5 is above the maximum 1`, `$.items[0].index: -1 is below the minimum 0`},
+		},
+		{
+			name:     "integer with a fraction",
+			data:     `{"items": [{"date": "2025-06-03", "index": 2.5, "type": "debit"}]}`,
+			problems: []string{`$.items[0].index: expected integer, got 2.5`},
+		},
+		{
+			name:     "invalid JSON",
+			data:     `{"items": [`,
+			problems: []string{`invalid JSON: unexpected EOF`},
+		},
+	}
+	for _, tt := range tests {
+		err := testSchema.Validate([]byte(tt.data))
+		if tt.problems == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: err = %v, want a *ValidationError", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(verr.Problems, tt.problems) {
+			t.Errorf("%s: problems\n%q\nwant\n%q", tt.name, verr.Problems, tt.problems)
+		}
+	}
+}
+
+func TestSchemaEmptyIsValid(t *testing.T) {
+	empty := testSchema.Empty()
+	if string(empty) != `{"items":[]}` {
+		t.Errorf("Empty() = %s", empty)
+	}
+	if err := testSchema.Validate(empty); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tool is a function the model is asked to call with structured input.
+// Forcing a tool call (Request.ToolChoice) makes the model return JSON that
+// follows InputSchema instead of free text.
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	InputSchema *Schema `json:"input_schema"`
+}
+
+// ToolCall is a tool invocation returned by the model
+type ToolCall struct {
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// Decode finds the model's call to the tool in resp, validates its input against
+// the tool's schema and decodes it into v. Numbers are decoded as json.Number when
+// v uses that type, so amounts keep their exact decimal text.
+//
+// Servers that do not support tools may answer with the JSON object as plain text;
+// such a reply is accepted only when the whole text is a single JSON object.
+func (t Tool) Decode(resp *Response, v interface{}) error {
+	input, err := t.input(resp)
+	if err != nil {
+		return err
+	}
+	if err := t.InputSchema.Validate(input); err != nil {
+		return fmt.Errorf("%s tool %v", t.Name, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s tool input: %v", t.Name, err)
+	}
+	return nil
+}
+
+// input returns the raw input of the model's call to the tool
+func (t Tool) input(resp *Response) (json.RawMessage, error) {
+	if resp.Truncated() {
+		return nil, fmt.Errorf("the response was cut off at the max tokens limit before the %s tool input was complete", t.Name)
+	}
+	for _, call := range resp.ToolCalls {
+		if call.Name == t.Name {
+			return call.Input, nil
+		}
+	}
+
+	text := strings.TrimSpace(resp.Text)
+	if strings.HasPrefix(text, "{") && json.Valid([]byte(text)) {
+		return json.RawMessage(text), nil
+	}
+	return nil, fmt.Errorf("the model did not call the %s tool", t.Name)
+}
+
+// Truncated reports whether the model stopped because it reached the max tokens limit
+func (r *Response) Truncated() bool {
+	return r.StopReason == "max_tokens" || r.StopReason == "length"
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var testTool = Tool{Name: "record_items", InputSchema: testSchema}
+
+func TestToolDecode(t *testing.T) {
+	valid := `{"items": [{"date": "2025-06-03", "index": 0, "type": "debit", "confidence": 0.95}]}`
+	tests := []struct {
+		name     string
+		response *Response
+		err      string // substring of the error; empty for success
+	}{
+		{
+			name:     "tool call",
+			response: &Response{StopReason: "tool_use", ToolCalls: []ToolCall{{Name: "record_items", Input: json.RawMessage(valid)}}},
+		},
+		{
+			name:     "JSON object as text",
+			response: &Response{Text: "  " + valid + "\n"},
+		},
+		{
+			name:     "another tool",
+			response: &Response{ToolCalls: []ToolCall{{Name: "other", Input: json.RawMessage(valid)}}},
+			err:      "did not call the record_items tool",
+		},
+		{
+			name:     "prose around the JSON",
+			response: &Response{Text: "Here are the items: " + valid},
+			err:      "did not call the record_items tool",
+		},
+		{
+			name:     "truncated",
+			response: &Response{StopReason: "max_tokens", ToolCalls: []ToolCall{{Name: "record_items", Input: json.RawMessage(valid)}}},
+			err:      "cut off at the max tokens limit",
+		},
+		{
+			name:     "schema mismatch",
+			response: &Response{ToolCalls: []ToolCall{{Name: "record_items", Input: json.RawMessage(`{"items": [{"date": "2025-06-03"}]}`)}}},
+			err:      "record_items tool output does not match the schema:\n  - $.items[0]: missing required field \"index\"",
+		},
+	}
+	for _, tt := range tests {
+		var out struct {
+			Items []struct {
+				Date       string      `json:"date"`
+				Index      int         `json:"index"`
+				Confidence json.Number `json:"confidence"`
+			} `json:"items"`
+		}
+		err := testTool.Decode(tt.response, &out)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		// Numbers keep their decimal text
+		if len(out.Items) != 1 || out.Items[0].Date != "2025-06-03" || out.Items[0].Confidence != "0.95" {
+			t.Errorf("%s: decoded %+v", tt.name, out)
+		}
+	}
+}
@@ -1,25 +1,50 @@
 package loader
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// importExtensions lists the structured export formats that are imported
+// directly instead of going through PDF text extraction
+var importExtensions = map[string]bool{
+	".ofx":  true,
+	".qfx":  true,
+	".csv":  true,
+	".xlsx": true,
+	// ISO 20022 camt.053 and SWIFT MT940
+	".xml":   true,
+	".sta":   true,
+	".mt940": true,
+	".940":   true,
+}
+
 type PDFLoader struct {
 	pdfFolderPath string
 	PDFs          []string
+	// Imports holds structured bank exports (OFX/QFX, CSV/XLSX, camt.053, MT940) found alongside the PDFs
+	Imports []string
 }
 
 func New(pdfFolderPath string) *PDFLoader {
 	return &PDFLoader{
 		pdfFolderPath: pdfFolderPath,
 		PDFs:          make([]string, 0),
+		Imports:       make([]string, 0),
 	}
 }
 
-func (l *PDFLoader) Load() error {
+// Load lists the PDFs and bank exports in the folder
+func (l *PDFLoader) Load(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if _, err := os.Stat(l.pdfFolderPath); os.IsNotExist(err) {
 		return fmt.Errorf("PDF dir does not exist: %s", l.pdfFolderPath)
 	}
@@ -30,12 +55,50 @@ func (l *PDFLoader) Load() error {
 	}
 
 	for _, file := range files {
-		if strings.ToLower(filepath.Ext(file.Name())) == ".pdf" {
+		if file.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		switch {
+		case ext == ".pdf":
 			l.PDFs = append(l.PDFs, file.Name())
+		case importExtensions[ext]:
+			l.Imports = append(l.Imports, file.Name())
 		}
 	}
-	if len(l.PDFs) == 0 {
-		return fmt.Errorf("no PDFs found in dir: %s", l.pdfFolderPath)
+	if len(l.PDFs) == 0 && len(l.Imports) == 0 {
+		return fmt.Errorf("no PDFs or bank exports found in dir: %s", l.pdfFolderPath)
 	}
 	return nil
 }
+
+// Files returns every loaded statement file: PDFs first, then structured exports
+func (l *PDFLoader) Files() []string {
+	files := make([]string, 0, len(l.PDFs)+len(l.Imports))
+	files = append(files, l.PDFs...)
+	return append(files, l.Imports...)
+}
+
+// Path returns the full path of a loaded file
+func (l *PDFLoader) Path(name string) string {
+	return filepath.Join(l.pdfFolderPath, name)
+}
+
+// Fingerprint returns the hex-encoded SHA-256 hash of a loaded file's contents.
+// The hash changes whenever the file does, regardless of its name or timestamps.
+func (l *PDFLoader) Fingerprint(ctx context.Context, name string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f, err := os.Open(l.Path(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %s", name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %s", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Account represents a bank account or card that statements are issued for.
+// Transactions belong to an account through the statement they were listed on.
+//
+// Identity:
+// - An account is identified by its kind and number (see Key)
+// - The same account found in different statement files maps to a single ledger row
+type Account struct {
+	// Institution is the issuing bank, when known.
+	// Examples: "Bancolombia", "Davivienda"
+	Institution string
+
+	// Kind is the type of account: "credit-card", "savings", "checking" or "loan".
+	Kind string
+
+	// Number is the account or card number as printed on the statement.
+	// Statements usually mask it to the last digits (e.g. "7002"); IBANs are kept whole.
+	Number string
+
+	// Name is the product name printed on the statement.
+	// Examples: "Mastercard Black", "Cuenta de Ahorros"
+	Name string
+
+	// Currency is the ISO-4217 code the account is held in
+	Currency string
+}
+
+// Account kinds
+const (
+	AccountCreditCard = "credit-card"
+	AccountSavings    = "savings"
+	AccountChecking   = "checking"
+	AccountLoan       = "loan"
+)
+
+// Key returns a stable identifier for the account, built from its kind and
+// number, or from its name when the number is unknown.
+func (a *Account) Key() string {
+	id := a.Number
+	if id == "" {
+		id = a.Name
+	}
+	return strings.ToLower(a.Kind + "/" + strings.Join(strings.Fields(id), ""))
+}
+
+// Label returns a short human-readable name for reports, e.g. "Mastercard (credit-card ****7002)"
+func (a *Account) Label() string {
+	name := a.Name
+	if name == "" {
+		name = a.Institution
+	}
+	if name == "" {
+		name = "Account"
+	}
+	details := a.Kind
+	if n := a.lastDigits(); n != "" {
+		details = strings.TrimSpace(details + " ****" + n)
+	}
+	if details == "" {
+		return name
+	}
+	return name + " (" + details + ")"
+}
+
+// lastDigits returns the last four characters of the account number
+func (a *Account) lastDigits() string {
+	n := strings.Join(strings.Fields(a.Number), "")
+	if len(n) > 4 {
+		return n[len(n)-4:]
+	}
+	return n
+}
+
+// Statement represents one statement of an account for one billing period,
+// with the figures printed in its header.
+//
+// Relationships:
+// - A statement file (Source) usually holds one Statement; bank exports may hold several
+// - Each Transaction points to the Statement it was listed on
+type Statement struct {
+	// Account is the account the statement was issued for; nil when unknown
+	Account *Account
+
+	// Source identifies the statement file the header was read from
+	Source string
+
+	// PeriodStart and PeriodEnd delimit the billing period; zero when not printed
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+
+	// DueDate is the payment due date of credit card statements; zero otherwise
+	DueDate time.Time
+
+	// OpeningBalance and ClosingBalance are the balances at the start and end of the period.
+	// For credit cards the closing balance is the total amount owed.
+	OpeningBalance Money
+	ClosingBalance Money
+
+	// MinimumPayment is the minimum payment due of credit card statements; zero otherwise
+	MinimumPayment Money
+
+	// TotalDebits and TotalCredits are the period totals of charges and of payments or
+	// deposits as printed on the statement, both positive; zero when not printed.
+	// They are used to check that no rows were lost or invented during extraction.
+	TotalDebits  Money
+	TotalCredits Money
+}
+
+// Label returns a short description of the statement for reports,
+// e.g. "Mastercard (credit-card ****7002) 2025-06-16 to 2025-07-15"
+func (s *Statement) Label() string {
+	label := s.Source
+	if s.Account != nil {
+		label = s.Account.Label()
+	}
+	switch {
+	case !s.PeriodStart.IsZero() && !s.PeriodEnd.IsZero():
+		label += " " + s.PeriodStart.Format("2006-01-02") + " to " + s.PeriodEnd.Format("2006-01-02")
+	case !s.PeriodEnd.IsZero():
+		label += " until " + s.PeriodEnd.Format("2006-01-02")
+	}
+	return label
+}
+
+// Link points every transaction at the statement
+func (s *Statement) Link(transactions []*Transaction) {
+	for _, t := range transactions {
+		t.Statement = s
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CategorizedByUser marks categories set by the user in Transaction.CategorizedBy.
+// They are never overwritten by rules or the model.
+const CategorizedByUser = "user"
+
+// Correction records a category fixed or confirmed by the user, in an edited
+// transactions report or with the review command.
+// Corrections are kept in the ledger and learned from: merchants corrected the same
+// way repeatedly become rules, and single corrections are shown to the model as examples.
+type Correction struct {
+	// Merchant is the payee of the transaction, or its cleaned description (see corrections.MerchantKey)
+	Merchant string
+
+	// Description, Amount, Type, Date and Source describe the corrected transaction
+	Description string
+	Amount      Money
+	Type        TransactionType
+	Date        time.Time
+	Source      string
+
+	// FromCategory and FromSubcategory are the categories that were replaced;
+	// equal to Category and Subcategory when the user confirmed them
+	FromCategory    string
+	FromSubcategory string
+
+	// Category and Subcategory are the categories the user chose
+	Category    string
+	Subcategory string
+
+	// CreatedAt is when the correction was imported
+	CreatedAt time.Time
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO-4217 code assumed for amounts whose source does not state a currency.
+// Statements handled by this application are predominantly Colombian, hence COP.
+var DefaultCurrency = "COP"
+
+// Money is an exact monetary amount: an integer number of minor units plus an ISO-4217 currency code.
+// It replaces float64 amounts so that sums over thousands of transactions reconcile exactly.
+//
+// Design Notes:
+// - Value Object: all operations return new values
+// - Minor units follow the ISO-4217 exponent of the currency (2 for COP and USD, 0 for JPY, 3 for KWD)
+// - Decimal strings are parsed without going through float64
+// - Rounding to minor units is round-half-to-even (banker's rounding), which avoids upward drift
+// - The zero value is a currency-less zero, which adopts the currency of the first amount added to it
+type Money struct {
+	// Minor is the amount in minor units (e.g. cents); negative for money spent
+	Minor int64
+
+	// Currency is the ISO-4217 code, e.g. "COP" or "USD"
+	Currency string
+}
+
+// currencyExponents lists ISO-4217 currencies whose minor unit is not 1/100
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimal digits of the currency's minor unit
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// NewMoney creates an amount from minor units
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: normalizeCurrency(currency)}
+}
+
+// ParseMoney parses a plain decimal string such as "-125000.50" into Money.
+// Digits beyond the currency's exponent are rounded half to even.
+func ParseMoney(s string, currency string) (Money, error) {
+	currency = normalizeCurrency(currency)
+	raw := s
+	s = strings.TrimSpace(s)
+
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !allDigits(intPart) || !allDigits(fracPart) {
+		return Money{}, fmt.Errorf("invalid decimal amount %q", raw)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	exp := CurrencyExponent(currency)
+	var dropped string
+	if len(fracPart) > exp {
+		dropped = fracPart[exp:]
+		fracPart = fracPart[:exp]
+	} else {
+		fracPart += strings.Repeat("0", exp-len(fracPart))
+	}
+
+	minor, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q out of range: %v", raw, err)
+	}
+	if roundsUp(dropped, minor) {
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// MoneyFromFloat converts a float64 into Money, rounding half to even on the
+// shortest decimal representation of the float. Use ParseMoney when the
+// original decimal text is available.
+func MoneyFromFloat(v float64, currency string) Money {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return Money{Currency: normalizeCurrency(currency)}
+	}
+	m, err := ParseMoney(strconv.FormatFloat(v, 'f', -1, 64), currency)
+	if err != nil {
+		return Money{Currency: normalizeCurrency(currency)}
+	}
+	return m
+}
+
+// roundsUp decides half-to-even rounding given the dropped digits and the kept value
+func roundsUp(dropped string, kept int64) bool {
+	if dropped == "" || dropped[0] < '5' {
+		return false
+	}
+	if dropped[0] > '5' || strings.TrimRight(dropped[1:], "0") != "" {
+		return true
+	}
+	// Exactly half: round to the even neighbour
+	return kept%2 != 0
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeCurrency(c string) string {
+	return strings.ToUpper(strings.TrimSpace(c))
+}
+
+// Add returns m + o.
+// Adding amounts in different currencies is a programming error and panics;
+// convert one of them first. A currency-less zero adopts the other currency.
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.sameCurrency(o)}
+}
+
+// Sub returns m - o, with the same currency rules as Add
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.sameCurrency(o)}
+}
+
+func (m Money) sameCurrency(o Money) string {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("models: cannot combine %s and %s amounts", m.Currency, o.Currency))
+	}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money { return Money{Minor: -m.Minor, Currency: m.Currency} }
+
+// Abs returns the absolute value of m
+func (m Money) Abs() Money {
+	if m.Minor < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool { return m.Minor == 0 }
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// IsPositive reports whether the amount is above zero
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+
+// Float64 returns the amount in major units as a float64, for display and ratios only
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// Decimal formats the amount as a plain decimal string with the currency's exponent, e.g. "-125000.50"
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	digits := strconv.FormatInt(minor, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "-125000.50 COP"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// MarshalText encodes the amount as "<decimal> <currency>", e.g. for CSV cells
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes "<decimal> [currency]"; without a currency, DefaultCurrency is assumed
+func (m *Money) UnmarshalText(text []byte) error {
+	fields := strings.Fields(string(text))
+	currency := DefaultCurrency
+	switch len(fields) {
+	case 1:
+	case 2:
+		currency = fields[1]
+	default:
+		return fmt.Errorf("invalid money value %q", string(text))
+	}
+	parsed, err := ParseMoney(fields[0], currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// moneyJSON is the JSON form of Money; the amount is a string to keep it exact
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as {"amount": "-125000.50", "currency": "COP"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the object form produced by MarshalJSON, a "<decimal> <currency>"
+// string, or a bare JSON number in DefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		currency := v.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		parsed, err := ParseMoney(v.Amount, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case strings.HasPrefix(trimmed, `"`):
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return m.UnmarshalText([]byte(s))
+	default:
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid money value %s", trimmed)
+		}
+		return m.UnmarshalText([]byte(n.String()))
+	}
+}
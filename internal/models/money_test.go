@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+	}{
+		{"-125000.50", "COP", Money{-12500050, "COP"}},
+		{"+12", "usd", Money{1200, "USD"}},
+		{".5", "USD", Money{50, "USD"}},
+		{"1500", "JPY", Money{1500, "JPY"}},
+		{"1.2345", "KWD", Money{1234, "KWD"}},
+		// Rounding is half to even
+		{"0.125", "USD", Money{12, "USD"}},
+		{"0.135", "USD", Money{14, "USD"}},
+		{"0.1251", "USD", Money{13, "USD"}},
+		{"-2.5", "JPY", Money{-2, "JPY"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q, %s) = %+v, want %+v", tt.in, tt.currency, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "-", "1,50", "1.2.3", "abc"} {
+		if _, err := ParseMoney(in, "USD"); err == nil {
+			t.Errorf("ParseMoney(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	var total Money
+	total = total.Add(NewMoney(1050, "usd")).Add(NewMoney(-250, "USD"))
+	if total != (Money{800, "USD"}) {
+		t.Errorf("total = %+v, want 8.00 USD", total)
+	}
+	if got := total.Sub(NewMoney(1000, "USD")); got.String() != "-2.00 USD" || !got.IsNegative() || got.Abs().String() != "2.00 USD" {
+		t.Errorf("8.00 - 10.00 = %s", got)
+	}
+	if got := NewMoney(5, "COP").Decimal(); got != "0.05" {
+		t.Errorf("Decimal() = %q, want 0.05", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("adding COP to USD did not panic")
+		}
+	}()
+	total.Add(NewMoney(100, "COP"))
+}
+
+func TestMoneyEncoding(t *testing.T) {
+	m := NewMoney(-12500050, "COP")
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"-125000.50","currency":"COP"}` {
+		t.Errorf("json = %s", data)
+	}
+
+	for _, in := range []string{string(data), `"-125000.50 COP"`, `-125000.50`} {
+		var got Money
+		if err := json.Unmarshal([]byte(in), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", in, err)
+			continue
+		}
+		if got != m {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", in, got, m)
+		}
+	}
+}
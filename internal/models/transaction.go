@@ -10,6 +10,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -36,11 +37,23 @@ type Transaction struct {
 	// Examples: "SUPERMERCADO CENTRAL", "GASOLINA SHELL"
 	Description string
 
-	// Amount represents the transaction value in the local currency.
-	// Stored as float64 for precision in financial calculations.
-	// Positive values typically represent debits (money spent).
-	// Negative values typically represent credits (money received).
-	Amount float64
+	// Payee is the canonical merchant or payer behind the description, with processor
+	// prefixes, store numbers and terminal noise removed (see the payee package).
+	// Examples: "Uber" for both "UBER *TRIP HELP.UBER.COM" and "DLO*UBER RIDES"
+	Payee string
+
+	// Amount represents the transaction value and its currency.
+	// Stored as exact minor units (see Money) so totals never drift.
+	// Negative values represent debits (money spent).
+	// Positive values represent credits (money received).
+	// Amount.Currency is the currency the statement was billed in.
+	Amount Money
+
+	// OriginalAmount is the amount in the currency the charge was made in, when it
+	// differs from the billing currency (e.g. a USD purchase on a COP credit card).
+	// Zero when the transaction was made in the billing currency.
+	// Has the same sign as Amount.
+	OriginalAmount Money
 
 	// Type indicates whether this is a debit (money spent) or credit (money received).
 	// Uses a custom enum for type safety and clear intent.
@@ -50,7 +63,7 @@ type Transaction struct {
 	// Balance represents the account balance after this transaction.
 	// Optional field that may not be available in all bank statements.
 	// Useful for reconciliation and balance verification.
-	Balance float64
+	Balance Money
 
 	// Category is the AI-generated spending category.
 	// Examples: "Food & Dining", "Transportation", "Shopping"
@@ -62,11 +75,26 @@ type Transaction struct {
 	// Helps with detailed spending analysis and budgeting.
 	Subcategory string
 
+	// CategoryID is the ID of the category in the category taxonomy (see taxonomy.Category).
+	// Examples: "food.groceries", "transfers"
+	// Empty for uncategorized transactions and rows categorized before the taxonomy existed.
+	CategoryID string
+
 	// Confidence represents the AI's confidence level in the categorization.
 	// Range: 0.0 (no confidence) to 1.0 (complete confidence)
 	// Used to filter out low-confidence categorizations or flag for review.
 	Confidence float64
 
+	// Tags are free-form labels assigned by categorization rules.
+	// Examples: "essentials", "subscription", "work-expense"
+	// Useful for views that cut across categories.
+	Tags []string
+
+	// CategorizedBy records what assigned the category: "rule:<name>" for a
+	// categorization rule or "model:<model>" for the LLM.
+	// Empty for uncategorized transactions and rows categorized before it was tracked.
+	CategorizedBy string
+
 	// RawText contains the original text line from the bank statement.
 	// Useful for debugging, validation, and audit trails.
 	// Preserves the exact format from the source document.
@@ -76,6 +104,42 @@ type Transaction struct {
 	// Format: filename (e.g., "Extracto_875208547_202507_TARJETA_MASTERCARD_7002.pdf")
 	// Helps with data lineage and troubleshooting.
 	Source string
+
+	// ExternalID is a stable identifier assigned by the bank, when the source provides one.
+	// Example: the FITID of an OFX/QFX STMTTRN record.
+	// Used to recognize the same transaction across re-imports of overlapping exports.
+	ExternalID string
+
+	// ValueDate is when the funds became effective, if the bank reports it separately.
+	// Date always holds the booking date; ValueDate is zero when not provided.
+	ValueDate time.Time
+
+	// Counterparty is the name of the other party (payee or payer), when the source provides it.
+	// Examples: the creditor name of a camt.053 debit, the NAME subfield of an MT940 :86: line
+	Counterparty string
+
+	// RemittanceInfo is the payment reference or free-text remittance information.
+	// Example: "FACTURA 2025-0712 ARRIENDO JULIO"
+	RemittanceInfo string
+
+	// DuplicateOf is the source file of the transaction this one duplicates, when the
+	// same transaction was listed in more than one file (see the dedupe package).
+	// Duplicates stay in the ledger but are left out of categorization and reports.
+	DuplicateOf string
+
+	// TransferID links the two legs of a transfer between the user's own accounts,
+	// e.g. a credit card payment that leaves the savings account and reaches the card.
+	// Both legs share it (see the transfers package); empty for other transactions.
+	// Transfers are neither income nor expenses.
+	TransferID string
+
+	// TransferKind is TransferCardPayment or TransferOwnAccounts for the legs of a transfer
+	TransferKind string
+
+	// Statement is the account statement this transaction was listed on.
+	// Nil when the statement header could not be read (e.g. spreadsheet exports).
+	// Gives access to the account, billing period and balances.
+	Statement *Statement
 }
 
 // TransactionType represents the nature of a financial transaction.
@@ -91,12 +155,12 @@ type TransactionType int
 const (
 	// Debit represents money spent (purchases, withdrawals, fees)
 	// This is the most common type in credit card statements.
-	// Amount is typically positive for debits.
+	// Amount is negative for debits.
 	Debit TransactionType = iota
 
 	// Credit represents money received (payments, refunds, deposits)
 	// Common in bank account statements and credit card payments.
-	// Amount is typically negative for credits.
+	// Amount is positive for credits.
 	Credit
 )
 
@@ -117,3 +181,22 @@ func (t TransactionType) String() string {
 		return "Unknown"
 	}
 }
+
+// ParseTransactionType converts a textual transaction type back into a TransactionType.
+// It accepts the values produced by String as well as the lowercase forms used
+// in API responses, and defaults to Debit for anything unrecognized.
+func ParseTransactionType(s string) TransactionType {
+	if strings.EqualFold(strings.TrimSpace(s), "credit") {
+		return Credit
+	}
+	return Debit
+}
+
+// Kinds of transfer between the user's own accounts
+const (
+	// TransferCardPayment is a payment of a credit card or loan from another account
+	TransferCardPayment = "card-payment"
+
+	// TransferOwnAccounts is money moved between bank accounts of the user
+	TransferOwnAccounts = "own-accounts"
+)
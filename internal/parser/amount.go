@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// ParseAmount converts a statement amount into exact Money in the given currency.
+// It understands Colombian formatting ("125.000,50", "$ 1.250.000") as well as
+// US formatting ("1,250.00"), leading or trailing minus signs and accounting
+// parentheses. When only one kind of separator is present, a single group of
+// exactly three digits after it is read as thousands, which is how COP amounts
+// without cents are printed.
+func ParseAmount(s, currency string) (models.Money, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "$", "")
+	s = strings.ReplaceAll(s, "COP", "")
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.ReplaceAll(s, "\u00a0", "")
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	} else if strings.HasSuffix(s, "-") {
+		negative = true
+		s = s[:len(s)-1]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if s == "" {
+		return models.Money{}, fmt.Errorf("invalid amount %q", raw)
+	}
+
+	lastDot := strings.LastIndex(s, ".")
+	lastComma := strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Both separators: whichever comes last is the decimal separator
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		s = normalizeSingleSeparator(s, ",")
+	case lastDot >= 0:
+		s = normalizeSingleSeparator(s, ".")
+	}
+
+	v, err := models.ParseMoney(s, currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid amount %q: %v", raw, err)
+	}
+	if negative {
+		v = v.Neg()
+	}
+	return v, nil
+}
+
+// normalizeSingleSeparator rewrites s, which contains only sep as separator,
+// into a plain decimal that models.ParseMoney accepts.
+func normalizeSingleSeparator(s, sep string) string {
+	parts := strings.Split(s, sep)
+	if len(parts) > 2 || len(parts[len(parts)-1]) == 3 {
+		// "1.250.000" or "125.000": thousands separators
+		return strings.ReplaceAll(s, sep, "")
+	}
+	return strings.Replace(s, sep, ".", 1)
+}
+
+// spanishMonths maps Spanish (and English) month abbreviations to month numbers
+var spanishMonths = map[string]string{
+	"ene": "01", "jan": "01",
+	"feb": "02",
+	"mar": "03",
+	"abr": "04", "apr": "04",
+	"may": "05",
+	"jun": "06",
+	"jul": "07",
+	"ago": "08", "aug": "08",
+	"sep": "09", "sept": "09", "set": "09",
+	"oct": "10",
+	"nov": "11",
+	"dic": "12", "dec": "12",
+}
+
+// ParseDate parses a statement date using the given layouts, in order.
+// Spanish or English month abbreviations ("02 ago 2025") are translated into
+// month numbers before parsing, so layouts use numeric months ("02 01 2006").
+func ParseDate(s string, layouts ...string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	normalized := translateMonth(s)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// translateMonth replaces a month abbreviation with its number, so that
+// "02 ago 2025" becomes "02 08 2025".
+func translateMonth(s string) string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '-' || r == '/' })
+	for _, f := range fields {
+		key := strings.TrimSuffix(strings.ToLower(f), ".")
+		if num, ok := spanishMonths[key]; ok {
+			return strings.Replace(s, f, num, 1)
+		}
+	}
+	return s
+}
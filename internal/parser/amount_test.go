@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"125.000,50", 12500050},
+		{"-$ 1.250.000,00", -125000000},
+		{"125.000,50-", -12500050},
+		{"$ 1.250.000", 125000000},
+		{"125.000", 12500000},
+		{"1,250.00", 125000},
+		{"(45,10)", -4510},
+		{"+12.5", 1250},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in, "COP")
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", tt.in, err)
+			continue
+		}
+		if got != models.NewMoney(tt.want, "COP") {
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.in, got, models.NewMoney(tt.want, "COP"))
+		}
+	}
+
+	if _, err := ParseAmount("-", "COP"); err == nil {
+		t.Error("ParseAmount(\"-\") succeeded, want an error")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		layouts []string
+		want    string
+	}{
+		{"02 ago 2025", []string{"02 01 2006"}, "2025-08-02"},
+		{"15-Dic-2024", []string{"02-01-2006"}, "2024-12-15"},
+		{"5/01/2025", []string{"02/01/2006", "2/01/2006"}, "2025-01-05"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in, tt.layouts...)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("ParseDate(%q) = %s, want %s", tt.in, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
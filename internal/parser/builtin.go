@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"regexp"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// amountPattern matches a Colombian-formatted amount such as "125.000,50",
+// "-$ 1.250.000,00" or "125.000,50-"
+const amountPattern = `-?\$?\s?\d{1,3}(?:\.\d{3})*,\d{2}-?`
+
+// foreignAmountPattern matches the original value of an international charge,
+// e.g. "USD 25,99" or "EUR 1.234,50"
+const foreignAmountPattern = `(?P<original_currency>USD|EUR|GBP|MXN|BRL|CAD)\s?(?P<original_amount>\d{1,3}(?:[.,]\d{3})*[.,]\d{2})`
+
+// creditCardLine matches card rows of the form
+// "[authorization] DD/MM/YYYY DESCRIPTION [FOREIGN-VALUE] ORIGINAL-VALUE [rates, installments...]".
+// The first COP amount after the description is the original transaction value;
+// international charges print their foreign-currency value just before it.
+var creditCardLine = regexp.MustCompile(`^(?:\d{4,8}\s+)?(?P<date>\d{2}/\d{2}/\d{4})\s+(?P<description>.+?)\s+(?:` + foreignAmountPattern + `\s+)?(?P<amount>` + amountPattern + `)(?:\s+.*)?$`)
+
+// Header patterns shared by the credit card layouts
+var (
+	cardPeriodEnd      = regexp.MustCompile(`(?i)fecha\s+de\s+corte:?\s*(\d{2}/\d{2}/\d{4})`)
+	cardPeriodStart    = regexp.MustCompile(`(?i)periodo\s+facturado:?\s*(\d{2}/\d{2}/\d{4})`)
+	cardDueDate        = regexp.MustCompile(`(?i)(?:fecha\s+l[ií]mite\s+de\s+pago|p[aá]guese\s+antes\s+de):?\s*(\d{2}/\d{2}/\d{4})`)
+	cardMinimumPayment = regexp.MustCompile(`(?i)pago\s+m[ií]nimo:?\s*(` + amountPattern + `)`)
+	cardTotalDue       = regexp.MustCompile(`(?i)(?:pago\s+total|saldo\s+total|nuevo\s+saldo):?\s*(` + amountPattern + `)`)
+	cardPreviousDue    = regexp.MustCompile(`(?i)saldo\s+anterior:?\s*(` + amountPattern + `)`)
+)
+
+// cardHeader builds the header of a credit card layout whose file names end in the card's last digits
+func cardHeader(name string) *Header {
+	return &Header{
+		Kind:           models.AccountCreditCard,
+		Name:           name,
+		AccountNumber:  regexp.MustCompile(`(?i)_TARJETA_` + name + `_(\d+)`),
+		PeriodStart:    cardPeriodStart,
+		PeriodEnd:      cardPeriodEnd,
+		DueDate:        cardDueDate,
+		DateLayouts:    []string{"02/01/2006"},
+		OpeningBalance: cardPreviousDue,
+		ClosingBalance: cardTotalDue,
+		MinimumPayment: cardMinimumPayment,
+	}
+}
+
+// builtinLayouts are the statement layouts recognized out of the box
+var builtinLayouts = []*Layout{
+	{
+		Issuer:        "mastercard",
+		Kind:          "credit-card",
+		SourcePattern: regexp.MustCompile(`(?i)^Extracto_\d+_\d{6}_TARJETA_MASTERCARD_\d+`),
+		Markers: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)mastercard`),
+			regexp.MustCompile(`(?i)fecha\s+de\s+transacci[oó]n`),
+		},
+		Line:            creditCardLine,
+		DateLayouts:     []string{"02/01/2006"},
+		ChargesPositive: true,
+		Header:          cardHeader("Mastercard"),
+	},
+	{
+		Issuer:        "visa",
+		Kind:          "credit-card",
+		SourcePattern: regexp.MustCompile(`(?i)^Extracto_\d+_\d{6}_TARJETA_VISA_\d+`),
+		Markers: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bvisa\b`),
+			regexp.MustCompile(`(?i)fecha\s+de\s+transacci[oó]n`),
+		},
+		Line:            creditCardLine,
+		DateLayouts:     []string{"02/01/2006"},
+		ChargesPositive: true,
+		Header:          cardHeader("Visa"),
+	},
+	{
+		// Savings account rows: "D/MM DESCRIPTION [BRANCH] VALUE BALANCE", without the year,
+		// which comes from the "HASTA: YYYY/MM/DD" period end header or the file name
+		Issuer:        "savings",
+		Kind:          "account",
+		SourcePattern: regexp.MustCompile(`(?i)^Extracto_\d+_\d{6}_(?:CUENTA_)?AHORROS`),
+		Markers: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)cuenta\s+de\s+ahorros`),
+			regexp.MustCompile(`(?i)saldo\s+anterior`),
+		},
+		Line:        regexp.MustCompile(`^(?P<date>\d{1,2}/\d{2})\s+(?P<description>.+?)\s+(?P<amount>` + amountPattern + `)\s+(?P<balance>` + amountPattern + `)$`),
+		DateLayouts: []string{"2/01/2006"},
+		YearPattern: regexp.MustCompile(`(?i)(?:hasta:?\s*(?P<year>\d{4})/(?P<month>\d{2})/\d{2}|_(?P<year>\d{4})(?P<month>\d{2})_)`),
+		Header: &Header{
+			Kind:           models.AccountSavings,
+			Name:           "Cuenta de Ahorros",
+			AccountNumber:  regexp.MustCompile(`(?i)(?:n[uú]mero\s+de\s+cuenta|cuenta\s+n[uú]mero|cuenta\s+no\.?):?\s*([\d-]{4,})`),
+			PeriodStart:    regexp.MustCompile(`(?i)desde:?\s*(\d{4}/\d{2}/\d{2})`),
+			PeriodEnd:      regexp.MustCompile(`(?i)hasta:?\s*(\d{4}/\d{2}/\d{2})`),
+			DateLayouts:    []string{"2006/01/02"},
+			OpeningBalance: regexp.MustCompile(`(?i)saldo\s+anterior:?\s*(` + amountPattern + `)`),
+			ClosingBalance: regexp.MustCompile(`(?i)saldo\s+actual:?\s*(` + amountPattern + `)`),
+			TotalDebits:    regexp.MustCompile(`(?i)total\s+(?:cargos|d[eé]bitos):?\s*(` + amountPattern + `)`),
+			TotalCredits:   regexp.MustCompile(`(?i)total\s+(?:abonos|cr[eé]ditos):?\s*(` + amountPattern + `)`),
+		},
+	},
+}
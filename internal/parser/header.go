@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Header describes how a Layout reads the account and statement figures.
+// Every pattern holds the value in its first capture group and is matched
+// against the statement text first and the file name second.
+type Header struct {
+	// Kind and Name describe the account, e.g. models.AccountCreditCard and "Mastercard"
+	Kind string
+	Name string
+
+	// AccountNumber captures the (usually masked) account or card number
+	AccountNumber *regexp.Regexp
+
+	// PeriodStart, PeriodEnd and DueDate capture dates parsed with DateLayouts
+	PeriodStart *regexp.Regexp
+	PeriodEnd   *regexp.Regexp
+	DueDate     *regexp.Regexp
+	DateLayouts []string
+
+	// OpeningBalance, ClosingBalance and MinimumPayment capture amounts as printed
+	OpeningBalance *regexp.Regexp
+	ClosingBalance *regexp.Regexp
+	MinimumPayment *regexp.Regexp
+
+	// TotalDebits and TotalCredits capture the period totals printed in the statement summary
+	TotalDebits  *regexp.Regexp
+	TotalCredits *regexp.Regexp
+}
+
+// parse reads the statement header; fields whose pattern is missing or does not match stay zero
+func (h *Header) parse(text, source, currency string) *models.Statement {
+	stmt := &models.Statement{
+		Source: source,
+		Account: &models.Account{
+			Kind:     h.Kind,
+			Name:     h.Name,
+			Number:   capture(h.AccountNumber, text, source),
+			Currency: currency,
+		},
+	}
+
+	if v := capture(h.PeriodStart, text, source); v != "" {
+		stmt.PeriodStart, _ = ParseDate(v, h.DateLayouts...)
+	}
+	if v := capture(h.PeriodEnd, text, source); v != "" {
+		stmt.PeriodEnd, _ = ParseDate(v, h.DateLayouts...)
+	}
+	if v := capture(h.DueDate, text, source); v != "" {
+		stmt.DueDate, _ = ParseDate(v, h.DateLayouts...)
+	}
+
+	amount := func(re *regexp.Regexp) models.Money {
+		if v := capture(re, text, source); v != "" {
+			if m, err := ParseAmount(v, currency); err == nil {
+				return m
+			}
+		}
+		return models.Money{Currency: currency}
+	}
+	stmt.OpeningBalance = amount(h.OpeningBalance)
+	stmt.ClosingBalance = amount(h.ClosingBalance)
+	stmt.MinimumPayment = amount(h.MinimumPayment)
+	stmt.TotalDebits = amount(h.TotalDebits).Abs()
+	stmt.TotalCredits = amount(h.TotalCredits).Abs()
+
+	return stmt
+}
+
+// capture returns the first group of re in text, or in source if text has no match
+func capture(re *regexp.Regexp, text, source string) string {
+	if re == nil {
+		return ""
+	}
+	for _, s := range []string{text, source} {
+		if m := re.FindStringSubmatch(s); len(m) > 1 {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
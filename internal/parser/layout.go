@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Layout is a table-driven Parser for statements whose rows fit a single regular expression.
+//
+// Line must define the named groups "date", "description" and "amount", and may
+// define "balance", "currency" (the row's ISO code when it differs per row) and
+// "original_amount"/"original_currency" for foreign charges. Amounts follow the extraction convention used throughout
+// the application: negative for money spent, positive for money received.
+type Layout struct {
+	// Issuer and Kind together name the layout, e.g. "mastercard" and "credit-card"
+	Issuer string
+	Kind   string
+
+	// SourcePattern, when set, detects the layout from the statement file name
+	SourcePattern *regexp.Regexp
+
+	// Markers must all match the statement text to detect the layout from its contents
+	Markers []*regexp.Regexp
+
+	// Line matches one transaction row
+	Line *regexp.Regexp
+
+	// DateLayouts are tried in order to parse the "date" group
+	DateLayouts []string
+
+	// YearPattern captures a "year" group from the text or file name for rows
+	// whose dates omit the year; the year is appended as "/2006" before parsing.
+	// It is the year the statement period ends in: with an optional "month" group
+	// of the period end, rows dated in a later month belong to the year before,
+	// as in a December-to-January statement.
+	YearPattern *regexp.Regexp
+
+	// ChargesPositive is set for statements (typically credit cards) that print
+	// purchases as positive amounts and payments as negative ones
+	ChargesPositive bool
+
+	// Currency is the ISO-4217 code of the amounts; models.DefaultCurrency when empty
+	Currency string
+
+	// Header, when set, reads the account and statement figures; parsed
+	// transactions are then linked to the resulting statement
+	Header *Header
+}
+
+// Name returns "issuer/kind"
+func (l *Layout) Name() string {
+	return l.Issuer + "/" + l.Kind
+}
+
+// Detect matches the file name first and falls back to the text markers
+func (l *Layout) Detect(text, source string) bool {
+	if l.SourcePattern != nil && l.SourcePattern.MatchString(source) {
+		return true
+	}
+	if len(l.Markers) == 0 {
+		return false
+	}
+	for _, m := range l.Markers {
+		if !m.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse extracts one transaction per matching line of the statement text
+func (l *Layout) Parse(text, source string) ([]*models.Transaction, error) {
+	year, endMonth := 0, 0
+	if l.YearPattern != nil {
+		year, endMonth = capturePeriodEnd(l.YearPattern, text)
+		if year == 0 {
+			year, endMonth = capturePeriodEnd(l.YearPattern, source)
+		}
+		if year == 0 {
+			return nil, fmt.Errorf("%s: could not determine the statement year", l.Name())
+		}
+	}
+
+	currency := l.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	var result []*models.Transaction
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--- Page ") {
+			continue
+		}
+
+		m := l.Line.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		group := func(name string) string {
+			if i := l.Line.SubexpIndex(name); i >= 0 {
+				return strings.TrimSpace(m[i])
+			}
+			return ""
+		}
+
+		dateText := group("date")
+		if year != 0 {
+			dateText += fmt.Sprintf("/%d", year)
+		}
+		date, err := ParseDate(dateText, l.DateLayouts...)
+		if err != nil {
+			continue
+		}
+		if year != 0 && endMonth != 0 && int(date.Month()) > endMonth {
+			if date, err = ParseDate(fmt.Sprintf("%s/%d", group("date"), year-1), l.DateLayouts...); err != nil {
+				continue
+			}
+		}
+
+		rowCurrency := currency
+		if c := group("currency"); c != "" {
+			rowCurrency = c
+		}
+		amount, err := ParseAmount(group("amount"), rowCurrency)
+		if err != nil {
+			continue
+		}
+		if l.ChargesPositive {
+			amount = amount.Neg()
+		}
+
+		// Foreign charges keep their original value, with the sign of the billed amount
+		var original models.Money
+		if o, c := group("original_amount"), group("original_currency"); o != "" && c != "" {
+			if v, err := ParseAmount(o, c); err == nil {
+				original = v.Abs()
+				if amount.IsNegative() {
+					original = original.Neg()
+				}
+			}
+		}
+
+		transactionType := models.Debit
+		if amount.IsPositive() {
+			transactionType = models.Credit
+		}
+
+		balance := models.Money{Currency: rowCurrency}
+		if b := group("balance"); b != "" {
+			if v, err := ParseAmount(b, rowCurrency); err == nil {
+				balance = v
+			}
+		}
+
+		result = append(result, &models.Transaction{
+			Date:           date,
+			Description:    strings.Join(strings.Fields(group("description")), " "),
+			Amount:         amount,
+			OriginalAmount: original,
+			Type:           transactionType,
+			Balance:        balance,
+			RawText:        line,
+			Source:         source,
+		})
+	}
+
+	if l.Header != nil {
+		l.Header.parse(text, source, currency).Link(result)
+	}
+
+	return result, nil
+}
+
+// capturePeriodEnd returns the "year" and "month" groups matched in s; 0 when not matched
+func capturePeriodEnd(re *regexp.Regexp, s string) (year, month int) {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0
+	}
+	// Alternatives may each define the groups; use the ones that matched
+	for i, name := range re.SubexpNames() {
+		if m[i] == "" {
+			continue
+		}
+		switch name {
+		case "year":
+			year, _ = strconv.Atoi(m[i])
+		case "month":
+			month, _ = strconv.Atoi(m[i])
+		}
+	}
+	return year, month
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+const savingsText = `--- Page 1 ---
+CUENTA DE AHORROS
+NUMERO DE CUENTA: 123-456789-01
+DESDE: 2024/12/16 HASTA: 2025/01/15
+SALDO ANTERIOR: 1.000.000,00
+TOTAL ABONOS: 500.000,00
+TOTAL CARGOS: 130.000,00
+SALDO ACTUAL: 1.370.000,00
+16/12 COMPRA EXITO -80.000,00 920.000,00
+31/12 ABONO NOMINA 500.000,00 1.420.000,00
+2/01 PAGO PSE CLARO -50.000,00 1.370.000,00`
+
+func TestSavingsLayoutAcrossYearEnd(t *testing.T) {
+	registry := Default()
+	p := registry.Detect(savingsText, "extracto.pdf")
+	if p == nil || p.Name() != "savings/account" {
+		t.Fatalf("detected %v, want savings/account", p)
+	}
+	transactions, err := p.Parse(savingsText, "extracto.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// December rows belong to the year before the HASTA date
+	want := []struct {
+		date   string
+		amount models.Money
+	}{
+		{"2024-12-16", models.NewMoney(-8000000, "COP")},
+		{"2024-12-31", models.NewMoney(50000000, "COP")},
+		{"2025-01-02", models.NewMoney(-5000000, "COP")},
+	}
+	if len(transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(want))
+	}
+	for i, w := range want {
+		tx := transactions[i]
+		if got := tx.Date.Format("2006-01-02"); got != w.date || tx.Amount != w.amount {
+			t.Errorf("row %d = %s %s, want %s %s", i, got, tx.Amount, w.date, w.amount)
+		}
+	}
+
+	stmt := transactions[0].Statement
+	if stmt.Account.Number != "123-456789-01" || stmt.PeriodStart.Format("2006-01-02") != "2024-12-16" {
+		t.Errorf("unexpected header: account %q, period start %s", stmt.Account.Number, stmt.PeriodStart)
+	}
+	if stmt.TotalCredits != models.NewMoney(50000000, "COP") || stmt.TotalDebits != models.NewMoney(13000000, "COP") {
+		t.Errorf("totals: credits %s, debits %s", stmt.TotalCredits, stmt.TotalDebits)
+	}
+}
+
+func TestSavingsLayoutYearFromFileName(t *testing.T) {
+	text := "CUENTA DE AHORROS\nSALDO ANTERIOR: 0,00\n28/02 ABONO 10.000,00 10.000,00\n1/03 RETIRO -5.000,00 5.000,00"
+	transactions, err := Default().Detect(text, "").Parse(text, "Extracto_123_202503_AHORROS.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	for i, want := range []string{"2025-02-28", "2025-03-01"} {
+		if got := transactions[i].Date.Format("2006-01-02"); got != want {
+			t.Errorf("row %d dated %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestCreditCardLayout(t *testing.T) {
+	text := `MASTERCARD
+FECHA DE TRANSACCION
+FECHA DE CORTE: 15/07/2025
+PAGO MINIMO: 85.000,00
+123456 20/06/2025 NETFLIX.COM USD 15,99 64.500,00 1/1
+28/06/2025 ABONO SUCURSAL VIRTUAL -500.000,00`
+	source := "Extracto_1_202507_TARJETA_MASTERCARD_7002.pdf"
+	p := Default().Detect(text, source)
+	if p == nil || p.Name() != "mastercard/credit-card" {
+		t.Fatalf("detected %v, want mastercard/credit-card", p)
+	}
+	transactions, err := p.Parse(text, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+
+	charge := transactions[0]
+	if charge.Description != "NETFLIX.COM" || charge.Amount != models.NewMoney(-6450000, "COP") ||
+		charge.OriginalAmount != models.NewMoney(-1599, "USD") || charge.Type != models.Debit {
+		t.Errorf("unexpected charge %s %s (%s) %s", charge.Description, charge.Amount, charge.OriginalAmount, charge.Type)
+	}
+	if payment := transactions[1]; payment.Amount != models.NewMoney(50000000, "COP") || payment.Type != models.Credit {
+		t.Errorf("unexpected payment %s %s", payment.Amount, payment.Type)
+	}
+	if stmt := charge.Statement; stmt.Account.Number != "7002" || stmt.MinimumPayment != models.NewMoney(8500000, "COP") {
+		t.Errorf("unexpected header: number %q, minimum payment %s", stmt.Account.Number, stmt.MinimumPayment)
+	}
+}
@@ -0,0 +1,53 @@
+// Package parser extracts transactions from statement layouts that are known
+// in advance using deterministic rules, so those statements never need an LLM.
+package parser
+
+import (
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Parser extracts transactions from the text of one statement layout
+type Parser interface {
+	// Name identifies the issuer and layout, e.g. "mastercard/credit-card"
+	Name() string
+
+	// Detect reports whether the statement text or file name matches this layout
+	Detect(text, source string) bool
+
+	// Parse extracts the transactions of a statement accepted by Detect
+	Parse(text, source string) ([]*models.Transaction, error)
+}
+
+// Registry holds the known parsers in priority order
+type Registry struct {
+	parsers []Parser
+}
+
+// NewRegistry creates a registry with the given parsers
+func NewRegistry(parsers ...Parser) *Registry {
+	return &Registry{parsers: parsers}
+}
+
+// Default returns a registry with every built-in layout
+func Default() *Registry {
+	var parsers []Parser
+	for _, l := range builtinLayouts {
+		parsers = append(parsers, l)
+	}
+	return NewRegistry(parsers...)
+}
+
+// Register adds a parser after the existing ones
+func (r *Registry) Register(p Parser) {
+	r.parsers = append(r.parsers, p)
+}
+
+// Detect returns the first parser that recognizes the statement, or nil if none does
+func (r *Registry) Detect(text, source string) Parser {
+	for _, p := range r.parsers {
+		if p.Detect(text, source) {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package payee
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// processorPrefix matches the payment processor or aggregator a charge went through,
+// e.g. "DLO*", "PAYU *", "SQ *", "PAYPAL *", also after terminal wording like "COMPRA POS"
+var processorPrefix = regexp.MustCompile(`(?:^|\s)(?:DLO|DLOCAL|PAYU|SQ|TST|PAYPAL|PP|MERPAGO|MERCADOPAGO|MP|EBANX|SUMUP|ZETTLE|IZ|STRIPE|SP|PY|GOOGLE|APPLE\.COM/BILL)\s*\*\s*`)
+
+// domain matches web addresses such as "HELP.UBER.COM" or "WWW.NETFLIX.COM/BILL";
+// the first group is the name the domain is registered to
+var domain = regexp.MustCompile(`(?:WWW\.)?(?:[\p{L}0-9-]+\.)*([\p{L}0-9-]+)\.(?:COM|NET|ORG|CO|IO|APP|TV)(?:\.[A-Z]{2})?(?:/\S*)?`)
+
+// punctuation matches everything that is not part of a word
+var punctuation = regexp.MustCompile(`[^\p{L}\p{N}&']+`)
+
+// digit matches a single digit; tokens of digits or with two or more of them are store
+// numbers, terminal IDs, dates or references ("8842", "#123", "2K4L"), while names like
+// "D1" or "7ELEVEN" are kept
+var digit = regexp.MustCompile(`\p{N}`)
+
+func isNumber(word string) bool {
+	n := len(digit.FindAllStringIndex(word, -1))
+	return n >= 2 || (n > 0 && n == len([]rune(word)))
+}
+
+// noiseWords are dropped wherever they appear: legal forms and card-terminal wording.
+// Legal forms written with dots ("S.A.S.") are matched once their letters are joined.
+var noiseWords = wordSet(
+	"BV", "SAS", "SA", "LTDA", "LTD", "INC", "LLC", "GMBH", "CORP", "PLC",
+	"POS", "COMPRA", "COMPRAS", "PURCHASE", "DATAFONO", "TERMINAL", "TRX", "TPV", "CONTACTLESS",
+)
+
+// locations are dropped from the end of a description: cities, country codes and
+// street words that banks append after the merchant name. Names of several words
+// are only dropped as a whole.
+var locations = wordSet(
+	"CALLE", "CL", "CARRERA", "CRA", "KR", "AV", "AVENIDA", "CENTRO", "CC",
+	"BOGOTA", "BOGOTÁ", "MEDELLIN", "MEDELLÍN", "CALI", "BARRANQUILLA", "CARTAGENA", "BUCARAMANGA",
+	"PEREIRA", "MANIZALES", "SANTA MARTA", "CUCUTA", "CÚCUTA", "IBAGUE", "IBAGUÉ", "CHIA", "CHÍA",
+	"ENVIGADO", "ITAGUI", "SABANETA", "RIONEGRO", "DC",
+	"AMSTERDAM", "LONDON", "DUBLIN", "MADRID", "MIAMI", "NEW YORK", "SAN FRANCISCO", "MEXICO",
+	"CO", "COL", "US", "USA", "ES", "ESP", "MX", "MEX", "NL", "IE", "GB", "UK",
+)
+
+// maxLocationWords is the number of words of the longest entry in locations
+const maxLocationWords = 2
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Clean strips the noise banks and processors add around a merchant name: processor
+// prefixes, web addresses, store and terminal numbers, legal forms, card-terminal
+// wording and trailing city or country names. Extra words to drop can be passed.
+// Example: "UBER *TRIP HELP.UBER.COM", "UBER   BV" and "DLO*UBER RIDES" give
+// "UBER TRIP", "UBER" and "UBER RIDES"; "ALMACENES EXITO S.A.S. SANTA MARTA" gives
+// "ALMACENES EXITO", while "CAFE A LA MODE" and "SANTA MARTA" are kept as they are
+func Clean(description string, extra map[string]bool) string {
+	s := strings.ToUpper(strings.TrimSpace(description))
+	s = processorPrefix.ReplaceAllString(s, " ")
+	s = domain.ReplaceAllString(s, " $1 ")
+	s = punctuation.ReplaceAllString(s, " ")
+
+	var words []string
+	seen := make(map[string]bool)
+	for _, w := range joinLetters(strings.Fields(s)) {
+		w = strings.Trim(w, "'")
+		if w == "" || isNumber(w) || noiseWords[w] || extra[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	// Keep at least one word, so a merchant named like a city is not lost
+	for {
+		n := trailingLocation(words)
+		if n == 0 || n == len(words) {
+			break
+		}
+		words = words[:len(words)-n]
+	}
+	return strings.Join(words, " ")
+}
+
+// joinLetters joins runs of single letters, which are abbreviations written with
+// dots or spaces: "S A S" becomes "SAS" and "D C" becomes "DC". Single letters on
+// their own, like the "A" of "CAFE A LA MODE", are kept.
+func joinLetters(words []string) []string {
+	var joined []string
+	for i := 0; i < len(words); {
+		j := i
+		for j < len(words) && len([]rune(words[j])) == 1 && unicode.IsLetter([]rune(words[j])[0]) {
+			j++
+		}
+		if j-i < 2 {
+			joined = append(joined, words[i])
+			i++
+			continue
+		}
+		joined = append(joined, strings.Join(words[i:j], ""))
+		i = j
+	}
+	return joined
+}
+
+// trailingLocation returns how many words at the end of words name a location, 0 if none
+func trailingLocation(words []string) int {
+	for n := maxLocationWords; n > 0; n-- {
+		if n <= len(words) && locations[strings.Join(words[len(words)-n:], " ")] {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package payee
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"UBER *TRIP HELP.UBER.COM", "UBER TRIP"},
+		{"UBER   BV", "UBER"},
+		{"DLO*UBER RIDES", "UBER RIDES"},
+		{"RAPPI*COL 8842", "RAPPI"},
+		{"COMPRA POS NETFLIX.COM 2K4L", "NETFLIX"},
+		{"WWW.AMAZON.COM/BILL", "AMAZON"},
+		{"D1 CALLE 80 BOGOTA", "D1"},
+		{"7ELEVEN #1234", "7ELEVEN"},
+		// Legal forms are dropped when their letters are adjacent, not single words
+		{"ALMACENES EXITO S.A.S. SANTA MARTA", "ALMACENES EXITO"},
+		{"CLARO S A", "CLARO"},
+		{"CAFE A LA MODE", "CAFE A LA MODE"},
+		{"TALLER S MOTOS", "TALLER S MOTOS"},
+		// Multi-word places are only dropped as a whole, and never the whole name
+		{"SANTA MARTA", "SANTA MARTA"},
+		{"FARMACIA SANTA", "FARMACIA SANTA"},
+		{"PIZZERIA NEW YORK", "PIZZERIA"},
+		{"BOGOTA D.C.", "BOGOTA"},
+		{"HOTEL SAN MARTIN", "HOTEL SAN MARTIN"},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.description, nil); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestCleanExtraWords(t *testing.T) {
+	if got := Clean("TIENDA D1 ZIPAQUIRA", map[string]bool{"ZIPAQUIRA": true}); got != "TIENDA D1" {
+		t.Errorf("Clean = %q, want TIENDA D1", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Package payee turns raw statement descriptions into canonical payees, so charges
+// of one merchant ("UBER *TRIP HELP.UBER.COM", "UBER   BV", "DLO*UBER RIDES") can be
+// reported together. Descriptions are cleaned of processor, store and terminal noise
+// (see Clean) and the cleaned text is mapped to a payee through a user-editable
+// alias table.
+package payee
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Payee is a canonical merchant name and the cleaned descriptions that refer to it
+type Payee struct {
+	// Name is the payee shown in reports, e.g. "Uber"
+	Name string `yaml:"name"`
+
+	// Aliases are matched against the cleaned description: an alias matches when the
+	// description equals it or starts with it followed by more words. Aliases are
+	// cleaned like descriptions, so raw statement text may be pasted as is.
+	Aliases []string `yaml:"aliases"`
+}
+
+// Registry maps cleaned descriptions to canonical payees
+type Registry struct {
+	Payees []*Payee `yaml:"payees"`
+
+	// Strip lists extra words to drop from descriptions, e.g. local city names
+	Strip []string `yaml:"strip"`
+
+	strip   map[string]bool
+	aliases []alias
+}
+
+type alias struct {
+	text  string
+	payee *Payee
+}
+
+// Load reads the payee registry from a YAML file.
+// A missing file is not an error; it yields a registry without aliases, so payees
+// are the cleaned descriptions.
+func Load(path string) (*Registry, error) {
+	r := &Registry{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payee registry %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse payee registry %s: %v", path, err)
+	}
+	if err := r.index(); err != nil {
+		return nil, fmt.Errorf("payee registry %s: %v", path, err)
+	}
+	return r, nil
+}
+
+// index validates the payees and sorts the aliases longest first, so "UBER EATS"
+// wins over "UBER"
+func (r *Registry) index() error {
+	r.strip = make(map[string]bool)
+	for _, w := range r.Strip {
+		r.strip[strings.ToUpper(strings.TrimSpace(w))] = true
+	}
+
+	owner := make(map[string]string)
+	for i, p := range r.Payees {
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			return fmt.Errorf("payee %d has no name", i+1)
+		}
+		// The name is an alias of itself
+		for _, a := range append([]string{p.Name}, p.Aliases...) {
+			text := Clean(a, r.strip)
+			if text == "" {
+				continue
+			}
+			if other, ok := owner[text]; ok {
+				if other != p.Name {
+					return fmt.Errorf("alias %q belongs to both %s and %s", a, other, p.Name)
+				}
+				continue
+			}
+			owner[text] = p.Name
+			r.aliases = append(r.aliases, alias{text: text, payee: p})
+		}
+	}
+	sort.SliceStable(r.aliases, func(i, j int) bool {
+		return len(r.aliases[i].text) > len(r.aliases[j].text)
+	})
+	return nil
+}
+
+// Aliases returns the number of aliases in the registry
+func (r *Registry) Aliases() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.aliases)
+}
+
+// Resolve returns the payee of a description: the canonical name when an alias
+// matches, otherwise the cleaned description
+func (r *Registry) Resolve(description string) string {
+	name, _ := r.resolve(description)
+	return name
+}
+
+func (r *Registry) resolve(description string) (string, bool) {
+	if r == nil {
+		return Clean(description, nil), false
+	}
+	cleaned := Clean(description, r.strip)
+	for _, a := range r.aliases {
+		if cleaned == a.text || strings.HasPrefix(cleaned, a.text+" ") {
+			return a.payee.Name, true
+		}
+	}
+	return cleaned, false
+}
+
+// Assign sets the payee of every transaction from its description, or from the
+// counterparty when the description holds no merchant name. It returns how many
+// transactions matched an alias.
+func (r *Registry) Assign(transactions []*models.Transaction) int {
+	matched := 0
+	for _, t := range transactions {
+		name, ok := r.resolve(t.Description)
+		if name == "" && t.Counterparty != "" {
+			name, ok = r.resolve(t.Counterparty)
+		}
+		t.Payee = name
+		if ok {
+			matched++
+		}
+	}
+	return matched
+}
@@ -0,0 +1,55 @@
+package payee
+
+import (
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func TestRegistryResolve(t *testing.T) {
+	registry, err := Load("../../config/payees.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"UBER *TRIP HELP.UBER.COM", "Uber"},
+		{"DLO*UBER RIDES", "Uber"},
+		{"UBER   BV", "Uber"},
+		// The longest alias wins
+		{"DLO*UBER EATS 8812", "Uber Eats"},
+		{"NETFLIX.COM 866-579-7172", "Netflix"},
+		{"RAPPI*COL 8842", "Rappi"},
+		// Without an alias the payee is the cleaned description
+		{"FARMATODO 0231 MEDELLIN", "FARMATODO"},
+	}
+	for _, tt := range tests {
+		if got := registry.Resolve(tt.description); got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryAssignFallsBackToCounterparty(t *testing.T) {
+	var registry *Registry
+	transactions := []*models.Transaction{
+		{Description: "8842 0042", Counterparty: "Stadtwerke GmbH"},
+		{Description: "SPOTIFY AB"},
+	}
+	registry.Assign(transactions)
+	if transactions[0].Payee != "STADTWERKE" || transactions[1].Payee != "SPOTIFY AB" {
+		t.Errorf("payees %q and %q, want STADTWERKE and SPOTIFY AB", transactions[0].Payee, transactions[1].Payee)
+	}
+}
+
+func TestLoadRejectsSharedAliases(t *testing.T) {
+	r := &Registry{Payees: []*Payee{
+		{Name: "Uber", Aliases: []string{"UBER TRIP"}},
+		{Name: "Uber Eats", Aliases: []string{"UBER *TRIP"}},
+	}}
+	if err := r.index(); err == nil {
+		t.Error("alias shared by two payees was accepted")
+	}
+}
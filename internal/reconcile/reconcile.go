@@ -0,0 +1,243 @@
+// Package reconcile checks extracted transactions against the figures printed on
+// their statement, to catch rows the extraction dropped or invented.
+//
+// Checks, each made only when the statement prints the figures it needs:
+// - Balance: the opening balance plus the extracted transactions gives the closing balance
+// - Totals: the extracted debits and credits add up to the printed totals
+// - Running balance: each row's printed balance follows from the row before it
+//
+// For credit cards and loans the balances are amounts owed, so charges raise them.
+package reconcile
+
+import (
+	"fmt"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// DefaultTolerance is the difference, in units of the statement currency, up to
+// which figures are considered equal; statements round some amounts they print
+const DefaultTolerance = 1.0
+
+// Result is the reconciliation of one statement
+type Result struct {
+	Statement    *models.Statement
+	Transactions []*models.Transaction
+
+	// Debits and Credits are the sums of the extracted transactions in the statement
+	// currency, both positive. OtherCurrency counts transactions in other currencies,
+	// which are left out of the sums.
+	Debits        models.Money
+	Credits       models.Money
+	OtherCurrency int
+
+	// BalanceGap is the amount the extracted transactions would have to add up to
+	// for the balances to reconcile: negative when debits are missing or credits
+	// were invented. DebitsGap and CreditsGap are the printed totals minus the
+	// extracted sums. A gap is zero when it is within the tolerance or not checked.
+	BalanceGap models.Money
+	DebitsGap  models.Money
+	CreditsGap models.Money
+
+	// CheckedBalance, CheckedDebits, CheckedCredits and CheckedRunning tell which
+	// checks the printed figures allowed
+	CheckedBalance bool
+	CheckedDebits  bool
+	CheckedCredits bool
+	CheckedRunning bool
+
+	// Breaks are the indexes into Transactions of the rows whose running balance does not
+	// follow from the row before, i.e. where rows are likely missing or wrong
+	Breaks []int
+}
+
+// Checked reports whether the statement printed any figure to check against
+func (r Result) Checked() bool {
+	return r.CheckedBalance || r.CheckedDebits || r.CheckedCredits || r.CheckedRunning
+}
+
+// Reconciled reports whether every check that could be made passed
+func (r Result) Reconciled() bool {
+	return r.BalanceGap.IsZero() && r.DebitsGap.IsZero() && r.CreditsGap.IsZero() && len(r.Breaks) == 0
+}
+
+// Problem describes in one line why the statement does not reconcile; empty when it does
+func (r Result) Problem() string {
+	var problem string
+	add := func(s string) {
+		if problem != "" {
+			problem += "; "
+		}
+		problem += s
+	}
+	if !r.BalanceGap.IsZero() {
+		net := r.Credits.Sub(r.Debits)
+		add(fmt.Sprintf("balance %s to %s needs a net of %s, extracted %s (gap %s)",
+			r.Statement.OpeningBalance, r.Statement.ClosingBalance, net.Add(r.BalanceGap), net, r.BalanceGap))
+	}
+	if !r.DebitsGap.IsZero() {
+		add(fmt.Sprintf("debits %s extracted, %s printed (gap %s)", r.Debits, r.Statement.TotalDebits, r.DebitsGap))
+	}
+	if !r.CreditsGap.IsZero() {
+		add(fmt.Sprintf("credits %s extracted, %s printed (gap %s)", r.Credits, r.Statement.TotalCredits, r.CreditsGap))
+	}
+	if n := len(r.Breaks); n > 0 {
+		add(fmt.Sprintf("%d running balance breaks, the first at %s %s",
+			n, r.Transactions[r.Breaks[0]].Date.Format("2006-01-02"), r.Transactions[r.Breaks[0]].Description))
+	}
+	return problem
+}
+
+// Size returns the sum of the absolute gaps, to compare two extractions of a statement
+func (r Result) Size() int64 {
+	return r.BalanceGap.Abs().Minor + r.DebitsGap.Abs().Minor + r.CreditsGap.Abs().Minor
+}
+
+// Statements reconciles the transactions of every statement, in the order the
+// statements first appear. Transactions without a statement are skipped.
+func Statements(transactions []*models.Transaction, tolerance float64) []Result {
+	var statements []*models.Statement
+	byStatement := make(map[*models.Statement][]*models.Transaction)
+	for _, t := range transactions {
+		if t.Statement == nil {
+			continue
+		}
+		if _, ok := byStatement[t.Statement]; !ok {
+			statements = append(statements, t.Statement)
+		}
+		byStatement[t.Statement] = append(byStatement[t.Statement], t)
+	}
+
+	results := make([]Result, 0, len(statements))
+	for _, stmt := range statements {
+		results = append(results, Check(stmt, byStatement[stmt], tolerance))
+	}
+	return results
+}
+
+// Check reconciles the transactions extracted from a statement, in the order they
+// were listed, with the figures printed on it. Balances and totals left at zero are
+// treated as not printed.
+func Check(stmt *models.Statement, transactions []*models.Transaction, tolerance float64) Result {
+	currency := statementCurrency(stmt)
+	r := Result{
+		Statement:    stmt,
+		Transactions: transactions,
+		Debits:       models.Money{Currency: currency},
+		Credits:      models.Money{Currency: currency},
+		BalanceGap:   models.Money{Currency: currency},
+		DebitsGap:    models.Money{Currency: currency},
+		CreditsGap:   models.Money{Currency: currency},
+	}
+	for _, t := range transactions {
+		if !inCurrency(t.Amount, currency) {
+			r.OtherCurrency++
+			continue
+		}
+		if t.Amount.IsNegative() {
+			r.Debits = r.Debits.Add(t.Amount.Neg())
+		} else {
+			r.Credits = r.Credits.Add(t.Amount)
+		}
+	}
+	limit := models.MoneyFromFloat(tolerance, currency).Abs().Minor
+	gap := func(m models.Money) models.Money {
+		if m.Abs().Minor <= limit {
+			return models.Money{Currency: currency}
+		}
+		return m
+	}
+
+	// Balances of credit cards and loans are amounts owed, which charges raise
+	owed := owes(stmt)
+	opening, closing := stmt.OpeningBalance, stmt.ClosingBalance
+	if (!opening.IsZero() || !closing.IsZero()) && inCurrency(opening, currency) && inCurrency(closing, currency) {
+		r.CheckedBalance = true
+		change := models.Money{Currency: currency}.Add(closing).Sub(opening)
+		if owed {
+			change = change.Neg()
+		}
+		r.BalanceGap = gap(change.Sub(r.Credits.Sub(r.Debits)))
+	}
+	if !stmt.TotalDebits.IsZero() && inCurrency(stmt.TotalDebits, currency) {
+		r.CheckedDebits = true
+		r.DebitsGap = gap(models.Money{Currency: currency}.Add(stmt.TotalDebits).Sub(r.Debits))
+	}
+	if !stmt.TotalCredits.IsZero() && inCurrency(stmt.TotalCredits, currency) {
+		r.CheckedCredits = true
+		r.CreditsGap = gap(models.Money{Currency: currency}.Add(stmt.TotalCredits).Sub(r.Credits))
+	}
+
+	r.Breaks, r.CheckedRunning = runningBalanceBreaks(transactions, currency, owed, limit)
+	return r
+}
+
+// runningBalanceBreaks returns the indexes of the rows whose printed balance does not
+// follow from the previous row with a printed balance. Statements list rows oldest or
+// newest first, so either direction is accepted. checked is false when fewer than two
+// rows print a balance.
+func runningBalanceBreaks(transactions []*models.Transaction, currency string, owed bool, limit int64) (breaks []int, checked bool) {
+	sign := int64(1)
+	if owed {
+		sign = -1
+	}
+	// Rows without a printed balance in between still count: the sums cover the
+	// amounts after the previous balance row (oldest first) or from it (newest first)
+	prev := -1
+	var after, from int64
+	for i, t := range transactions {
+		if !inCurrency(t.Amount, currency) {
+			continue
+		}
+		after += t.Amount.Minor
+		if t.Balance.IsZero() || !inCurrency(t.Balance, currency) {
+			from += t.Amount.Minor
+			continue
+		}
+		if prev >= 0 {
+			p := transactions[prev]
+			forward := p.Balance.Minor + sign*after - t.Balance.Minor
+			backward := t.Balance.Minor + sign*from - p.Balance.Minor
+			if abs(forward) > limit && abs(backward) > limit {
+				breaks = append(breaks, i)
+			}
+			checked = true
+		}
+		prev = i
+		after, from = 0, t.Amount.Minor
+	}
+	return breaks, checked
+}
+
+// owes reports whether the statement's balances are amounts owed
+func owes(stmt *models.Statement) bool {
+	return stmt.Account != nil && (stmt.Account.Kind == models.AccountCreditCard || stmt.Account.Kind == models.AccountLoan)
+}
+
+// statementCurrency returns the currency the statement is held in
+func statementCurrency(stmt *models.Statement) string {
+	switch {
+	case stmt.Account != nil && stmt.Account.Currency != "":
+		return stmt.Account.Currency
+	case stmt.ClosingBalance.Currency != "":
+		return stmt.ClosingBalance.Currency
+	}
+	return models.DefaultCurrency
+}
+
+// inCurrency reports whether m is in currency; amounts without a currency are
+// in the default currency
+func inCurrency(m models.Money, currency string) bool {
+	c := m.Currency
+	if c == "" {
+		c = models.DefaultCurrency
+	}
+	return c == currency
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
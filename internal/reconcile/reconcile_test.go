@@ -0,0 +1,135 @@
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func cop(minor int64) models.Money { return models.NewMoney(minor, "COP") }
+
+// rows builds transactions from (day, amount, printed balance) triples
+func rows(values ...[3]int64) []*models.Transaction {
+	var txs []*models.Transaction
+	for _, v := range values {
+		txs = append(txs, &models.Transaction{
+			Date:        time.Date(2025, 6, int(v[0]), 0, 0, 0, 0, time.UTC),
+			Description: "ROW",
+			Amount:      cop(v[1]),
+			Balance:     cop(v[2]),
+		})
+	}
+	return txs
+}
+
+func statement(kind string, opening, closing int64) *models.Statement {
+	return &models.Statement{
+		Account:        &models.Account{Kind: kind, Currency: "COP"},
+		OpeningBalance: cop(opening),
+		ClosingBalance: cop(closing),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		stmt         *models.Statement
+		transactions []*models.Transaction
+		balanceGap   int64
+		breaks       []int
+	}{
+		{
+			name:         "checking account reconciles",
+			stmt:         statement(models.AccountChecking, 100000, 70000),
+			transactions: rows([3]int64{1, -50000, 50000}, [3]int64{2, 20000, 70000}),
+		},
+		{
+			name:         "invented credit",
+			stmt:         statement(models.AccountChecking, 100000, 50000),
+			transactions: rows([3]int64{1, -50000, 0}, [3]int64{3, 5000, 0}),
+			balanceGap:   -5000,
+		},
+		{
+			name:         "dropped row leaves a gap and a break",
+			stmt:         statement(models.AccountChecking, 100000, 30000),
+			transactions: rows([3]int64{1, -50000, 50000}, [3]int64{3, -10000, 30000}),
+			balanceGap:   -10000,
+			breaks:       []int{1},
+		},
+		{
+			name:         "rows listed newest first",
+			stmt:         statement(models.AccountChecking, 100000, 30000),
+			transactions: rows([3]int64{3, -20000, 30000}, [3]int64{1, -50000, 50000}),
+		},
+		{
+			name:         "card balances are owed, so charges raise them",
+			stmt:         statement(models.AccountCreditCard, 200000, 260000),
+			transactions: rows([3]int64{1, -80000, 280000}, [3]int64{5, 20000, 260000}),
+		},
+		{
+			name:         "card read with account signs does not reconcile",
+			stmt:         statement(models.AccountCreditCard, -200000, -260000),
+			transactions: rows([3]int64{1, -80000, 0}, [3]int64{5, 20000, 0}),
+			balanceGap:   120000,
+		},
+		{
+			name:         "rounding within the tolerance",
+			stmt:         statement(models.AccountChecking, 100000, 49950),
+			transactions: rows([3]int64{1, -50000, 0}),
+		},
+	}
+	for _, tt := range tests {
+		r := Check(tt.stmt, tt.transactions, DefaultTolerance)
+		if r.BalanceGap.Minor != tt.balanceGap {
+			t.Errorf("%s: BalanceGap = %d, want %d (%s)", tt.name, r.BalanceGap.Minor, tt.balanceGap, r.Problem())
+		}
+		if !reflect.DeepEqual(r.Breaks, tt.breaks) {
+			t.Errorf("%s: Breaks = %v, want %v", tt.name, r.Breaks, tt.breaks)
+		}
+		if r.Reconciled() != (tt.balanceGap == 0 && len(tt.breaks) == 0) {
+			t.Errorf("%s: Reconciled() = %v with problem %q", tt.name, r.Reconciled(), r.Problem())
+		}
+	}
+}
+
+func TestCheckTotals(t *testing.T) {
+	stmt := &models.Statement{
+		Account:      &models.Account{Kind: models.AccountCreditCard, Currency: "COP"},
+		TotalDebits:  cop(90000),
+		TotalCredits: cop(20000),
+	}
+	txs := rows([3]int64{1, -80000, 0}, [3]int64{5, 20000, 0})
+	txs = append(txs, &models.Transaction{Amount: models.NewMoney(-1500, "USD")})
+
+	r := Check(stmt, txs, DefaultTolerance)
+	if r.CheckedBalance || r.CheckedRunning {
+		t.Errorf("checked balances that were not printed: %+v", r)
+	}
+	if !r.CheckedDebits || r.DebitsGap.Minor != 10000 {
+		t.Errorf("DebitsGap = %s, want 100.00", r.DebitsGap)
+	}
+	if !r.CheckedCredits || !r.CreditsGap.IsZero() {
+		t.Errorf("CreditsGap = %s, want 0", r.CreditsGap)
+	}
+	if r.OtherCurrency != 1 {
+		t.Errorf("OtherCurrency = %d, want 1", r.OtherCurrency)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	a := statement(models.AccountChecking, 100000, 50000)
+	b := statement(models.AccountSavings, 0, 0)
+	txs := rows([3]int64{1, -50000, 0}, [3]int64{2, 1000, 0}, [3]int64{3, -1000, 0})
+	a.Link(txs[:1])
+	b.Link(txs[1:2])
+
+	results := Statements(txs, DefaultTolerance)
+	if len(results) != 2 || results[0].Statement != a || results[1].Statement != b {
+		t.Fatalf("Statements returned %d results, want a and b in order", len(results))
+	}
+	if !results[0].Reconciled() || results[1].Checked() {
+		t.Errorf("unexpected results: %q, checked %v", results[0].Problem(), results[1].Checked())
+	}
+}
@@ -0,0 +1,67 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+// WriteReport writes the reconciliation of every checked statement to a CSV file,
+// statements that do not reconcile first. Gaps of checks not made are left blank.
+func WriteReport(path string, results []Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation report %s: %v", path, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"Status", "Statement", "Source", "Currency", "Transactions",
+		"OpeningBalance", "ClosingBalance", "BalanceGap",
+		"PrintedDebits", "ExtractedDebits", "DebitsGap",
+		"PrintedCredits", "ExtractedCredits", "CreditsGap",
+		"RunningBalanceBreaks", "OtherCurrencyTransactions"})
+	figure := func(checked bool, value string) string {
+		if !checked {
+			return ""
+		}
+		return value
+	}
+	write := func(reconciled bool) {
+		for _, r := range results {
+			if !r.Checked() || r.Reconciled() != reconciled {
+				continue
+			}
+			status := "reconciled"
+			if !reconciled {
+				status = "gap"
+			}
+			s := r.Statement
+			w.Write([]string{
+				status,
+				s.Label(),
+				s.Source,
+				r.Debits.Currency,
+				fmt.Sprintf("%d", len(r.Transactions)),
+				figure(r.CheckedBalance, s.OpeningBalance.Decimal()),
+				figure(r.CheckedBalance, s.ClosingBalance.Decimal()),
+				figure(r.CheckedBalance, r.BalanceGap.Decimal()),
+				figure(r.CheckedDebits, s.TotalDebits.Decimal()),
+				r.Debits.Decimal(),
+				figure(r.CheckedDebits, r.DebitsGap.Decimal()),
+				figure(r.CheckedCredits, s.TotalCredits.Decimal()),
+				r.Credits.Decimal(),
+				figure(r.CheckedCredits, r.CreditsGap.Decimal()),
+				figure(r.CheckedRunning, fmt.Sprintf("%d", len(r.Breaks))),
+				fmt.Sprintf("%d", r.OtherCurrency),
+			})
+		}
+	}
+	write(false)
+	write(true)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write reconciliation report %s: %v", path, err)
+	}
+	return nil
+}
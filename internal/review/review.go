@@ -0,0 +1,54 @@
+// Package review lets the user check the categories the model was unsure about in
+// an interactive terminal session. Each decision is saved as it is made, both to
+// the ledger and as a correction future runs learn from.
+package review
+
+import (
+	"sort"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+)
+
+// Decision is the category the user chose for a transaction under review
+type Decision struct {
+	Transaction *models.Transaction
+
+	// Accepted is true when the user kept the category the transaction had
+	Accepted bool
+
+	// Category, Subcategory and CategoryID are the chosen category;
+	// CategoryID is empty for a category typed outside the taxonomy
+	Category    string
+	Subcategory string
+	CategoryID  string
+}
+
+// NeedsReview reports whether the user should check the category of t: it was not
+// set by the user nor marked as a duplicate, and it is missing, the taxonomy
+// fallback, or below threshold
+func NeedsReview(t *models.Transaction, threshold float64, fallback *taxonomy.Category) bool {
+	switch {
+	case t.CategorizedBy == models.CategorizedByUser, t.DuplicateOf != "":
+		return false
+	case t.Category == "":
+		return true
+	case t.CategoryID == fallback.ID || (t.Category == fallback.Root().Name && t.Subcategory == fallback.Path()):
+		return true
+	}
+	return t.Confidence < threshold
+}
+
+// Queue returns the transactions that need review, least confident first
+func Queue(transactions []*models.Transaction, threshold float64, fallback *taxonomy.Category) []*models.Transaction {
+	var queue []*models.Transaction
+	for _, t := range transactions {
+		if NeedsReview(t, threshold, fallback) {
+			queue = append(queue, t)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Confidence < queue[j].Confidence
+	})
+	return queue
+}
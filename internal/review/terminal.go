@@ -0,0 +1,344 @@
+package review
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+	"github.com/KerynSuoress/finance-manager/internal/taxonomy"
+
+	"golang.org/x/term"
+)
+
+// Keys that are not plain characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyEsc       = "esc"
+	keyBackspace = "backspace"
+	keyInterrupt = "ctrl-c"
+)
+
+// pickerRows is how many categories the picker shows at once
+const pickerRows = 15
+
+// ANSI sequences used to draw the screens
+const (
+	clearScreen = "\x1b[2J\x1b[H"
+	bold        = "\x1b[1m"
+	dim         = "\x1b[2m"
+	reverse     = "\x1b[7m"
+	reset       = "\x1b[0m"
+)
+
+// session is one interactive review of a queue of transactions
+type session struct {
+	in         *os.File
+	out        *bufio.Writer
+	categories *taxonomy.Taxonomy
+	queue      []*models.Transaction
+	decided    []bool
+	save       func(Decision) error
+}
+
+// Run reviews the queue in the terminal, one transaction per screen. The user accepts
+// the category, picks one from the taxonomy or types a new one; save is called with
+// every decision as it is made, so quitting early keeps the decisions so far.
+// It returns how many transactions were decided.
+func Run(queue []*models.Transaction, categories *taxonomy.Taxonomy, save func(Decision) error) (int, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, fmt.Errorf("review needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to switch the terminal to raw mode: %v", err)
+	}
+	defer term.Restore(fd, state)
+
+	s := &session{
+		in:         os.Stdin,
+		out:        bufio.NewWriter(os.Stdout),
+		categories: categories,
+		queue:      queue,
+		decided:    make([]bool, len(queue)),
+		save:       save,
+	}
+	err = s.loop()
+	s.out.WriteString(clearScreen)
+	s.out.Flush()
+
+	decided := 0
+	for _, d := range s.decided {
+		if d {
+			decided++
+		}
+	}
+	return decided, err
+}
+
+// loop shows the transactions in turn until the queue is done or the user quits
+func (s *session) loop() error {
+	message := ""
+	for i := 0; i < len(s.queue); {
+		t := s.queue[i]
+		s.drawTransaction(i, message)
+		message = ""
+
+		key, err := s.readKey()
+		if err != nil {
+			return err
+		}
+		var decision *Decision
+		switch key {
+		case "a", "A", keyEnter:
+			if t.Category == "" {
+				message = "Nothing to accept: pick or type a category"
+				continue
+			}
+			decision = &Decision{Transaction: t, Accepted: true, Category: t.Category, Subcategory: t.Subcategory, CategoryID: t.CategoryID}
+		case "p", "P":
+			c, err := s.pick(t)
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				decision = &Decision{Transaction: t, Category: c.Root().Name, Subcategory: c.Path(), CategoryID: c.ID}
+			}
+		case "t", "T":
+			text, err := s.input(t, "Category / Subcategory: ")
+			if err != nil {
+				return err
+			}
+			if decision = s.typed(t, text); decision != nil && decision.CategoryID == "" {
+				message = fmt.Sprintf("%q is not in the category taxonomy; kept as typed", text)
+			}
+		case "s", "S", "n", "N", keyRight:
+			i++
+		case "b", "B", keyLeft:
+			if i > 0 {
+				i--
+			}
+		case "q", "Q", keyEsc, keyInterrupt:
+			return nil
+		}
+
+		if decision != nil {
+			if err := s.save(*decision); err != nil {
+				return fmt.Errorf("failed to save the decision for %q: %v", t.Description, err)
+			}
+			s.decided[i] = true
+			i++
+		}
+	}
+	return nil
+}
+
+// typed turns "Category / Subcategory" typed by the user into a decision, using the
+// taxonomy's names when the category is in it
+func (s *session) typed(t *models.Transaction, text string) *Decision {
+	category, subcategory, _ := strings.Cut(text, "/")
+	category, subcategory = strings.TrimSpace(category), strings.TrimSpace(subcategory)
+	if category == "" {
+		return nil
+	}
+	if c, ok := s.categories.Find(category, subcategory); ok {
+		return &Decision{Transaction: t, Category: c.Root().Name, Subcategory: c.Path(), CategoryID: c.ID}
+	}
+	return &Decision{Transaction: t, Category: category, Subcategory: subcategory}
+}
+
+// drawTransaction shows transaction i of the queue with its current category
+func (s *session) drawTransaction(i int, message string) {
+	t := s.queue[i]
+	s.printf("%s%sReview %d of %d%s", clearScreen, bold, i+1, len(s.queue), reset)
+	if s.decided[i] {
+		s.printf("  (decided)")
+	}
+	s.printf("\n\n")
+	s.describe(t)
+
+	if t.Category == "" {
+		s.printf("Category:    %s(uncategorized)%s\n", dim, reset)
+	} else {
+		s.printf("Category:    %s%s%s\n", bold, categoryLabel(t.Category, t.Subcategory), reset)
+		s.printf("Confidence:  %.2f", t.Confidence)
+		if t.CategorizedBy != "" {
+			s.printf(" by %s", t.CategorizedBy)
+		}
+		s.printf("\n")
+	}
+	if message != "" {
+		s.printf("\n%s\n", message)
+	}
+	s.printf("\n%s[a]ccept  [p]ick from categories  [t]ype a category  [s]kip  [b]ack  [q]uit%s\n", dim, reset)
+	s.out.Flush()
+}
+
+// describe prints the details of a transaction
+func (s *session) describe(t *models.Transaction) {
+	s.printf("Date:        %s\n", t.Date.Format("2006-01-02"))
+	s.printf("Description: %s\n", t.Description)
+	if t.Payee != "" && t.Payee != t.Description {
+		s.printf("Payee:       %s\n", t.Payee)
+	}
+	s.printf("Amount:      %s (%s)\n", t.Amount, t.Type)
+	s.printf("Source:      %s\n\n", t.Source)
+}
+
+// pick lets the user choose a category from the taxonomy; typing filters the list.
+// It returns nil when the user cancels.
+func (s *session) pick(t *models.Transaction) (*taxonomy.Category, error) {
+	all := s.categories.All()
+	filter, cursor := "", 0
+	for {
+		matches := filterCategories(all, filter)
+		cursor = max(0, min(cursor, len(matches)-1))
+
+		s.printf("%s%sPick a category%s for %s (%s)\n\n", clearScreen, bold, reset, t.Description, t.Amount)
+		s.printf("Filter: %s_\n\n", filter)
+		first := max(0, min(cursor-pickerRows/2, len(matches)-pickerRows))
+		for n := first; n < len(matches) && n < first+pickerRows; n++ {
+			c := matches[n]
+			label := strings.Repeat("  ", c.Depth()) + c.Name
+			if filter != "" {
+				label = categoryLabel(c.Root().Name, c.Path())
+			}
+			line := fmt.Sprintf("%s %s(%s, %s)%s", label, dim, c.ID, c.Kind, reset)
+			if n == cursor {
+				s.printf("%s>%s %s\n", reverse, reset, line)
+			} else {
+				s.printf("  %s\n", line)
+			}
+		}
+		if len(matches) == 0 {
+			s.printf("%s(no category matches)%s\n", dim, reset)
+		}
+		s.printf("\n%s↑/↓ move  type to filter  Enter select  Esc cancel%s\n", dim, reset)
+		s.out.Flush()
+
+		key, err := s.readKey()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case keyUp:
+			cursor--
+		case keyDown:
+			cursor++
+		case keyEnter:
+			if len(matches) > 0 {
+				return matches[cursor], nil
+			}
+		case keyEsc, keyInterrupt:
+			return nil, nil
+		case keyBackspace:
+			if r := []rune(filter); len(r) > 0 {
+				filter = string(r[:len(r)-1])
+			}
+		case keyLeft, keyRight:
+		default:
+			filter += key
+			cursor = 0
+		}
+	}
+}
+
+// input reads a line of text; Esc cancels and returns ""
+func (s *session) input(t *models.Transaction, prompt string) (string, error) {
+	text := ""
+	for {
+		s.printf("%s%sType a category%s\n\n", clearScreen, bold, reset)
+		s.describe(t)
+		s.printf("%s%s_\n\n", prompt, text)
+		s.printf("%sA category outside the taxonomy is kept as typed.  Enter save  Esc cancel%s\n", dim, reset)
+		s.out.Flush()
+
+		key, err := s.readKey()
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case keyEnter:
+			return strings.TrimSpace(text), nil
+		case keyEsc, keyInterrupt:
+			return "", nil
+		case keyBackspace:
+			if r := []rune(text); len(r) > 0 {
+				text = string(r[:len(r)-1])
+			}
+		case keyUp, keyDown, keyLeft, keyRight:
+		default:
+			text += key
+		}
+	}
+}
+
+// readKey reads one key press: a named key, or the typed (or pasted) text
+func (s *session) readKey() (string, error) {
+	buf := make([]byte, 64)
+	n, err := s.in.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from the terminal: %v", err)
+	}
+	b := buf[:n]
+	switch {
+	case n >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O'):
+		switch b[2] {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		}
+		return "", nil
+	case b[0] == 0x1b:
+		return keyEsc, nil
+	case b[0] == '\r' || b[0] == '\n':
+		return keyEnter, nil
+	case b[0] == 0x7f || b[0] == 0x08:
+		return keyBackspace, nil
+	case b[0] == 0x03:
+		return keyInterrupt, nil
+	case b[0] < 0x20:
+		return "", nil
+	}
+	return string(b), nil
+}
+
+// printf writes to the terminal; raw mode needs explicit carriage returns
+func (s *session) printf(format string, args ...interface{}) {
+	s.out.WriteString(strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", "\r\n"))
+}
+
+// filterCategories returns the categories whose ID, name or path contains filter
+func filterCategories(all []*taxonomy.Category, filter string) []*taxonomy.Category {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return all
+	}
+	var matches []*taxonomy.Category
+	for _, c := range all {
+		label := strings.ToLower(c.ID + " " + categoryLabel(c.Root().Name, c.Path()))
+		if strings.Contains(label, filter) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// categoryLabel joins a category and subcategory for display
+func categoryLabel(category, subcategory string) string {
+	if subcategory == "" {
+		return category
+	}
+	return category + taxonomy.PathSeparator + subcategory
+}
@@ -0,0 +1,206 @@
+// Package rules categorizes transactions with user-editable rules before any
+// are sent to the LLM. Merchants that always get the same category (supermarkets,
+// ride sharing, streaming services) are matched by a rule instead of a model call.
+package rules
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePrefix marks categories assigned by a rule in Transaction.CategorizedBy
+const SourcePrefix = "rule:"
+
+// Rule assigns a category to the transactions matching all of its conditions
+type Rule struct {
+	// Name identifies the rule in reports, e.g. "supermarkets"
+	Name string `yaml:"name"`
+
+	// Match holds the conditions; at least one is required
+	Match Match `yaml:"match"`
+
+	// Category, Subcategory and Tags are assigned with a confidence of 1.0
+	Category    string   `yaml:"category"`
+	Subcategory string   `yaml:"subcategory"`
+	Tags        []string `yaml:"tags"`
+
+	description *regexp.Regexp
+	account     *regexp.Regexp
+}
+
+// Match lists the conditions of a rule; conditions left empty are not checked
+type Match struct {
+	// Description is a regular expression matched case-insensitively against the description
+	Description string `yaml:"description"`
+
+	// MinAmount and MaxAmount bound the absolute amount, in the transaction's currency,
+	// as plain decimals (e.g. "150000" or "25.50")
+	MinAmount string `yaml:"min_amount"`
+	MaxAmount string `yaml:"max_amount"`
+
+	// Currency restricts the rule to amounts in one ISO-4217 currency
+	Currency string `yaml:"currency"`
+
+	// Account is a regular expression matched case-insensitively against the account
+	// label and key, e.g. "7002" or "^savings/"
+	Account string `yaml:"account"`
+
+	// Type is "debit" or "credit"
+	Type string `yaml:"type"`
+}
+
+// Set is an ordered list of rules; the first matching rule wins
+type Set struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Load reads categorization rules from a YAML file.
+// A missing file is not an error; it yields an empty set and every transaction goes to the model.
+func Load(path string) (*Set, error) {
+	set := &Set{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read categorization rules %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("failed to parse categorization rules %s: %v", path, err)
+	}
+
+	names := make(map[string]bool)
+	for i, r := range set.Rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("categorization rule #%d (%s): %v", i+1, r.Name, err)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("categorization rule #%d: duplicate name %q", i+1, r.Name)
+		}
+		names[r.Name] = true
+	}
+	return set, nil
+}
+
+// compile validates the rule and compiles its patterns
+func (r *Rule) compile() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(r.Category) == "" {
+		return fmt.Errorf("category is required")
+	}
+
+	m := r.Match
+	if m.Description == "" && m.MinAmount == "" && m.MaxAmount == "" && m.Currency == "" && m.Account == "" && m.Type == "" {
+		return fmt.Errorf("match needs at least one condition")
+	}
+
+	var err error
+	if m.Description != "" {
+		if r.description, err = regexp.Compile("(?i)" + m.Description); err != nil {
+			return fmt.Errorf("invalid description pattern: %v", err)
+		}
+	}
+	if m.Account != "" {
+		if r.account, err = regexp.Compile("(?i)" + m.Account); err != nil {
+			return fmt.Errorf("invalid account pattern: %v", err)
+		}
+	}
+	for _, amount := range []string{m.MinAmount, m.MaxAmount} {
+		if amount == "" {
+			continue
+		}
+		if _, err := models.ParseMoney(amount, models.DefaultCurrency); err != nil {
+			return fmt.Errorf("invalid amount bound: %v", err)
+		}
+	}
+	if m.Currency != "" && len(m.Currency) != 3 {
+		return fmt.Errorf("currency must be a three-letter ISO-4217 code")
+	}
+	switch strings.ToLower(m.Type) {
+	case "", "debit", "credit":
+	default:
+		return fmt.Errorf("type must be \"debit\" or \"credit\"")
+	}
+	return nil
+}
+
+// Matches reports whether the transaction meets every condition of the rule
+func (r *Rule) Matches(t *models.Transaction) bool {
+	m := r.Match
+	if r.description != nil && !r.description.MatchString(t.Description) {
+		return false
+	}
+	if m.Type != "" && !strings.EqualFold(m.Type, t.Type.String()) {
+		return false
+	}
+
+	currency := t.Amount.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if m.Currency != "" && !strings.EqualFold(m.Currency, currency) {
+		return false
+	}
+	amount := t.Amount.Abs()
+	if m.MinAmount != "" {
+		if lo, err := models.ParseMoney(m.MinAmount, currency); err != nil || amount.Minor < lo.Minor {
+			return false
+		}
+	}
+	if m.MaxAmount != "" {
+		if hi, err := models.ParseMoney(m.MaxAmount, currency); err != nil || amount.Minor > hi.Minor {
+			return false
+		}
+	}
+
+	if r.account != nil {
+		if t.Statement == nil || t.Statement.Account == nil {
+			return false
+		}
+		a := t.Statement.Account
+		if !r.account.MatchString(a.Label()) && !r.account.MatchString(a.Key()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the first rule matching the transaction, or nil
+func (s *Set) Match(t *models.Transaction) *Rule {
+	for _, r := range s.Rules {
+		if r.Matches(t) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Apply categorizes the transactions matched by a rule and returns the ones no rule matched
+func (s *Set) Apply(transactions []*models.Transaction) []*models.Transaction {
+	if s == nil || len(s.Rules) == 0 {
+		return transactions
+	}
+	var unmatched []*models.Transaction
+	for _, t := range transactions {
+		r := s.Match(t)
+		if r == nil {
+			unmatched = append(unmatched, t)
+			continue
+		}
+		t.Category = r.Category
+		t.Subcategory = r.Subcategory
+		t.Tags = append([]string(nil), r.Tags...)
+		t.Confidence = 1.0
+		t.CategorizedBy = SourcePrefix + r.Name
+	}
+	return unmatched
+}
@@ -0,0 +1,200 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// writeRules writes a rules file to a temporary directory and returns its path
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mustRule compiles a single rule or fails the test
+func mustRule(t *testing.T, r Rule) *Rule {
+	t.Helper()
+	if r.Name == "" {
+		r.Name = "test"
+	}
+	if r.Category == "" {
+		r.Category = "Other"
+	}
+	if err := r.compile(); err != nil {
+		t.Fatalf("compile(%+v): %v", r.Match, err)
+	}
+	return &r
+}
+
+func TestLoadShippedRules(t *testing.T) {
+	set, err := Load("../../config/rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Rules) == 0 {
+		t.Fatal("config/rules.yaml has no rules")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	set, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Rules) != 0 {
+		t.Errorf("rules = %d, want 0", len(set.Rules))
+	}
+}
+
+func TestLoadRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no name", "rules:\n  - match: {type: debit}\n    category: Food\n", "name is required"},
+		{"no category", "rules:\n  - name: a\n    match: {type: debit}\n", "category is required"},
+		{"no condition", "rules:\n  - name: a\n    category: Food\n", "at least one condition"},
+		{"bad description", "rules:\n  - name: a\n    match: {description: '('}\n    category: Food\n", "invalid description pattern"},
+		{"bad account", "rules:\n  - name: a\n    match: {account: '['}\n    category: Food\n", "invalid account pattern"},
+		{"bad amount", "rules:\n  - name: a\n    match: {min_amount: 'lots'}\n    category: Food\n", "invalid amount bound"},
+		{"bad currency", "rules:\n  - name: a\n    match: {currency: PESOS}\n    category: Food\n", "three-letter"},
+		{"bad type", "rules:\n  - name: a\n    match: {type: refund}\n    category: Food\n", "debit"},
+		{"duplicate name", "rules:\n  - name: a\n    match: {type: debit}\n    category: Food\n  - name: a\n    match: {type: credit}\n    category: Income\n", "duplicate name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeRules(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	card := &models.Statement{Account: &models.Account{Kind: models.AccountCreditCard, Number: "5412 **** **** 7002", Name: "Mastercard Black"}}
+	purchase := &models.Transaction{
+		Description: "EXITO POBLADO",
+		Amount:      models.NewMoney(-15000000, "COP"),
+		Type:        models.Debit,
+		Statement:   card,
+	}
+
+	tests := []struct {
+		name  string
+		match Match
+		want  bool
+	}{
+		{"description", Match{Description: `\bEXITO\b`}, true},
+		{"description is case-insensitive", Match{Description: "exito"}, true},
+		{"other description", Match{Description: "CARULLA"}, false},
+		{"type", Match{Type: "debit"}, true},
+		{"other type", Match{Type: "credit"}, false},
+		{"currency", Match{Currency: "cop"}, true},
+		{"other currency", Match{Currency: "USD"}, false},
+		// Bounds apply to the absolute amount, inclusively
+		{"min amount", Match{MinAmount: "150000"}, true},
+		{"below min amount", Match{MinAmount: "150000.01"}, false},
+		{"max amount", Match{MaxAmount: "150000"}, true},
+		{"above max amount", Match{MaxAmount: "149999.99"}, false},
+		{"account number", Match{Account: "7002"}, true},
+		{"account key", Match{Account: "^credit-card/"}, true},
+		{"other account", Match{Account: "^savings/"}, false},
+		{"all conditions", Match{Description: "EXITO", Type: "debit", Currency: "COP", MaxAmount: "200000", Account: "7002"}, true},
+		{"one failing condition", Match{Description: "EXITO", Type: "debit", Currency: "USD"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mustRule(t, Rule{Match: tt.match})
+			if got := r.Matches(purchase); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatchesAccountWithoutStatement(t *testing.T) {
+	r := mustRule(t, Rule{Match: Match{Account: "7002"}})
+	if r.Matches(&models.Transaction{Description: "EXITO", Amount: models.NewMoney(-100, "COP")}) {
+		t.Error("an account rule matched a transaction without a statement")
+	}
+}
+
+func TestRuleMatchesDefaultCurrency(t *testing.T) {
+	r := mustRule(t, Rule{Match: Match{Currency: models.DefaultCurrency}})
+	if !r.Matches(&models.Transaction{Amount: models.Money{Minor: -100}}) {
+		t.Errorf("a transaction without a currency did not match currency %s", models.DefaultCurrency)
+	}
+}
+
+func TestSetApply(t *testing.T) {
+	set, err := Load(writeRules(t, `rules:
+  - name: uber-eats
+    match: {description: 'UBER EATS'}
+    category: Food & Dining
+    subcategory: Delivery
+  - name: ride-sharing
+    match: {description: '\bUBER\b', type: debit}
+    category: Transportation
+    subcategory: Ride Sharing
+    tags: [commute]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eats := &models.Transaction{Description: "DLO*UBER EATS", Amount: models.NewMoney(-4500000, "COP"), Type: models.Debit}
+	trip := &models.Transaction{Description: "UBER *TRIP", Amount: models.NewMoney(-1800000, "COP"), Type: models.Debit}
+	refund := &models.Transaction{Description: "UBER *TRIP REFUND", Amount: models.NewMoney(1800000, "COP"), Type: models.Credit}
+	other := &models.Transaction{Description: "FARMATODO", Amount: models.NewMoney(-2300000, "COP"), Type: models.Debit}
+
+	unmatched := set.Apply([]*models.Transaction{eats, trip, refund, other})
+	if len(unmatched) != 2 || unmatched[0] != refund || unmatched[1] != other {
+		t.Fatalf("unmatched = %v, want the refund and FARMATODO", unmatched)
+	}
+
+	tests := []struct {
+		t           *models.Transaction
+		category    string
+		subcategory string
+		by          string
+	}{
+		// The first matching rule wins, though ride-sharing matches too
+		{eats, "Food & Dining", "Delivery", "rule:uber-eats"},
+		{trip, "Transportation", "Ride Sharing", "rule:ride-sharing"},
+	}
+	for _, tt := range tests {
+		if tt.t.Category != tt.category || tt.t.Subcategory != tt.subcategory {
+			t.Errorf("%s: category = %s / %s, want %s / %s", tt.t.Description, tt.t.Category, tt.t.Subcategory, tt.category, tt.subcategory)
+		}
+		if tt.t.CategorizedBy != tt.by {
+			t.Errorf("%s: CategorizedBy = %q, want %q", tt.t.Description, tt.t.CategorizedBy, tt.by)
+		}
+		if tt.t.Confidence != 1.0 {
+			t.Errorf("%s: Confidence = %v, want 1", tt.t.Description, tt.t.Confidence)
+		}
+	}
+	if len(trip.Tags) != 1 || trip.Tags[0] != "commute" {
+		t.Errorf("tags = %v, want [commute]", trip.Tags)
+	}
+	if other.Category != "" || other.CategorizedBy != "" {
+		t.Errorf("unmatched transaction was categorized: %s by %s", other.Category, other.CategorizedBy)
+	}
+}
+
+func TestNilSetApply(t *testing.T) {
+	var set *Set
+	transactions := []*models.Transaction{{Description: "EXITO"}}
+	if got := set.Apply(transactions); len(got) != 1 {
+		t.Errorf("Apply() on a nil set returned %d transactions, want 1", len(got))
+	}
+}
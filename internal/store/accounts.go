@@ -0,0 +1,172 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// ensureAccount returns the id of the account row for a, creating it if needed.
+// Known fields of an existing account are only overwritten by non-empty values.
+func ensureAccount(tx *sql.Tx, a *models.Account) (int64, error) {
+	key := a.Key()
+	if _, err := tx.Exec(`
+		INSERT INTO accounts (key, institution, kind, number, name, currency) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			institution = CASE WHEN excluded.institution <> '' THEN excluded.institution ELSE accounts.institution END,
+			number      = CASE WHEN excluded.number <> '' THEN excluded.number ELSE accounts.number END,
+			name        = CASE WHEN excluded.name <> '' THEN excluded.name ELSE accounts.name END,
+			currency    = CASE WHEN excluded.currency <> '' THEN excluded.currency ELSE accounts.currency END`,
+		key, a.Institution, a.Kind, a.Number, a.Name, a.Currency); err != nil {
+		return 0, fmt.Errorf("failed to upsert account %s: %v", key, err)
+	}
+	var id int64
+	if err := tx.QueryRow(`SELECT id FROM accounts WHERE key = ?`, key).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to look up account %s: %v", key, err)
+	}
+	return id, nil
+}
+
+// ensureAccountStatement returns the id of the header row of s, creating or updating it.
+// A header is identified by its statement file, account and period.
+func ensureAccountStatement(tx *sql.Tx, s *models.Statement, now string) (int64, error) {
+	statementID, err := ensureStatement(tx, s.Source, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var accountID sql.NullInt64
+	currency := s.ClosingBalance.Currency
+	if s.Account != nil {
+		id, err := ensureAccount(tx, s.Account)
+		if err != nil {
+			return 0, err
+		}
+		accountID = sql.NullInt64{Int64: id, Valid: true}
+		if s.Account.Currency != "" {
+			currency = s.Account.Currency
+		}
+	}
+
+	periodStart, periodEnd := formatOptionalDate(s.PeriodStart), formatOptionalDate(s.PeriodEnd)
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM account_statements
+		WHERE statement_id = ? AND account_id IS ? AND period_start = ? AND period_end = ?`,
+		statementID, accountID, periodStart, periodEnd).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.Exec(`INSERT INTO account_statements (
+				statement_id, account_id, period_start, period_end, due_date, currency,
+				opening_balance_minor, closing_balance_minor, minimum_payment_minor,
+				total_debits_minor, total_credits_minor
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			statementID, accountID, periodStart, periodEnd, formatOptionalDate(s.DueDate), currency,
+			s.OpeningBalance.Minor, s.ClosingBalance.Minor, s.MinimumPayment.Minor,
+			s.TotalDebits.Minor, s.TotalCredits.Minor)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert statement header for %s: %v", s.Source, err)
+		}
+		return res.LastInsertId()
+	case err != nil:
+		return 0, fmt.Errorf("failed to look up statement header for %s: %v", s.Source, err)
+	}
+
+	if _, err := tx.Exec(`UPDATE account_statements SET due_date = ?, currency = ?,
+			opening_balance_minor = ?, closing_balance_minor = ?, minimum_payment_minor = ?,
+			total_debits_minor = ?, total_credits_minor = ?
+		WHERE id = ?`,
+		formatOptionalDate(s.DueDate), currency, s.OpeningBalance.Minor, s.ClosingBalance.Minor,
+		s.MinimumPayment.Minor, s.TotalDebits.Minor, s.TotalCredits.Minor, id); err != nil {
+		return 0, fmt.Errorf("failed to update statement header for %s: %v", s.Source, err)
+	}
+	return id, nil
+}
+
+// loadStatements returns the statement headers with the given ids, keyed by id.
+// Headers of the same account share one *models.Account.
+func (s *Store) loadStatements(ids []int64) (map[int64]*models.Statement, error) {
+	result := make(map[int64]*models.Statement, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(`
+		SELECT ast.id, st.source, ast.period_start, ast.period_end, ast.due_date, ast.currency,
+		       ast.opening_balance_minor, ast.closing_balance_minor, ast.minimum_payment_minor,
+		       ast.total_debits_minor, ast.total_credits_minor,
+		       a.id, a.institution, a.kind, a.number, a.name, a.currency
+		FROM account_statements ast
+		JOIN statements st ON st.id = ast.statement_id
+		LEFT JOIN accounts a ON a.id = ast.account_id
+		WHERE ast.id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statement headers: %v", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[int64]*models.Account)
+	for rows.Next() {
+		var (
+			id                              int64
+			stmt                            models.Statement
+			periodStart, periodEnd, dueDate string
+			currency                        string
+			opening, closing, minimum       int64
+			totalDebits, totalCredits       int64
+			accountID                       sql.NullInt64
+			institution, kind, number, name sql.NullString
+			accountCurrency                 sql.NullString
+		)
+		if err := rows.Scan(&id, &stmt.Source, &periodStart, &periodEnd, &dueDate, &currency,
+			&opening, &closing, &minimum, &totalDebits, &totalCredits, &accountID, &institution, &kind, &number, &name,
+			&accountCurrency); err != nil {
+			return nil, fmt.Errorf("failed to scan statement header: %v", err)
+		}
+
+		for _, d := range []struct {
+			text   string
+			target *time.Time
+		}{{periodStart, &stmt.PeriodStart}, {periodEnd, &stmt.PeriodEnd}, {dueDate, &stmt.DueDate}} {
+			if d.text == "" {
+				continue
+			}
+			if *d.target, err = time.Parse(dateLayout, d.text); err != nil {
+				return nil, fmt.Errorf("invalid stored statement date %q: %v", d.text, err)
+			}
+		}
+		stmt.OpeningBalance = models.NewMoney(opening, currency)
+		stmt.ClosingBalance = models.NewMoney(closing, currency)
+		stmt.MinimumPayment = models.NewMoney(minimum, currency)
+		stmt.TotalDebits = models.NewMoney(totalDebits, currency)
+		stmt.TotalCredits = models.NewMoney(totalCredits, currency)
+
+		if accountID.Valid {
+			account, ok := accounts[accountID.Int64]
+			if !ok {
+				account = &models.Account{
+					Institution: institution.String,
+					Kind:        kind.String,
+					Number:      number.String,
+					Name:        name.String,
+					Currency:    accountCurrency.String,
+				}
+				accounts[accountID.Int64] = account
+			}
+			stmt.Account = account
+		}
+		result[id] = &stmt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read statement headers: %v", err)
+	}
+	return result, nil
+}
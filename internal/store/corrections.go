@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// SaveCorrections records category corrections made by the user
+func (s *Store) SaveCorrections(corrections []*models.Correction) error {
+	if len(corrections) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, c := range corrections {
+		if _, err := tx.Exec(`INSERT INTO corrections (
+				merchant, description, amount_minor, currency, type, date, source,
+				from_category, from_subcategory, category, subcategory, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.Merchant, c.Description, c.Amount.Minor, c.Amount.Currency, c.Type.String(), c.Date.Format(dateLayout),
+			c.Source, c.FromCategory, c.FromSubcategory, c.Category, c.Subcategory,
+			c.CreatedAt.UTC().Format(time.RFC3339)); err != nil {
+			return fmt.Errorf("failed to record correction of %q: %v", c.Description, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit corrections: %v", err)
+	}
+	return nil
+}
+
+// Corrections returns every recorded correction, oldest first
+func (s *Store) Corrections() ([]*models.Correction, error) {
+	rows, err := s.db.Query(`SELECT merchant, description, amount_minor, currency, type, date, source,
+			from_category, from_subcategory, category, subcategory, created_at
+		FROM corrections ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query corrections: %v", err)
+	}
+	defer rows.Close()
+
+	var result []*models.Correction
+	for rows.Next() {
+		var (
+			c                 models.Correction
+			amountMinor       int64
+			currency, txnType string
+			date, createdAt   string
+		)
+		if err := rows.Scan(&c.Merchant, &c.Description, &amountMinor, &currency, &txnType, &date, &c.Source,
+			&c.FromCategory, &c.FromSubcategory, &c.Category, &c.Subcategory, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan correction: %v", err)
+		}
+		c.Amount = models.NewMoney(amountMinor, currency)
+		c.Type = models.ParseTransactionType(txnType)
+		if c.Date, err = time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid stored correction date %q: %v", date, err)
+		}
+		if c.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("invalid stored correction time %q: %v", createdAt, err)
+		}
+		result = append(result, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corrections: %v", err)
+	}
+	return result, nil
+}
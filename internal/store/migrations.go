@@ -0,0 +1,240 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a single, ordered schema change. Versions must be strictly
+// increasing and a migration must never be edited once released; add a new
+// one instead.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations lists every schema change applied to the ledger, oldest first.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create statements and transactions",
+		statements: []string{
+			`CREATE TABLE statements (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				source      TEXT NOT NULL UNIQUE,
+				imported_at TEXT NOT NULL
+			)`,
+			`CREATE TABLE transactions (
+				id           INTEGER PRIMARY KEY AUTOINCREMENT,
+				statement_id INTEGER NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+				fingerprint  TEXT NOT NULL UNIQUE,
+				date         TEXT NOT NULL,
+				description  TEXT NOT NULL,
+				amount       REAL NOT NULL,
+				type         TEXT NOT NULL,
+				balance      REAL NOT NULL DEFAULT 0,
+				category     TEXT NOT NULL DEFAULT '',
+				subcategory  TEXT NOT NULL DEFAULT '',
+				confidence   REAL NOT NULL DEFAULT 0,
+				raw_text     TEXT NOT NULL DEFAULT '',
+				created_at   TEXT NOT NULL,
+				updated_at   TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_transactions_date ON transactions(date)`,
+			`CREATE INDEX idx_transactions_statement ON transactions(statement_id)`,
+		},
+	},
+	{
+		version:     2,
+		description: "track processed statement content hashes",
+		statements: []string{
+			`ALTER TABLE statements ADD COLUMN content_hash TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE statements ADD COLUMN processed_at TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     3,
+		description: "store bank-assigned external transaction ids",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN external_id TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     4,
+		description: "store value date, counterparty and remittance info",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN value_date TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE transactions ADD COLUMN counterparty TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE transactions ADD COLUMN remittance_info TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     5,
+		description: "store amounts as integer minor units with a currency",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN amount_minor INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE transactions ADD COLUMN balance_minor INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE transactions ADD COLUMN currency TEXT NOT NULL DEFAULT 'COP'`,
+			// Rows stored before this migration were COP amounts with two decimals
+			`UPDATE transactions SET
+				amount_minor  = CAST(ROUND(amount * 100) AS INTEGER),
+				balance_minor = CAST(ROUND(balance * 100) AS INTEGER)`,
+			`ALTER TABLE transactions DROP COLUMN amount`,
+			`ALTER TABLE transactions DROP COLUMN balance`,
+		},
+	},
+	{
+		version:     6,
+		description: "store the original amount of foreign-currency charges",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN original_amount_minor INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE transactions ADD COLUMN original_currency TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     7,
+		description: "add accounts and per-account statement headers",
+		statements: []string{
+			`CREATE TABLE accounts (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				key         TEXT NOT NULL UNIQUE,
+				institution TEXT NOT NULL DEFAULT '',
+				kind        TEXT NOT NULL DEFAULT '',
+				number      TEXT NOT NULL DEFAULT '',
+				name        TEXT NOT NULL DEFAULT '',
+				currency    TEXT NOT NULL DEFAULT ''
+			)`,
+			// statements holds one row per statement file; account_statements holds
+			// the header of each account statement found in a file
+			`CREATE TABLE account_statements (
+				id                    INTEGER PRIMARY KEY AUTOINCREMENT,
+				statement_id          INTEGER NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+				account_id            INTEGER REFERENCES accounts(id),
+				period_start          TEXT NOT NULL DEFAULT '',
+				period_end            TEXT NOT NULL DEFAULT '',
+				due_date              TEXT NOT NULL DEFAULT '',
+				currency              TEXT NOT NULL DEFAULT '',
+				opening_balance_minor INTEGER NOT NULL DEFAULT 0,
+				closing_balance_minor INTEGER NOT NULL DEFAULT 0,
+				minimum_payment_minor INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX idx_account_statements_statement ON account_statements(statement_id)`,
+			`ALTER TABLE transactions ADD COLUMN account_statement_id INTEGER REFERENCES account_statements(id) ON DELETE SET NULL`,
+		},
+	},
+	{
+		version:     8,
+		description: "store categorization tags and what assigned the category",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN tags TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE transactions ADD COLUMN categorized_by TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     9,
+		description: "record category corrections made by the user",
+		statements: []string{
+			`CREATE TABLE corrections (
+				id               INTEGER PRIMARY KEY AUTOINCREMENT,
+				merchant         TEXT NOT NULL,
+				description      TEXT NOT NULL,
+				amount_minor     INTEGER NOT NULL,
+				currency         TEXT NOT NULL,
+				type             TEXT NOT NULL,
+				date             TEXT NOT NULL,
+				source           TEXT NOT NULL,
+				from_category    TEXT NOT NULL DEFAULT '',
+				from_subcategory TEXT NOT NULL DEFAULT '',
+				category         TEXT NOT NULL,
+				subcategory      TEXT NOT NULL DEFAULT '',
+				created_at       TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_corrections_merchant ON corrections(merchant)`,
+		},
+	},
+	{
+		version:     10,
+		description: "store the taxonomy ID of each category",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN category_id TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     11,
+		description: "store the canonical payee of each transaction",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN payee TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_transactions_payee ON transactions(payee)`,
+		},
+	},
+	{
+		version:     12,
+		description: "mark transactions duplicated across statement files",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN duplicate_of TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     13,
+		description: "store the debit and credit totals printed on statements",
+		statements: []string{
+			`ALTER TABLE account_statements ADD COLUMN total_debits_minor INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE account_statements ADD COLUMN total_credits_minor INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version:     14,
+		description: "link the two legs of transfers between own accounts",
+		statements: []string{
+			`ALTER TABLE transactions ADD COLUMN transfer_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE transactions ADD COLUMN transfer_kind TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_transactions_transfer ON transactions(transfer_id)`,
+		},
+	},
+}
+
+// migrate brings the database schema up to the latest version.
+// Each pending migration runs in its own transaction together with the
+// bookkeeping row, so a failure leaves the schema at the last good version.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %v", m.version, err)
+		}
+		for _, stmt := range m.statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d (%s) failed: %v", m.version, m.description, err)
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			m.version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %v", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %v", m.version, err)
+		}
+	}
+
+	return nil
+}
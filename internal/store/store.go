@@ -0,0 +1,415 @@
+// Package store persists transactions in a local SQLite ledger so that
+// history accumulates across runs instead of living only in memory.
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const dateLayout = "2006-01-02"
+
+// Store is a SQLite-backed transaction ledger
+type Store struct {
+	db   *sql.DB
+	path string
+}
+
+// Open opens (or creates) the ledger at path and applies pending migrations
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create ledger directory: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger %s: %v", path, err)
+	}
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate ledger %s: %v", path, err)
+	}
+
+	return &Store{db: db, path: path}, nil
+}
+
+// Close releases the underlying database handle
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Path returns the location of the ledger file
+func (s *Store) Path() string { return s.path }
+
+// UpsertTransactions inserts new transactions and updates existing ones.
+// Rows are identified by a fingerprint of source, date, description, amount and
+// the occurrence number among identical rows of the same source (or of source
+// and ExternalID when the bank provides one), so re-importing a statement
+// updates its rows instead of duplicating them. A blank category on the
+// incoming row never overwrites a stored categorization, and a category set
+// by the user is only overwritten by another one set by the user.
+func (s *Store) UpsertTransactions(transactions []*models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := upsertTransactions(tx, transactions, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transactions: %v", err)
+	}
+	return nil
+}
+
+// ProcessedHash returns the content hash recorded the last time source was
+// processed, or an empty string if it has never been processed.
+func (s *Store) ProcessedHash(source string) (string, error) {
+	var hash string
+	err := s.db.QueryRow(`SELECT content_hash FROM statements WHERE source = ?`, source).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up manifest entry for %s: %v", source, err)
+	}
+	return hash, nil
+}
+
+// SaveStatement stores the transactions extracted from source and records its
+// content hash in the processed-files manifest. Rows previously stored for the
+// same source that are no longer present are removed, while rows that are
+// still present keep their categorization. Categories set by the user are
+// copied onto the matching transactions, so they also show in this run's reports.
+// An extraction without transactions changes nothing: it is more likely a failure
+// than an empty statement, so the file is not recorded and the next run retries it.
+func (s *Store) SaveStatement(source, contentHash string, transactions []*models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	statementID, err := ensureStatement(tx, source, now)
+	if err != nil {
+		return err
+	}
+
+	// Headers are rebuilt from the freshly extracted transactions
+	if _, err := tx.Exec(`DELETE FROM account_statements WHERE statement_id = ?`, statementID); err != nil {
+		return fmt.Errorf("failed to clear statement headers for %s: %v", source, err)
+	}
+
+	fingerprints, err := upsertTransactions(tx, transactions, now)
+	if err != nil {
+		return err
+	}
+
+	// Rows no longer present are stale; rows the user categorized pass their category
+	// back to the freshly extracted transaction
+	rows, err := tx.Query(`SELECT fingerprint, category, subcategory, category_id, confidence, tags, categorized_by
+		FROM transactions WHERE statement_id = ?`, statementID)
+	if err != nil {
+		return fmt.Errorf("failed to list stored transactions for %s: %v", source, err)
+	}
+	var stale []string
+	for rows.Next() {
+		var fp, category, subcategory, categoryID, tags, categorizedBy string
+		var confidence float64
+		if err := rows.Scan(&fp, &category, &subcategory, &categoryID, &confidence, &tags, &categorizedBy); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan fingerprint: %v", err)
+		}
+		t, ok := fingerprints[fp]
+		if !ok {
+			stale = append(stale, fp)
+			continue
+		}
+		if categorizedBy == models.CategorizedByUser {
+			t.Category, t.Subcategory, t.CategoryID = category, subcategory, categoryID
+			t.Confidence, t.CategorizedBy = confidence, categorizedBy
+			t.Tags = nil
+			if tags != "" {
+				t.Tags = strings.Split(tags, ",")
+			}
+		}
+	}
+	rows.Close()
+	for _, fp := range stale {
+		if _, err := tx.Exec(`DELETE FROM transactions WHERE fingerprint = ?`, fp); err != nil {
+			return fmt.Errorf("failed to remove stale transaction: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE statements SET content_hash = ?, processed_at = ? WHERE id = ?`,
+		contentHash, now, statementID); err != nil {
+		return fmt.Errorf("failed to update manifest entry for %s: %v", source, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit statement %s: %v", source, err)
+	}
+	return nil
+}
+
+// upsertTransactions writes transactions inside tx and returns the written transactions by fingerprint
+func upsertTransactions(tx *sql.Tx, transactions []*models.Transaction, now string) (map[string]*models.Transaction, error) {
+	statementIDs := make(map[string]int64)
+	headerIDs := make(map[*models.Statement]int64)
+	occurrences := make(map[string]int)
+	written := make(map[string]*models.Transaction, len(transactions))
+
+	for _, t := range transactions {
+		statementID, ok := statementIDs[t.Source]
+		if !ok {
+			var err error
+			statementID, err = ensureStatement(tx, t.Source, now)
+			if err != nil {
+				return nil, err
+			}
+			statementIDs[t.Source] = statementID
+		}
+
+		var headerID sql.NullInt64
+		if t.Statement != nil {
+			id, ok := headerIDs[t.Statement]
+			if !ok {
+				var err error
+				id, err = ensureAccountStatement(tx, t.Statement, now)
+				if err != nil {
+					return nil, err
+				}
+				headerIDs[t.Statement] = id
+			}
+			headerID = sql.NullInt64{Int64: id, Valid: true}
+		}
+
+		base := fingerprintBase(t)
+		fp := fingerprint(base, occurrences[base])
+		occurrences[base]++
+		written[fp] = t
+
+		// Categories set by the user ('user' is models.CategorizedByUser) survive re-categorization
+		_, err := tx.Exec(`
+			INSERT INTO transactions (
+				statement_id, fingerprint, date, description, amount_minor, currency, type,
+				balance_minor, category, subcategory, confidence, raw_text, external_id,
+				value_date, counterparty, remittance_info, original_amount_minor,
+				original_currency, account_statement_id, tags, categorized_by, category_id, payee, duplicate_of,
+				transfer_id, transfer_kind, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(fingerprint) DO UPDATE SET
+				statement_id    = excluded.statement_id,
+				date            = excluded.date,
+				description     = excluded.description,
+				amount_minor    = excluded.amount_minor,
+				currency        = excluded.currency,
+				type            = excluded.type,
+				balance_minor   = excluded.balance_minor,
+				raw_text        = excluded.raw_text,
+				external_id     = excluded.external_id,
+				value_date      = excluded.value_date,
+				counterparty    = excluded.counterparty,
+				remittance_info = excluded.remittance_info,
+				payee           = CASE WHEN excluded.payee <> '' THEN excluded.payee ELSE transactions.payee END,
+				duplicate_of    = excluded.duplicate_of,
+				transfer_id     = excluded.transfer_id,
+				transfer_kind   = excluded.transfer_kind,
+				original_amount_minor = excluded.original_amount_minor,
+				original_currency     = excluded.original_currency,
+				account_statement_id  = COALESCE(excluded.account_statement_id, transactions.account_statement_id),
+				category        = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.category ELSE transactions.category END,
+				subcategory     = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.subcategory ELSE transactions.subcategory END,
+				confidence      = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.confidence ELSE transactions.confidence END,
+				tags            = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.tags ELSE transactions.tags END,
+				categorized_by  = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.categorized_by ELSE transactions.categorized_by END,
+				category_id     = CASE WHEN excluded.category <> '' AND (transactions.categorized_by <> 'user' OR excluded.categorized_by = 'user') THEN excluded.category_id ELSE transactions.category_id END,
+				updated_at      = excluded.updated_at`,
+			statementID, fp, t.Date.Format(dateLayout), t.Description, t.Amount.Minor, currencyOf(t), t.Type.String(),
+			t.Balance.Minor, t.Category, t.Subcategory, t.Confidence, t.RawText, t.ExternalID, formatOptionalDate(t.ValueDate),
+			t.Counterparty, t.RemittanceInfo, t.OriginalAmount.Minor, t.OriginalAmount.Currency, headerID,
+			strings.Join(t.Tags, ","), t.CategorizedBy, t.CategoryID, t.Payee, t.DuplicateOf,
+			t.TransferID, t.TransferKind, now, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert transaction %q: %v", t.Description, err)
+		}
+	}
+
+	return written, nil
+}
+
+// Transactions returns every stored transaction dated within [from, to], oldest first.
+// A zero from or to leaves that end of the range open.
+func (s *Store) Transactions(from, to time.Time) ([]*models.Transaction, error) {
+	query := selectTransactions + ` WHERE 1 = 1`
+	var args []interface{}
+	if !from.IsZero() {
+		query += ` AND t.date >= ?`
+		args = append(args, from.Format(dateLayout))
+	}
+	if !to.IsZero() {
+		query += ` AND t.date <= ?`
+		args = append(args, to.Format(dateLayout))
+	}
+	query += ` ORDER BY t.date, t.id`
+
+	return s.queryTransactions(query, args...)
+}
+
+// TransactionsBySource returns the stored transactions of a single statement file
+func (s *Store) TransactionsBySource(source string) ([]*models.Transaction, error) {
+	return s.queryTransactions(selectTransactions+` WHERE st.source = ? ORDER BY t.id`, source)
+}
+
+const selectTransactions = `
+	SELECT t.date, t.description, t.amount_minor, t.currency, t.type, t.balance_minor, t.category,
+	       t.subcategory, t.confidence, t.raw_text, t.external_id, t.value_date,
+	       t.counterparty, t.remittance_info, t.original_amount_minor, t.original_currency, st.source,
+	       t.account_statement_id, t.tags, t.categorized_by, t.category_id, t.payee, t.duplicate_of,
+	       t.transfer_id, t.transfer_kind
+	FROM transactions t
+	JOIN statements st ON st.id = t.statement_id`
+
+func (s *Store) queryTransactions(query string, args ...interface{}) ([]*models.Transaction, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %v", err)
+	}
+	defer rows.Close()
+
+	var result []*models.Transaction
+	headerOf := make(map[*models.Transaction]int64)
+	var headerIDs []int64
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var (
+			t            models.Transaction
+			headerID     sql.NullInt64
+			date         string
+			valueDate    string
+			txnType      string
+			currency     string
+			amountMinor  int64
+			balanceMinor int64
+			origMinor    int64
+			origCurrency string
+			tags         string
+		)
+		if err := rows.Scan(&date, &t.Description, &amountMinor, &currency, &txnType, &balanceMinor, &t.Category,
+			&t.Subcategory, &t.Confidence, &t.RawText, &t.ExternalID, &valueDate,
+			&t.Counterparty, &t.RemittanceInfo, &origMinor, &origCurrency, &t.Source, &headerID,
+			&tags, &t.CategorizedBy, &t.CategoryID, &t.Payee, &t.DuplicateOf,
+			&t.TransferID, &t.TransferKind); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %v", err)
+		}
+		t.Date, err = time.Parse(dateLayout, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored date %q: %v", date, err)
+		}
+		if valueDate != "" {
+			if t.ValueDate, err = time.Parse(dateLayout, valueDate); err != nil {
+				return nil, fmt.Errorf("invalid stored value date %q: %v", valueDate, err)
+			}
+		}
+		t.Amount = models.NewMoney(amountMinor, currency)
+		t.Balance = models.NewMoney(balanceMinor, currency)
+		if origCurrency != "" {
+			t.OriginalAmount = models.NewMoney(origMinor, origCurrency)
+		}
+		t.Type = models.ParseTransactionType(txnType)
+		if tags != "" {
+			t.Tags = strings.Split(tags, ",")
+		}
+		if headerID.Valid {
+			headerOf[&t] = headerID.Int64
+			if !seen[headerID.Int64] {
+				seen[headerID.Int64] = true
+				headerIDs = append(headerIDs, headerID.Int64)
+			}
+		}
+		result = append(result, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transactions: %v", err)
+	}
+	rows.Close()
+
+	// Link transactions to their statement headers, sharing one *models.Statement per header
+	headers, err := s.loadStatements(headerIDs)
+	if err != nil {
+		return nil, err
+	}
+	for t, id := range headerOf {
+		t.Statement = headers[id]
+	}
+	return result, nil
+}
+
+// ensureStatement returns the id of the statement row for source, creating it if needed
+func ensureStatement(tx *sql.Tx, source string, now string) (int64, error) {
+	if _, err := tx.Exec(`INSERT INTO statements (source, imported_at) VALUES (?, ?)
+		ON CONFLICT(source) DO UPDATE SET imported_at = excluded.imported_at`, source, now); err != nil {
+		return 0, fmt.Errorf("failed to upsert statement %s: %v", source, err)
+	}
+	var id int64
+	if err := tx.QueryRow(`SELECT id FROM statements WHERE source = ?`, source).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to look up statement %s: %v", source, err)
+	}
+	return id, nil
+}
+
+// formatOptionalDate formats d for storage, using an empty string for the zero time
+func formatOptionalDate(d time.Time) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+// currencyOf returns the currency of the transaction's amount, or the default one if unset
+func currencyOf(t *models.Transaction) string {
+	if t.Amount.Currency != "" {
+		return t.Amount.Currency
+	}
+	return models.DefaultCurrency
+}
+
+func fingerprintBase(t *models.Transaction) string {
+	// A bank-assigned id identifies the row on its own, even if its text changes
+	if t.ExternalID != "" {
+		return fmt.Sprintf("%s\x00id\x00%s", t.Source, t.ExternalID)
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s", t.Source, t.Date.Format(dateLayout), t.Description, t.Amount.Decimal())
+}
+
+func fingerprint(base string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", base, occurrence)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSaveStatementRecordsHashOnlyWithTransactions(t *testing.T) {
+	s := openTestStore(t)
+
+	// An empty extraction is retried by the next run
+	if err := s.SaveStatement("june.pdf", "hash-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if hash, err := s.ProcessedHash("june.pdf"); err != nil || hash != "" {
+		t.Fatalf("hash after empty extraction = %q, %v; want none", hash, err)
+	}
+
+	transactions := []*models.Transaction{{
+		Date:        time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC),
+		Description: "EXITO",
+		Amount:      models.NewMoney(-12500050, "COP"),
+		Type:        models.Debit,
+		Source:      "june.pdf",
+	}}
+	if err := s.SaveStatement("june.pdf", "hash-1", transactions); err != nil {
+		t.Fatal(err)
+	}
+	if hash, err := s.ProcessedHash("june.pdf"); err != nil || hash != "hash-1" {
+		t.Fatalf("hash = %q, %v; want hash-1", hash, err)
+	}
+
+	// A later empty extraction keeps the stored rows and the recorded hash
+	if err := s.SaveStatement("june.pdf", "hash-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.TransactionsBySource("june.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Amount != transactions[0].Amount {
+		t.Errorf("stored transactions = %v, want the saved one", stored)
+	}
+}
@@ -0,0 +1,41 @@
+package taxonomy
+
+import "strings"
+
+// defaultCategories are the built-in top-level categories and their subcategories,
+// used when no taxonomy file exists. Subcategory IDs are "<parent>.<slug of name>".
+var defaultCategories = []struct {
+	id, name, kind string
+	children       []string
+}{
+	{"food", "Food & Dining", KindExpense, []string{"Restaurants", "Groceries", "Fast Food", "Coffee"}},
+	{"transportation", "Transportation", KindExpense, []string{"Gas", "Public Transit", "Ride Sharing", "Parking"}},
+	{"shopping", "Shopping", KindExpense, []string{"Clothing", "Electronics", "Home & Garden", "Online Shopping"}},
+	{"entertainment", "Entertainment", KindExpense, []string{"Movies", "Games", "Streaming Services", "Events"}},
+	{"health", "Health & Fitness", KindExpense, []string{"Medical", "Gym", "Pharmacy", "Wellness"}},
+	{"bills", "Bills & Utilities", KindExpense, []string{"Electricity", "Water", "Internet", "Phone"}},
+	{"income", "Income", KindIncome, []string{"Salary", "Freelance", "Investment", "Refunds"}},
+	{"banking", "Banking", KindExpense, []string{"ATM", "Fees"}},
+	{"transfers", "Transfers", KindTransfer, []string{"Between Accounts", "Credit Card Payment"}},
+	{"travel", "Travel", KindExpense, []string{"Flights", "Hotels", "Car Rental", "Tourism"}},
+	{"education", "Education", KindExpense, []string{"Tuition", "Books", "Courses"}},
+	{"insurance", "Insurance", KindExpense, []string{"Health", "Auto", "Home", "Life"}},
+	{"other", "Other", KindExpense, nil},
+}
+
+// Default returns the built-in English taxonomy with "other" as fallback
+func Default() *Taxonomy {
+	t := &Taxonomy{Fallback: "other"}
+	for _, d := range defaultCategories {
+		c := &Category{ID: d.id, Name: d.name, Kind: d.kind}
+		for _, name := range d.children {
+			slug := strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(name, " & ", " ")), " ", "-")
+			c.Children = append(c.Children, &Category{ID: d.id + "." + slug, Name: name})
+		}
+		t.Categories = append(t.Categories, c)
+	}
+	if err := t.index(); err != nil {
+		panic("taxonomy: invalid default taxonomy: " + err.Error())
+	}
+	return t
+}
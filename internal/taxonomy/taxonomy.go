@@ -0,0 +1,332 @@
+// Package taxonomy defines the categories transactions are sorted into: their IDs,
+// display names, parent/child structure and whether they count as expense, income
+// or transfer. The taxonomy is read from a config file so households can use their
+// own categories (in any language) instead of the built-in English list.
+package taxonomy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of category
+const (
+	KindExpense  = "expense"
+	KindIncome   = "income"
+	KindTransfer = "transfer"
+)
+
+// PathSeparator joins the names of nested categories, e.g. "Vehicle > Fuel"
+const PathSeparator = " > "
+
+// Category is one node of the taxonomy
+type Category struct {
+	// ID is the stable identifier the model answers with, e.g. "food.groceries"
+	ID string `yaml:"id"`
+
+	// Name is the display name used in reports, e.g. "Mercado"
+	Name string `yaml:"name"`
+
+	// Kind is expense, income or transfer; children inherit the kind of their parent
+	Kind string `yaml:"kind"`
+
+	// Children are the subcategories
+	Children []*Category `yaml:"children"`
+
+	// Parent is nil for top-level categories
+	Parent *Category `yaml:"-"`
+}
+
+// Root returns the top-level category the category belongs to
+func (c *Category) Root() *Category {
+	for c.Parent != nil {
+		c = c.Parent
+	}
+	return c
+}
+
+// Path returns the names from below the top-level category down to c,
+// joined with PathSeparator; empty for top-level categories
+func (c *Category) Path() string {
+	var names []string
+	for n := c; n.Parent != nil; n = n.Parent {
+		names = append([]string{n.Name}, names...)
+	}
+	return strings.Join(names, PathSeparator)
+}
+
+// Depth returns 0 for top-level categories, 1 for their subcategories, and so on
+func (c *Category) Depth() int {
+	depth := 0
+	for n := c.Parent; n != nil; n = n.Parent {
+		depth++
+	}
+	return depth
+}
+
+// within reports whether c is ancestor or one of its descendants
+func (c *Category) within(ancestor *Category) bool {
+	for n := c; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Taxonomy is the tree of categories
+type Taxonomy struct {
+	// Fallback is the ID of the category that answers outside the taxonomy are mapped to
+	Fallback string `yaml:"fallback"`
+
+	// Categories are the top-level categories
+	Categories []*Category `yaml:"categories"`
+
+	byID     map[string]*Category
+	byName   map[string][]*Category
+	fallback *Category
+}
+
+// Load reads the taxonomy from a YAML file.
+// A missing file is not an error; it yields the built-in default taxonomy.
+func Load(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read category taxonomy %s: %v", path, err)
+	}
+	t := &Taxonomy{}
+	if err := yaml.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("failed to parse category taxonomy %s: %v", path, err)
+	}
+	if err := t.index(); err != nil {
+		return nil, fmt.Errorf("category taxonomy %s: %v", path, err)
+	}
+	return t, nil
+}
+
+// index validates the tree, links parents and builds the lookup tables
+func (t *Taxonomy) index() error {
+	if len(t.Categories) == 0 {
+		return fmt.Errorf("no categories defined")
+	}
+	t.byID = make(map[string]*Category)
+	t.byName = make(map[string][]*Category)
+
+	var walk func(cats []*Category, parent *Category) error
+	walk = func(cats []*Category, parent *Category) error {
+		for _, c := range cats {
+			c.ID = strings.TrimSpace(c.ID)
+			c.Name = strings.TrimSpace(c.Name)
+			c.Kind = strings.ToLower(strings.TrimSpace(c.Kind))
+			c.Parent = parent
+			if c.ID == "" {
+				return fmt.Errorf("category %q has no id", c.Name)
+			}
+			if c.Name == "" {
+				return fmt.Errorf("category %s has no name", c.ID)
+			}
+			if _, dup := t.byID[key(c.ID)]; dup {
+				return fmt.Errorf("duplicate category id %s", c.ID)
+			}
+			if c.Kind == "" && parent != nil {
+				c.Kind = parent.Kind
+			}
+			switch c.Kind {
+			case KindExpense, KindIncome, KindTransfer:
+			case "":
+				return fmt.Errorf("category %s has no kind (expense, income or transfer)", c.ID)
+			default:
+				return fmt.Errorf("category %s has unknown kind %q", c.ID, c.Kind)
+			}
+			t.byID[key(c.ID)] = c
+			t.byName[key(c.Name)] = append(t.byName[key(c.Name)], c)
+			if err := walk(c.Children, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(t.Categories, nil); err != nil {
+		return err
+	}
+
+	if t.Fallback == "" {
+		return fmt.Errorf("fallback category is required")
+	}
+	t.fallback = t.byID[key(t.Fallback)]
+	if t.fallback == nil {
+		return fmt.Errorf("fallback category %s is not defined", t.Fallback)
+	}
+	return nil
+}
+
+// key makes lookups insensitive to case and surrounding or repeated whitespace
+func key(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// FallbackCategory returns the category unknown answers are mapped to
+func (t *Taxonomy) FallbackCategory() *Category { return t.fallback }
+
+// Lookup finds a category by ID, by name when the name is unique, or by its
+// "Top-level > Sub" path of names
+func (t *Taxonomy) Lookup(label string) *Category {
+	k := key(label)
+	if k == "" {
+		return nil
+	}
+	if c, ok := t.byID[k]; ok {
+		return c
+	}
+	if cats := t.byName[k]; len(cats) == 1 {
+		return cats[0]
+	}
+	if parts := strings.Split(label, strings.TrimSpace(PathSeparator)); len(parts) > 1 {
+		if root := t.Lookup(parts[0]); root != nil {
+			return root.find(strings.Join(parts[1:], strings.TrimSpace(PathSeparator)))
+		}
+	}
+	return nil
+}
+
+// find returns the descendant of c matching label by ID, name or path below c
+func (c *Category) find(label string) *Category {
+	k := key(label)
+	parts := strings.Split(label, strings.TrimSpace(PathSeparator))
+	for _, child := range c.Children {
+		if key(child.ID) == k || key(child.Name) == k {
+			return child
+		}
+		if len(parts) > 1 && key(child.Name) == key(parts[0]) {
+			if found := child.find(strings.Join(parts[1:], strings.TrimSpace(PathSeparator))); found != nil {
+				return found
+			}
+		}
+	}
+	for _, child := range c.Children {
+		if found := child.find(label); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Resolve maps a category and subcategory answer (IDs, names or paths) to the most
+// specific matching category. A subcategory that does not belong to the category
+// is ignored. When neither is in the taxonomy, the fallback is returned with ok false.
+func (t *Taxonomy) Resolve(category, subcategory string) (c *Category, ok bool) {
+	parent := t.Lookup(category)
+	if strings.TrimSpace(subcategory) != "" {
+		if parent != nil {
+			if key(subcategory) == key(parent.ID) || key(subcategory) == key(parent.Name) {
+				return parent, true
+			}
+			if child := parent.find(subcategory); child != nil {
+				return child, true
+			}
+		}
+		if child := t.Lookup(subcategory); child != nil && (parent == nil || child.within(parent)) {
+			return child, true
+		}
+	}
+	if parent != nil {
+		return parent, true
+	}
+	return t.fallback, false
+}
+
+// Assign sets the category of the transaction to c: the top-level name as
+// Category, the path below it as Subcategory and the ID as CategoryID
+func Assign(tx *models.Transaction, c *Category) {
+	tx.Category = c.Root().Name
+	tx.Subcategory = c.Path()
+	tx.CategoryID = c.ID
+}
+
+// Normalize resolves the transaction's category into the taxonomy and assigns it.
+// Uncategorized transactions are left alone; ok is false when the labels were not
+// in the taxonomy and the fallback was assigned.
+func (t *Taxonomy) Normalize(tx *models.Transaction) (ok bool) {
+	if tx.Category == "" && tx.CategoryID == "" {
+		return true
+	}
+	var c *Category
+	if tx.CategoryID != "" {
+		c = t.byID[key(tx.CategoryID)]
+	}
+	if c == nil {
+		c, ok = t.Resolve(tx.Category, tx.Subcategory)
+	} else {
+		ok = true
+	}
+	Assign(tx, c)
+	return ok
+}
+
+// KindOf returns the kind of the transaction's category, or "" when it is
+// uncategorized or its category is not in the taxonomy
+func (t *Taxonomy) KindOf(tx *models.Transaction) string {
+	if tx.CategoryID != "" {
+		if c := t.byID[key(tx.CategoryID)]; c != nil {
+			return c.Kind
+		}
+	}
+	if tx.Category == "" {
+		return ""
+	}
+	if c, ok := t.Resolve(tx.Category, tx.Subcategory); ok {
+		return c.Kind
+	}
+	return ""
+}
+
+// PromptList renders the taxonomy for the categorization prompt, one category per
+// line indented by depth, e.g. "- food: Food & Dining (expense)"
+func (t *Taxonomy) PromptList() string {
+	var sb strings.Builder
+	var walk func(cats []*Category, depth int)
+	walk = func(cats []*Category, depth int) {
+		for _, c := range cats {
+			sb.WriteString(fmt.Sprintf("%s- %s: %s (%s)\n", strings.Repeat("  ", depth), c.ID, c.Name, c.Kind))
+			walk(c.Children, depth+1)
+		}
+	}
+	walk(t.Categories, 0)
+	return sb.String()
+}
+
+// Find resolves a category typed by the user. Unlike Resolve, it only succeeds when
+// the subcategory, if given, is in the taxonomy too, so a new subcategory typed under
+// a known category is not silently dropped.
+func (t *Taxonomy) Find(category, subcategory string) (*Category, bool) {
+	c, ok := t.Resolve(category, subcategory)
+	if !ok {
+		return nil, false
+	}
+	if sub := key(subcategory); sub != "" && c.Parent == nil && sub != key(c.ID) && sub != key(c.Name) {
+		return nil, false
+	}
+	return c, true
+}
+
+// All returns every category, each parent followed by its subcategories
+func (t *Taxonomy) All() []*Category {
+	var all []*Category
+	var walk func(cats []*Category)
+	walk = func(cats []*Category) {
+		for _, c := range cats {
+			all = append(all, c)
+			walk(c.Children)
+		}
+	}
+	walk(t.Categories)
+	return all
+}
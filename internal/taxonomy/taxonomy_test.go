@@ -0,0 +1,277 @@
+package taxonomy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// household is a taxonomy nested three levels deep, with a subcategory name
+// ("Comisiones") used under two categories
+const household = `fallback: other
+categories:
+  - id: home
+    name: Hogar
+    kind: expense
+    children:
+      - id: home.rent
+        name: Arriendo
+      - id: home.services
+        name: Servicios
+        children:
+          - id: home.services.power
+            name: Luz
+          - id: home.services.fees
+            name: Comisiones
+  - id: vehicle
+    name: Vehículo
+    kind: expense
+    children:
+      - id: vehicle.fuel
+        name: Gasolina
+      - id: vehicle.fees
+        name: Comisiones
+  - id: income
+    name: Ingresos
+    kind: income
+    children:
+      - id: income.salary
+        name: Salario
+  - id: other
+    name: Otros
+    kind: expense
+`
+
+// load writes content to a temporary taxonomy file and loads it
+func load(t *testing.T, content string) (*Taxonomy, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func mustLoad(t *testing.T) *Taxonomy {
+	t.Helper()
+	tax, err := load(t, household)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tax
+}
+
+// id returns the ID of c, or "" for nil
+func id(c *Category) string {
+	if c == nil {
+		return ""
+	}
+	return c.ID
+}
+
+func TestLoadShippedTaxonomy(t *testing.T) {
+	tax, err := Load("../../config/categories.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tax.FallbackCategory() == nil {
+		t.Error("config/categories.yaml has no fallback category")
+	}
+}
+
+func TestLoadMissingFileUsesDefault(t *testing.T) {
+	tax, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := id(tax.Lookup("Food & Dining > Groceries")); got != "food.groceries" {
+		t.Errorf("Lookup(Food & Dining > Groceries) = %q, want food.groceries", got)
+	}
+	if got := id(tax.FallbackCategory()); got != "other" {
+		t.Errorf("fallback = %q, want other", got)
+	}
+}
+
+func TestLoadRejectsInvalidTaxonomies(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "fallback: other\n", "no categories"},
+		{"no id", "fallback: other\ncategories:\n  - name: Otros\n    kind: expense\n", "has no id"},
+		{"no name", "fallback: other\ncategories:\n  - id: other\n    kind: expense\n", "has no name"},
+		{"no kind", "fallback: other\ncategories:\n  - id: other\n    name: Otros\n", "has no kind"},
+		{"unknown kind", "fallback: other\ncategories:\n  - id: other\n    name: Otros\n    kind: savings\n", "unknown kind"},
+		{"duplicate id", "fallback: other\ncategories:\n  - id: other\n    name: Otros\n    kind: expense\n  - id: OTHER\n    name: Varios\n    kind: expense\n", "duplicate category id"},
+		{"no fallback", "categories:\n  - id: other\n    name: Otros\n    kind: expense\n", "fallback category is required"},
+		{"undefined fallback", "fallback: misc\ncategories:\n  - id: other\n    name: Otros\n    kind: expense\n", "misc is not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	tax := mustLoad(t)
+	power := tax.Lookup("home.services.power")
+	if power == nil {
+		t.Fatal("home.services.power not found")
+	}
+	if got := power.Path(); got != "Servicios > Luz" {
+		t.Errorf("Path() = %q, want %q", got, "Servicios > Luz")
+	}
+	if power.Depth() != 2 || power.Root().ID != "home" {
+		t.Errorf("Depth() = %d, Root() = %s, want 2 and home", power.Depth(), power.Root().ID)
+	}
+	// Children inherit the kind of their parent
+	if salary := tax.Lookup("income.salary"); salary.Kind != KindIncome {
+		t.Errorf("kind of income.salary = %q, want %q", salary.Kind, KindIncome)
+	}
+	if n := len(tax.All()); n != 11 {
+		t.Errorf("All() = %d categories, want 11", n)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tax := mustLoad(t)
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"home.rent", "home.rent"},
+		{"HOME.RENT", "home.rent"},
+		// Names are matched ignoring case and repeated whitespace
+		{"  arriendo ", "home.rent"},
+		{"Vehículo", "vehicle"},
+		// A name used twice is ambiguous on its own...
+		{"Comisiones", ""},
+		// ...but not under its path, with or without spaces around the separator
+		{"Vehículo > Comisiones", "vehicle.fees"},
+		{"Hogar>Servicios>Comisiones", "home.services.fees"},
+		{"Hogar > Servicios > Luz", "home.services.power"},
+		// Intermediate levels may be left out
+		{"Hogar > Luz", "home.services.power"},
+		{"Hogar > Comisiones", "home.services.fees"},
+		{"home > home.services.power", "home.services.power"},
+		// A category outside the path is not found
+		{"Hogar > Gasolina", ""},
+		{"Mascotas > Comida", ""},
+		{"", ""},
+		{"Mascotas", ""},
+	}
+	for _, tt := range tests {
+		if got := id(tax.Lookup(tt.label)); got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tax := mustLoad(t)
+	tests := []struct {
+		category, subcategory string
+		want                  string
+		ok                    bool
+	}{
+		{"home", "home.rent", "home.rent", true},
+		{"Hogar", "Arriendo", "home.rent", true},
+		{"Vehículo", "Comisiones", "vehicle.fees", true},
+		{"Hogar", "Comisiones", "home.services.fees", true},
+		{"Hogar", "Servicios > Luz", "home.services.power", true},
+		{"Hogar", "", "home", true},
+		// A subcategory naming the category itself
+		{"Hogar", "hogar", "home", true},
+		// A subcategory of another category is ignored
+		{"Hogar", "Gasolina", "home", true},
+		// An unknown category with a known subcategory
+		{"Casa", "Salario", "income.salary", true},
+		{"", "Gasolina", "vehicle.fuel", true},
+		// A subcategory that does not exist
+		{"Hogar", "Mascotas", "home", true},
+		{"Casa", "Mascotas", "other", false},
+		{"", "", "other", false},
+	}
+	for _, tt := range tests {
+		c, ok := tax.Resolve(tt.category, tt.subcategory)
+		if id(c) != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%q, %q) = %q, %v, want %q, %v", tt.category, tt.subcategory, id(c), ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tax := mustLoad(t)
+	tests := []struct {
+		category, subcategory string
+		want                  string
+	}{
+		{"Hogar", "", "home"},
+		{"Hogar", "Hogar", "home"},
+		{"Hogar", "Arriendo", "home.rent"},
+		{"Hogar", "Servicios > Luz", "home.services.power"},
+		{"vehicle", "Comisiones", "vehicle.fees"},
+		// Unlike Resolve, an unknown subcategory is not dropped
+		{"Hogar", "Mascotas", ""},
+		{"Hogar", "Gasolina", ""},
+		{"Casa", "", ""},
+	}
+	for _, tt := range tests {
+		c, ok := tax.Find(tt.category, tt.subcategory)
+		if id(c) != tt.want || ok != (tt.want != "") {
+			t.Errorf("Find(%q, %q) = %q, %v, want %q", tt.category, tt.subcategory, id(c), ok, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tax := mustLoad(t)
+	tests := []struct {
+		tx          models.Transaction
+		category    string
+		subcategory string
+		id          string
+		ok          bool
+	}{
+		// The model's answer by ID
+		{models.Transaction{Category: "home", Subcategory: "home.services.power"}, "Hogar", "Servicios > Luz", "home.services.power", true},
+		// A stored ID wins over the labels
+		{models.Transaction{Category: "Hogar", CategoryID: "vehicle.fuel"}, "Vehículo", "Gasolina", "vehicle.fuel", true},
+		{models.Transaction{Category: "Mascotas"}, "Otros", "", "other", false},
+		{models.Transaction{}, "", "", "", true},
+	}
+	for _, tt := range tests {
+		tx := tt.tx
+		ok := tax.Normalize(&tx)
+		if tx.Category != tt.category || tx.Subcategory != tt.subcategory || tx.CategoryID != tt.id || ok != tt.ok {
+			t.Errorf("Normalize(%+v) = %q, %q, %q, %v, want %q, %q, %q, %v",
+				tt.tx, tx.Category, tx.Subcategory, tx.CategoryID, ok, tt.category, tt.subcategory, tt.id, tt.ok)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tax := mustLoad(t)
+	tests := []struct {
+		tx   models.Transaction
+		want string
+	}{
+		{models.Transaction{CategoryID: "income.salary"}, KindIncome},
+		{models.Transaction{Category: "Hogar", Subcategory: "Arriendo"}, KindExpense},
+		{models.Transaction{Category: "Mascotas"}, ""},
+		{models.Transaction{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tax.KindOf(&tt.tx); got != tt.want {
+			t.Errorf("KindOf(%+v) = %q, want %q", tt.tx, got, tt.want)
+		}
+	}
+}
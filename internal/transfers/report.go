@@ -0,0 +1,43 @@
+package transfers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+// WriteReport writes the transfer pairs found to a CSV file, one row per pair
+func WriteReport(path string, pairs []Pair) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create transfers report %s: %v", path, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"TransferID", "Kind", "Amount", "Currency", "DaysApart",
+		"FromDate", "FromAccount", "FromDescription", "FromSource",
+		"ToDate", "ToAccount", "ToDescription", "ToSource"})
+	for _, p := range pairs {
+		w.Write([]string{
+			p.ID,
+			p.Kind,
+			p.In.Amount.Decimal(),
+			p.In.Amount.Currency,
+			fmt.Sprintf("%d", p.Days),
+			p.Out.Date.Format("2006-01-02"),
+			AccountLabel(p.Out),
+			p.Out.Description,
+			p.Out.Source,
+			p.In.Date.Format("2006-01-02"),
+			AccountLabel(p.In),
+			p.In.Description,
+			p.In.Source,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write transfers report %s: %v", path, err)
+	}
+	return nil
+}
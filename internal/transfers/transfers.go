@@ -0,0 +1,200 @@
+// Package transfers pairs the two legs of money moved between the user's own
+// accounts, such as a credit card paid from the savings account: a debit in one
+// statement and a credit of the same amount in another. Paired legs are neither
+// income nor expenses.
+//
+// Matching:
+// - A debit is paired with a credit of the same amount and currency in another account
+// - The legs may be booked up to a window of days apart
+// - Something must point at a transfer, see evidence
+package transfers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+// Options tune the pairing
+type Options struct {
+	// WindowDays is how many days apart the two legs may be booked
+	WindowDays int
+
+	// IsTransfer reports whether a transaction is categorized as a transfer; optional
+	IsTransfer func(*models.Transaction) bool
+}
+
+// DefaultOptions are used by the manager unless overridden with flags
+var DefaultOptions = Options{WindowDays: 3}
+
+// Pair is a transfer between two of the user's accounts
+type Pair struct {
+	// ID is shared by both legs as their TransferID; Kind is models.TransferCardPayment
+	// or models.TransferOwnAccounts
+	ID   string
+	Kind string
+
+	// Out is the debit leaving one account and In the credit reaching the other
+	Out *models.Transaction
+	In  *models.Transaction
+
+	// Days is how many days apart the legs were booked
+	Days int
+}
+
+// transferWords are description words of transfers and card payments
+var transferWords = regexp.MustCompile(`(?i)\b(?:pago|abono|transf\w*|traslado|traspaso|payment|transfer\w*|tarjeta)\b`)
+
+// Find pairs each debit with at most one credit of another account, in order,
+// and sets TransferID and TransferKind on both legs. Marks left by an earlier pass
+// are cleared first; transactions marked as duplicates are skipped.
+func Find(transactions []*models.Transaction, opts Options) []Pair {
+	Clear(transactions)
+	credits := make(map[string][]*models.Transaction)
+	for _, t := range transactions {
+		if t.DuplicateOf == "" && t.Amount.IsPositive() {
+			credits[amountKey(t)] = append(credits[amountKey(t)], t)
+		}
+	}
+
+	var pairs []Pair
+	paired := make(map[*models.Transaction]bool)
+	for _, out := range transactions {
+		if out.DuplicateOf != "" || !out.Amount.IsNegative() {
+			continue
+		}
+		var best *models.Transaction
+		bestScore, bestDays := 0, 0
+		for _, in := range credits[amountKey(out)] {
+			if paired[in] || accountKey(in) == accountKey(out) {
+				continue
+			}
+			days := daysApart(out.Date, in.Date)
+			if days > opts.WindowDays {
+				continue
+			}
+			score := evidence(out, in, opts)
+			if score == 0 {
+				continue
+			}
+			// Prefer stronger evidence, then closer dates
+			if best == nil || score > bestScore || (score == bestScore && days < bestDays) {
+				best, bestScore, bestDays = in, score, days
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		paired[best] = true
+		p := Pair{ID: pairID(out, best), Kind: models.TransferOwnAccounts, Out: out, In: best, Days: bestDays}
+		if owes(best) {
+			p.Kind = models.TransferCardPayment
+		}
+		out.TransferID, out.TransferKind = p.ID, p.Kind
+		best.TransferID, best.TransferKind = p.ID, p.Kind
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// Clear removes the transfer marks of the transactions, so they count as income and expenses again
+func Clear(transactions []*models.Transaction) {
+	for _, t := range transactions {
+		t.TransferID, t.TransferKind = "", ""
+	}
+}
+
+// evidence scores how likely the legs are a transfer rather than an unrelated debit
+// and credit of the same amount: the credit reaches a credit card or loan, a
+// description names the other account or reads like a transfer, or a leg is
+// categorized as a transfer. 0 means no evidence.
+func evidence(out, in *models.Transaction, opts Options) int {
+	score := 0
+	if owes(in) {
+		score += 2
+	}
+	if names(out, in) || names(in, out) {
+		score += 2
+	}
+	if transferWords.MatchString(out.Description) || transferWords.MatchString(in.Description) {
+		score++
+	}
+	if opts.IsTransfer != nil && (opts.IsTransfer(out) || opts.IsTransfer(in)) {
+		score++
+	}
+	return score
+}
+
+// names reports whether the description of t mentions the last digits of the account of other
+func names(t, other *models.Transaction) bool {
+	if other.Statement == nil || other.Statement.Account == nil {
+		return false
+	}
+	number := strings.Join(strings.Fields(other.Statement.Account.Number), "")
+	if len(number) < 4 {
+		return false
+	}
+	return strings.Contains(t.Description, number[len(number)-4:])
+}
+
+// owes reports whether the transaction is on a credit card or loan, where a
+// credit from another account is a payment
+func owes(t *models.Transaction) bool {
+	if t.Statement == nil || t.Statement.Account == nil {
+		return false
+	}
+	kind := t.Statement.Account.Kind
+	return kind == models.AccountCreditCard || kind == models.AccountLoan
+}
+
+// accountKey identifies the account of a transaction; the statement file stands
+// in for it when the statement header is unknown
+func accountKey(t *models.Transaction) string {
+	if t.Statement != nil && t.Statement.Account != nil {
+		return t.Statement.Account.Key()
+	}
+	return "source:" + t.Source
+}
+
+// AccountLabel names the account of a transaction for reports
+func AccountLabel(t *models.Transaction) string {
+	if t.Statement != nil && t.Statement.Account != nil {
+		return t.Statement.Account.Label()
+	}
+	return t.Source
+}
+
+// pairID derives a stable ID from both legs, so reprocessing links them the same way
+func pairID(out, in *models.Transaction) string {
+	sum := sha256.Sum256([]byte(legKey(out) + "\x00" + legKey(in)))
+	return hex.EncodeToString(sum[:6])
+}
+
+func legKey(t *models.Transaction) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s", t.Source, t.Date.Format("2006-01-02"), t.Description, t.Amount.Decimal())
+}
+
+// amountKey groups transactions by absolute amount and currency
+func amountKey(t *models.Transaction) string {
+	currency := t.Amount.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	return fmt.Sprintf("%d|%s", t.Amount.Abs().Minor, currency)
+}
+
+func daysApart(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(b.Sub(a).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
@@ -0,0 +1,154 @@
+package transfers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/models"
+)
+
+var (
+	savings  = &models.Statement{Source: "savings.pdf", Account: &models.Account{Kind: models.AccountSavings, Number: "1234 5678"}}
+	checking = &models.Statement{Source: "checking.pdf", Account: &models.Account{Kind: models.AccountChecking, Number: "9900 4321"}}
+	card     = &models.Statement{Source: "card.pdf", Account: &models.Account{Kind: models.AccountCreditCard, Number: "****7002"}}
+)
+
+func leg(stmt *models.Statement, day int, description string, minor int64) *models.Transaction {
+	return &models.Transaction{
+		Date:        time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC),
+		Description: description,
+		Amount:      models.NewMoney(minor, "COP"),
+		Source:      stmt.Source,
+		Statement:   stmt,
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []*models.Transaction
+		kind         string // of the single expected pair; empty for none
+	}{
+		{
+			name: "card payment from savings",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "DEBITO AUTOMATICO", -1200000),
+				leg(card, 6, "GRACIAS POR SU PAGO", 1200000),
+			},
+			kind: models.TransferCardPayment,
+		},
+		{
+			name: "own accounts with transfer words",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "TRANSFERENCIA SUCURSAL VIRTUAL", -300000),
+				leg(checking, 5, "ABONO", 300000),
+			},
+			kind: models.TransferOwnAccounts,
+		},
+		{
+			name: "own accounts with the other account's digits",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "ENVIO A CTA 4321", -300000),
+				leg(checking, 7, "RECIBIDO", 300000),
+			},
+			kind: models.TransferOwnAccounts,
+		},
+		{
+			name: "same amount without evidence",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "EXITO COLINA", -300000),
+				leg(checking, 5, "NOMINA EMPRESA", 300000),
+			},
+		},
+		{
+			name: "outside the window",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "PAGO TARJETA", -1200000),
+				leg(card, 12, "GRACIAS POR SU PAGO", 1200000),
+			},
+		},
+		{
+			name: "same account",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "TRANSFERENCIA", -300000),
+				leg(savings, 5, "REVERSO TRANSFERENCIA", 300000),
+			},
+		},
+		{
+			name: "different amounts",
+			transactions: []*models.Transaction{
+				leg(savings, 5, "PAGO TARJETA", -1200000),
+				leg(card, 6, "GRACIAS POR SU PAGO", 1100000),
+			},
+		},
+	}
+	for _, tt := range tests {
+		pairs := Find(tt.transactions, DefaultOptions)
+		if tt.kind == "" {
+			if len(pairs) != 0 {
+				t.Errorf("%s: paired %d transfers, want none", tt.name, len(pairs))
+			}
+			continue
+		}
+		if len(pairs) != 1 {
+			t.Errorf("%s: paired %d transfers, want 1", tt.name, len(pairs))
+			continue
+		}
+		p := pairs[0]
+		if p.Kind != tt.kind || p.Out != tt.transactions[0] || p.In != tt.transactions[1] {
+			t.Errorf("%s: pair = %s %v -> %v, want %s", tt.name, p.Kind, p.Out.Description, p.In.Description, tt.kind)
+		}
+		for _, tx := range []*models.Transaction{p.Out, p.In} {
+			if tx.TransferID != p.ID || tx.TransferKind != p.Kind {
+				t.Errorf("%s: leg %q marked %q %q, want %q %q", tt.name, tx.Description, tx.TransferID, tx.TransferKind, p.ID, p.Kind)
+			}
+		}
+	}
+}
+
+func TestFindPrefersStrongerEvidence(t *testing.T) {
+	out := leg(savings, 5, "PAGO", -500000)
+	refund := leg(checking, 5, "ABONO", 500000)
+	payment := leg(card, 7, "PAGO RECIBIDO", 500000)
+
+	pairs := Find([]*models.Transaction{out, refund, payment}, DefaultOptions)
+	if len(pairs) != 1 || pairs[0].In != payment {
+		t.Fatalf("paired %d transfers, want the card payment", len(pairs))
+	}
+	if refund.TransferID != "" {
+		t.Errorf("unpaired credit marked %q", refund.TransferID)
+	}
+}
+
+func TestFindSkipsDuplicatesAndClearsMarks(t *testing.T) {
+	out := leg(savings, 5, "PAGO TARJETA", -1200000)
+	in := leg(card, 6, "GRACIAS POR SU PAGO", 1200000)
+	transactions := []*models.Transaction{out, in}
+
+	first := Find(transactions, DefaultOptions)
+	if len(first) != 1 {
+		t.Fatalf("paired %d transfers, want 1", len(first))
+	}
+	if again := Find(transactions, DefaultOptions); len(again) != 1 || again[0].ID != first[0].ID {
+		t.Errorf("reprocessing gave a different pair ID")
+	}
+
+	in.DuplicateOf = "bank.ofx"
+	if pairs := Find(transactions, DefaultOptions); len(pairs) != 0 {
+		t.Errorf("paired %d transfers with a duplicate leg, want none", len(pairs))
+	}
+	if out.TransferID != "" || in.TransferID != "" {
+		t.Errorf("marks of the earlier pass were not cleared")
+	}
+}
+
+func TestFindIsTransfer(t *testing.T) {
+	out := leg(savings, 5, "MOVIMIENTO", -300000)
+	in := leg(checking, 5, "MOVIMIENTO", 300000)
+	opts := DefaultOptions
+	opts.IsTransfer = func(t *models.Transaction) bool { return t == in }
+
+	if pairs := Find([]*models.Transaction{out, in}, opts); len(pairs) != 1 {
+		t.Errorf("paired %d transfers, want 1 from the category", len(pairs))
+	}
+}
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KerynSuoress/finance-manager/internal/llm"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Price is what a model charges, in the price table currency per million tokens
+type Price struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// Cost returns the cost of the given token usage
+func (p Price) Cost(u llm.Usage) float64 {
+	return (float64(u.InputTokens)*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// Prices is the per-model price table
+type Prices struct {
+	// Currency the prices are quoted in, e.g. "USD"
+	Currency string `yaml:"currency"`
+
+	// Models maps a model name, or a prefix of it ending in "*", to its price
+	Models map[string]Price `yaml:"models"`
+}
+
+// LoadPrices reads the price table from a YAML file.
+// A missing file is not an error; it yields an empty table and every call costs 0.
+func LoadPrices(path string) (*Prices, error) {
+	prices := &Prices{Currency: "USD", Models: map[string]Price{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return prices, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %v", path, err)
+	}
+	for model, p := range prices.Models {
+		if p.Input < 0 || p.Output < 0 {
+			return nil, fmt.Errorf("price table %s: negative price for %s", path, model)
+		}
+	}
+	prices.Currency = strings.ToUpper(strings.TrimSpace(prices.Currency))
+	if prices.Currency == "" {
+		prices.Currency = "USD"
+	}
+	return prices, nil
+}
+
+// Lookup returns the price of a model: an exact match first, then the longest matching "prefix*" entry
+func (p *Prices) Lookup(model string) (Price, bool) {
+	if price, ok := p.Models[model]; ok {
+		return price, true
+	}
+	best, found := "", false
+	var price Price
+	for name, pr := range p.Models {
+		prefix, ok := strings.CutSuffix(name, "*")
+		if ok && strings.HasPrefix(model, prefix) && len(prefix) >= len(best) {
+			best, price, found = prefix, pr, true
+		}
+	}
+	return price, found
+}
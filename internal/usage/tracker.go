@@ -0,0 +1,305 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KerynSuoress/finance-manager/internal/llm"
+)
+
+// Phases that model calls are recorded against
+const (
+	PhaseExtraction     = "extraction"
+	PhaseCategorization = "categorization"
+)
+
+// Call is the token usage and cost of one model request attributed to one source file.
+// A request covering several files (a categorization batch) is split into one Call
+// per file, in proportion to the transactions of each file.
+type Call struct {
+	Time         time.Time `json:"time"`
+	Phase        string    `json:"phase"`
+	Source       string    `json:"source"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+
+	// Cached calls were answered from the response cache and cost nothing
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Totals aggregates calls
+type Totals struct {
+	Requests       int     `json:"requests"`
+	CachedRequests int     `json:"cached_requests"`
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	Cost           float64 `json:"cost"`
+}
+
+// add accumulates a call; first marks the first share of a request, which counts the request
+func (t *Totals) add(c Call, first bool) {
+	if first {
+		if c.Cached {
+			t.CachedRequests++
+		} else {
+			t.Requests++
+		}
+	}
+	if !c.Cached {
+		t.InputTokens += c.InputTokens
+		t.OutputTokens += c.OutputTokens
+		t.Cost += c.Cost
+	}
+}
+
+// Tracker records the token usage and cost of every model call and enforces an optional budget
+type Tracker struct {
+	prices *Prices
+	budget float64
+
+	mu        sync.Mutex
+	calls     []Call
+	firsts    []bool
+	spent     float64
+	reserved  float64
+	exhausted bool
+	unpriced  map[string]bool
+}
+
+// NewTracker creates a tracker; a budget of 0 means unlimited
+func NewTracker(prices *Prices, budget float64) *Tracker {
+	if prices == nil {
+		prices = &Prices{Currency: "USD", Models: map[string]Price{}}
+	}
+	return &Tracker{prices: prices, budget: budget, unpriced: map[string]bool{}}
+}
+
+// ErrBudgetExceeded is returned by Reserve when a request could exceed the budget
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Reservation is the worst-case cost of a request in flight, held against the budget
+// until the request is settled or released
+type Reservation struct {
+	cost float64
+}
+
+// Reserve holds the worst-case cost of a request against the budget: the estimated
+// prompt tokens plus the full MaxTokens of output. Requests in flight count with their
+// reservations, so concurrent requests cannot together exceed the budget. Every
+// reservation must be passed to Settle once the request returns, or to Release if it failed.
+// Once a request is refused every later one is refused too, so the run stops cleanly.
+// With a budget, requests to models missing from the price table are refused.
+func (t *Tracker) Reserve(req llm.Request) (Reservation, error) {
+	if t.budget <= 0 {
+		return Reservation{}, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.exhausted {
+		return Reservation{}, ErrBudgetExceeded
+	}
+	price, ok := t.prices.Lookup(req.Model)
+	if !ok {
+		t.exhausted = true
+		return Reservation{}, fmt.Errorf("%w: no price for model %s in the price table, so its cost cannot be checked against the budget",
+			ErrBudgetExceeded, req.Model)
+	}
+	estimate := price.Cost(llm.Usage{InputTokens: llm.EstimateTokens(req), OutputTokens: req.MaxTokens})
+	if t.spent+t.reserved+estimate > t.budget {
+		t.exhausted = true
+		return Reservation{}, fmt.Errorf("%w: %.4f %s spent and %.4f reserved, the next request could cost up to %.4f of the %.4f budget",
+			ErrBudgetExceeded, t.spent, t.prices.Currency, t.reserved, estimate, t.budget)
+	}
+	t.reserved += estimate
+	return Reservation{cost: estimate}, nil
+}
+
+// Release gives back the reservation of a request that failed without being billed
+func (t *Tracker) Release(r Reservation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(r)
+}
+
+// Settle replaces the reservation of a request with its actual usage, see Record
+func (t *Tracker) Settle(r Reservation, phase string, sources []string, model string, u llm.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(r)
+	t.record(phase, sources, model, u, false)
+}
+
+func (t *Tracker) release(r Reservation) {
+	t.reserved -= r.cost
+	if t.reserved < 0 {
+		t.reserved = 0
+	}
+}
+
+// Exhausted reports whether a request has been refused for exceeding the budget
+func (t *Tracker) Exhausted() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exhausted
+}
+
+// Record attributes the usage of one request to its phase and source files.
+// sources lists the source file of every item in the request (repeats are expected);
+// the usage is split between files in proportion to their items.
+func (t *Tracker) Record(phase string, sources []string, model string, u llm.Usage, cached bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(phase, sources, model, u, cached)
+}
+
+func (t *Tracker) record(phase string, sources []string, model string, u llm.Usage, cached bool) {
+	price, ok := t.prices.Lookup(model)
+	if !ok && !cached {
+		t.warnUnpriced(model)
+	}
+
+	// Count items per source, keeping first-seen order
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+	counts := map[string]int{}
+	var order []string
+	for _, s := range sources {
+		if counts[s] == 0 {
+			order = append(order, s)
+		}
+		counts[s]++
+	}
+
+	now := time.Now()
+	inputLeft, outputLeft := u.InputTokens, u.OutputTokens
+	for i, s := range order {
+		share := llm.Usage{
+			InputTokens:  u.InputTokens * counts[s] / len(sources),
+			OutputTokens: u.OutputTokens * counts[s] / len(sources),
+		}
+		// The last share takes the rounding remainder so the split adds up exactly
+		if i == len(order)-1 {
+			share = llm.Usage{InputTokens: inputLeft, OutputTokens: outputLeft}
+		}
+		inputLeft -= share.InputTokens
+		outputLeft -= share.OutputTokens
+
+		call := Call{
+			Time:         now,
+			Phase:        phase,
+			Source:       s,
+			Model:        model,
+			InputTokens:  share.InputTokens,
+			OutputTokens: share.OutputTokens,
+			Cached:       cached,
+		}
+		if !cached {
+			call.Cost = price.Cost(share)
+			t.spent += call.Cost
+		}
+		t.calls = append(t.calls, call)
+		t.firsts = append(t.firsts, i == 0)
+	}
+}
+
+// warnUnpriced prints a warning the first time a model without a price is seen
+func (t *Tracker) warnUnpriced(model string) {
+	if !t.unpriced[model] {
+		t.unpriced[model] = true
+		fmt.Printf("⚠️  Warning: No price for model %s in the price table; its calls are counted as free\n", model)
+	}
+}
+
+// Report is the usage summary of a run, as written to usage.json
+type Report struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Currency    string             `json:"currency"`
+	Budget      float64            `json:"budget,omitempty"`
+	Exhausted   bool               `json:"budget_exhausted,omitempty"`
+	Total       Totals             `json:"total"`
+	ByPhase     map[string]*Totals `json:"by_phase"`
+	ByFile      map[string]*Totals `json:"by_file"`
+	ByModel     map[string]*Totals `json:"by_model"`
+	Calls       []Call             `json:"calls"`
+}
+
+// Report aggregates the recorded calls
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := Report{
+		GeneratedAt: time.Now(),
+		Currency:    t.prices.Currency,
+		Budget:      t.budget,
+		Exhausted:   t.exhausted,
+		ByPhase:     map[string]*Totals{},
+		ByFile:      map[string]*Totals{},
+		ByModel:     map[string]*Totals{},
+		Calls:       append([]Call(nil), t.calls...),
+	}
+	group := func(m map[string]*Totals, key string) *Totals {
+		if m[key] == nil {
+			m[key] = &Totals{}
+		}
+		return m[key]
+	}
+	for i, c := range t.calls {
+		first := t.firsts[i]
+		r.Total.add(c, first)
+		group(r.ByPhase, c.Phase).add(c, first)
+		// Every share of a request counts as a request of its own file
+		group(r.ByFile, c.Source).add(c, true)
+		group(r.ByModel, c.Model).add(c, first)
+	}
+	return r
+}
+
+// WriteJSON writes the usage report to path
+func (t *Tracker) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(t.Report(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage report: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage report directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage report %s: %v", path, err)
+	}
+	return nil
+}
+
+// Summary returns the report lines printed at the end of a run
+func (r Report) Summary() []string {
+	lines := []string{fmt.Sprintf("%d requests (%d cached): %d input + %d output tokens, %.4f %s",
+		r.Total.Requests, r.Total.CachedRequests, r.Total.InputTokens, r.Total.OutputTokens, r.Total.Cost, r.Currency)}
+	for _, phase := range sortedKeys(r.ByPhase) {
+		t := r.ByPhase[phase]
+		lines = append(lines, fmt.Sprintf("  %-15s %4d requests  %9d in  %8d out  %.4f %s",
+			phase, t.Requests, t.InputTokens, t.OutputTokens, t.Cost, r.Currency))
+	}
+	if r.Budget > 0 {
+		lines = append(lines, fmt.Sprintf("Budget: %.4f of %.4f %s used", r.Total.Cost, r.Budget, r.Currency))
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]*Totals) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KerynSuoress/finance-manager/internal/llm"
+)
+
+var testPrices = &Prices{Currency: "USD", Models: map[string]Price{"model-a": {Input: 0, Output: 10}}}
+
+// request could cost up to 1.00 USD: 100000 output tokens at 10 per million
+var request = llm.Request{Model: "model-a", MaxTokens: 100000}
+
+func TestReserveHoldsInFlightRequests(t *testing.T) {
+	tracker := NewTracker(testPrices, 2.5)
+
+	first, err := tracker.Reserve(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := tracker.Reserve(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is spent yet, but the two requests in flight could cost 2.00
+	if _, err := tracker.Reserve(request); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("third concurrent request: err = %v, want ErrBudgetExceeded", err)
+	}
+	if !tracker.Exhausted() {
+		t.Error("Exhausted() = false after a refused request")
+	}
+
+	tracker.Settle(first, PhaseExtraction, []string{"june.pdf"}, "model-a", llm.Usage{OutputTokens: 1000})
+	tracker.Release(second)
+	if report := tracker.Report(); report.Total.Requests != 1 || report.Total.Cost != 0.01 {
+		t.Errorf("report total %+v, want 1 request costing 0.01", report.Total)
+	}
+	if tracker.reserved != 0 {
+		t.Errorf("reserved = %f after settling and releasing, want 0", tracker.reserved)
+	}
+}
+
+func TestReserveSettledRequestsFreeTheirReservation(t *testing.T) {
+	tracker := NewTracker(testPrices, 1.5)
+	for i := 0; i < 10; i++ {
+		r, err := tracker.Reserve(request)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		tracker.Settle(r, PhaseExtraction, []string{"june.pdf"}, "model-a", llm.Usage{OutputTokens: 1000})
+	}
+}
+
+func TestReserveUnpricedModel(t *testing.T) {
+	unpriced := llm.Request{Model: "model-b", MaxTokens: 100}
+
+	if _, err := NewTracker(testPrices, 0).Reserve(unpriced); err != nil {
+		t.Errorf("without a budget: %v", err)
+	}
+	if _, err := NewTracker(testPrices, 5).Reserve(unpriced); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("with a budget: err = %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestRecordSplitsUsageBetweenSources(t *testing.T) {
+	tracker := NewTracker(testPrices, 0)
+	tracker.Record(PhaseCategorization, []string{"a.pdf", "a.pdf", "b.pdf"}, "model-a", llm.Usage{InputTokens: 10, OutputTokens: 3000}, false)
+	tracker.Record(PhaseCategorization, []string{"b.pdf"}, "model-a", llm.Usage{OutputTokens: 5000}, true)
+
+	report := tracker.Report()
+	if a, b := report.ByFile["a.pdf"], report.ByFile["b.pdf"]; a.OutputTokens != 2000 || b.OutputTokens != 1000 || a.InputTokens+b.InputTokens != 10 {
+		t.Errorf("split %+v and %+v, want 2000 and 1000 output tokens", a, b)
+	}
+	if report.Total.Requests != 1 || report.Total.CachedRequests != 1 || report.Total.OutputTokens != 3000 {
+		t.Errorf("total %+v, want 1 request and 1 cached request of 3000 output tokens", report.Total)
+	}
+}
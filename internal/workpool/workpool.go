@@ -0,0 +1,75 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStopped is reported for items that were not started because of a stop request
+var ErrStopped = errors.New("stopped before processing")
+
+// stopKey is the context key of the stop signal
+type stopKey struct{}
+
+// WithStop returns a context carrying a stop signal. Closing stop asks the run to
+// wind down: callers check Stopped before starting a new item, while items already
+// running keep going until ctx itself is cancelled. A first interrupt closes stop,
+// a second one cancels ctx.
+func WithStop(ctx context.Context, stop <-chan struct{}) context.Context {
+	return context.WithValue(ctx, stopKey{}, stop)
+}
+
+// Stopped reports whether the stop signal carried by ctx has fired or ctx is done
+func Stopped(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	stop, _ := ctx.Value(stopKey{}).(<-chan struct{})
+	if stop == nil {
+		return false
+	}
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run calls fn for every index in [0, n) using at most workers goroutines and
+// returns once all calls are done. Callers store results by index, so the merged
+// output keeps the input order no matter which item finishes first.
+// No new items are started once ctx is cancelled; Run then returns ctx.Err().
+func Run(ctx context.Context, workers, n int, fn func(i int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,93 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunKeepsOrderAndBoundsWorkers(t *testing.T) {
+	const n, workers = 50, 4
+	results := make([]int, n)
+	var running, peak atomic.Int32
+
+	err := Run(context.Background(), workers, n, func(i int) {
+		now := running.Add(1)
+		for {
+			p := peak.Load()
+			if now <= p || peak.CompareAndSwap(p, now) {
+				break
+			}
+		}
+		// Later items finish first
+		time.Sleep(time.Duration(n-i) * 50 * time.Microsecond)
+		results[i] = i * i
+		running.Add(-1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r != i*i {
+			t.Fatalf("results[%d] = %d, want %d", i, r, i*i)
+		}
+	}
+	if p := peak.Load(); p > workers {
+		t.Errorf("%d items ran at once, want at most %d", p, workers)
+	}
+}
+
+func TestRunWithoutItems(t *testing.T) {
+	if err := Run(context.Background(), 4, 0, func(int) { t.Error("called without items") }); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunStopsDispatchingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var started []int
+	err := Run(ctx, 1, 10, func(i int) {
+		mu.Lock()
+		started = append(started, i)
+		mu.Unlock()
+		if i == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run = %v, want context.Canceled", err)
+	}
+	// The item running when ctx was cancelled finishes; at most one more was already handed out
+	if len(started) < 3 || len(started) > 4 {
+		t.Errorf("started items %v, want 0 to 2 and at most one more", started)
+	}
+}
+
+func TestStopped(t *testing.T) {
+	stop := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = WithStop(ctx, stop)
+
+	if Stopped(ctx) || Stopped(context.Background()) {
+		t.Fatal("Stopped before the stop signal")
+	}
+	close(stop)
+	if !Stopped(ctx) {
+		t.Error("Stopped = false after the stop signal")
+	}
+	// A stop request lets items already running finish: the context itself is not cancelled
+	if ctx.Err() != nil {
+		t.Errorf("stop cancelled the context: %v", ctx.Err())
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if !Stopped(cancelled) {
+		t.Error("Stopped = false for a cancelled context")
+	}
+}